@@ -90,7 +90,7 @@ func TestHelloEndpoint(t *testing.T) {
 			endpoint:       helloEndpoint,
 			expectedStatus: http.StatusOK,
 			validateFunc: func(t *testing.T, resp *http.Response, body []byte) {
-				// Note: CORS headers are set by API Gateway, not Lambda for v2 HTTP API
+				// Note: CORS headers are only sent to requests carrying an Origin header
 				validateCacheHeaders(t, resp)
 
 				var wrapper SuccessWrapper
@@ -189,7 +189,7 @@ func TestUsersEndpoint(t *testing.T) {
 			endpoint:       usersEndpoint,
 			expectedStatus: http.StatusOK,
 			validateFunc: func(t *testing.T, resp *http.Response, body []byte) {
-				// Note: CORS headers are set by API Gateway, not Lambda for v2 HTTP API
+				// Note: CORS headers are only sent to requests carrying an Origin header
 				validateCacheHeaders(t, resp)
 
 				var wrapper SuccessWrapper
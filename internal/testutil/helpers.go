@@ -22,19 +22,20 @@ import (
 // TestConfig creates a test configuration with safe defaults.
 func TestConfig() *config.Config {
 	return &config.Config{
-		ServiceName:     "test-service",
-		ServiceVersion:  "1.0.0-test",
-		Environment:     "test",
-		LogLevel:        "debug",
-		LogFormat:       "console",
-		FunctionName:    "test-function",
-		FunctionVersion: "1",
-		Region:          "us-east-1",
-		RequestTimeout:  30 * time.Second,
-		ResponseTimeout: 29 * time.Second,
-		EnableTracing:   false, // Disable tracing in tests
-		EnableMetrics:   false, // Disable metrics in tests
-		CacheMaxAge:     300,
+		ServiceName:      "test-service",
+		ServiceVersion:   "1.0.0-test",
+		Environment:      "test",
+		LogLevel:         "debug",
+		LogFormat:        "console",
+		FunctionName:     "test-function",
+		FunctionVersion:  "1",
+		Region:           "us-east-1",
+		RequestTimeout:   30 * time.Second,
+		ResponseTimeout:  29 * time.Second,
+		MinRequestBudget: 10 * time.Millisecond,
+		EnableTracing:    false, // Disable tracing in tests
+		EnableMetrics:    false, // Disable metrics in tests
+		CacheMaxAge:      300,
 	}
 }
 
@@ -247,11 +248,12 @@ func AssertResponseHeaders(t *testing.T, headers map[string]string, requiredHead
 	}
 }
 
-// AssertCORSHeaders asserts that CORS headers are properly set.
+// AssertCORSHeaders asserts that a response allows any origin under the default CORS
+// policy. Allowed methods and headers belong on preflight responses only.
 func AssertCORSHeaders(t *testing.T, headers map[string]string) {
 	assert.Equal(t, "*", headers["Access-Control-Allow-Origin"])
-	assert.Contains(t, headers, "Access-Control-Allow-Headers")
-	assert.Contains(t, headers, "Access-Control-Allow-Methods")
+	assert.NotContains(t, headers, "Access-Control-Allow-Methods")
+	assert.NotContains(t, headers, "Access-Control-Allow-Headers")
 }
 
 // AssertErrorResponse asserts that a response is a proper error response.
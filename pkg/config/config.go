@@ -28,12 +28,67 @@ type Config struct {
 	RequestTimeout  time.Duration `envconfig:"REQUEST_TIMEOUT" default:"30s"`
 	ResponseTimeout time.Duration `envconfig:"RESPONSE_TIMEOUT" default:"29s"`
 
+	// Deadline handling: time reserved before the invocation deadline for flushing logs
+	// and traces, and the least handler time worth starting a request with
+	TimeoutSafetyMargin time.Duration `envconfig:"TIMEOUT_SAFETY_MARGIN" default:"500ms"`
+	MinRequestBudget    time.Duration `envconfig:"MIN_REQUEST_BUDGET" default:"100ms"`
+
 	// Observability
 	EnableTracing bool `envconfig:"ENABLE_TRACING" default:"true"`
 	EnableMetrics bool `envconfig:"ENABLE_METRICS" default:"true"`
+	// CloudWatch namespace of published metrics; unset uses the service name
+	MetricsNamespace string `envconfig:"METRICS_NAMESPACE"`
+
+	// Redaction of logged and traced request data. Credentials, cookies and tokens are
+	// always redacted; these add field names and JSON body paths ("user.ssn",
+	// "cards[*].number"). Hash mode replaces values with a keyed hash instead of a mask
+	RedactFields    []string `envconfig:"REDACT_FIELDS"`
+	RedactBodyPaths []string `envconfig:"REDACT_BODY_PATHS"`
+	RedactMode      string   `envconfig:"REDACT_MODE" default:"mask"` // mask or hash
+	RedactHashKey   string   `envconfig:"REDACT_HASH_KEY"`
 
 	// Cache configuration
 	CacheMaxAge int `envconfig:"CACHE_MAX_AGE" default:"300"` // seconds
+
+	// Request body limits enforced by the LimitBody middleware; zero disables a limit
+	MaxBodyBytes       int `envconfig:"MAX_BODY_BYTES" default:"1048576"`
+	MaxJSONDepth       int `envconfig:"MAX_JSON_DEPTH" default:"32"`
+	MaxJSONArrayLength int `envconfig:"MAX_JSON_ARRAY_LENGTH" default:"1000"`
+	MaxJSONObjectKeys  int `envconfig:"MAX_JSON_OBJECT_KEYS" default:"1000"`
+
+	// Conditional requests: ETags computed from response bodies are weak, strong, or off
+	ETagMode string `envconfig:"ETAG_MODE" default:"weak"`
+
+	// Response compression: bodies of at least CompressionMinSize bytes are compressed
+	// when the client accepts gzip or brotli. REST APIs pass base64 bodies to clients
+	// undecoded unless binaryMediaTypes includes the response type, so they opt in
+	// separately once that is configured
+	EnableCompression  bool `envconfig:"ENABLE_COMPRESSION" default:"true"`
+	CompressionMinSize int  `envconfig:"COMPRESSION_MIN_SIZE" default:"1024"` // bytes
+	CompressRESTAPI    bool `envconfig:"COMPRESS_REST_API" default:"false"`
+
+	// Error responses
+	ErrorFormat        string `envconfig:"ERROR_FORMAT" default:"json"` // json or problem (RFC 7807)
+	ProblemTypeBaseURL string `envconfig:"PROBLEM_TYPE_BASE_URL"`       // base URL of problem type URIs
+
+	// CORS policy. Origins are exact ("https://app.example.com"), single-wildcard patterns
+	// ("https://*.example.com") or "*"; a max age of zero leaves preflight caching to browsers
+	CORSAllowedOrigins   []string      `envconfig:"CORS_ALLOWED_ORIGINS" default:"*"`
+	CORSAllowedMethods   []string      `envconfig:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	CORSAllowedHeaders   []string      `envconfig:"CORS_ALLOWED_HEADERS" default:"Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,Idempotency-Key"`
+	CORSExposedHeaders   []string      `envconfig:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials bool          `envconfig:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	CORSMaxAge           time.Duration `envconfig:"CORS_MAX_AGE" default:"10m"`
+
+	// Request authorizer: the trusted token issuer and its signing keys, API keys mapped
+	// to the principal they identify, and the API Gateway response format
+	AuthJWKSURL          string            `envconfig:"AUTH_JWKS_URL"`
+	AuthJWKSCacheTTL     time.Duration     `envconfig:"AUTH_JWKS_CACHE_TTL" default:"1h"`
+	AuthIssuer           string            `envconfig:"AUTH_ISSUER"`
+	AuthAudience         string            `envconfig:"AUTH_AUDIENCE"`
+	AuthAPIKeys          map[string]string `envconfig:"AUTH_API_KEYS"`                         // key:principal pairs
+	AuthAPIKeysParameter string            `envconfig:"AUTH_API_KEYS_PARAMETER"`               // SSM SecureString holding key:principal pairs
+	AuthResponseFormat   string            `envconfig:"AUTH_RESPONSE_FORMAT" default:"simple"` // simple or iam
 }
 
 // Load loads configuration from environment variables.
@@ -83,10 +138,43 @@ func (c *Config) validate() error {
 		return fmt.Errorf("response timeout must be less than request timeout")
 	}
 
+	if c.TimeoutSafetyMargin < 0 {
+		return fmt.Errorf("timeout safety margin cannot be negative")
+	}
+
+	if c.MinRequestBudget < 0 {
+		return fmt.Errorf("minimum request budget cannot be negative")
+	}
+
 	if c.CacheMaxAge < 0 {
 		return fmt.Errorf("cache max age cannot be negative")
 	}
 
+	if c.CompressionMinSize < 0 {
+		return fmt.Errorf("compression minimum size cannot be negative")
+	}
+
+	if c.MaxBodyBytes < 0 || c.MaxJSONDepth < 0 || c.MaxJSONArrayLength < 0 || c.MaxJSONObjectKeys < 0 {
+		return fmt.Errorf("request body limits cannot be negative")
+	}
+
+	if c.CORSMaxAge < 0 {
+		return fmt.Errorf("CORS max age cannot be negative")
+	}
+
+	// Browsers reject credentialed responses allowing any origin, so the origins must be listed
+	if c.CORSAllowCredentials {
+		for _, origin := range c.CORSAllowedOrigins {
+			if origin == "*" {
+				return fmt.Errorf("CORS credentials cannot be allowed for any origin")
+			}
+		}
+	}
+
+	if c.AuthJWKSCacheTTL < 0 {
+		return fmt.Errorf("JWKS cache TTL cannot be negative")
+	}
+
 	validLogLevels := map[string]bool{
 		"debug": true,
 		"info":  true,
@@ -109,6 +197,52 @@ func (c *Config) validate() error {
 		return fmt.Errorf("invalid log format: %s", c.LogFormat)
 	}
 
+	validErrorFormats := map[string]bool{
+		"json":    true,
+		"problem": true,
+	}
+
+	// An unset error format falls back to json
+	if c.ErrorFormat != "" && !validErrorFormats[c.ErrorFormat] {
+		return fmt.Errorf("invalid error format: %s", c.ErrorFormat)
+	}
+
+	validRedactModes := map[string]bool{
+		"mask": true,
+		"hash": true,
+	}
+
+	// An unset redaction mode falls back to mask
+	if c.RedactMode != "" && !validRedactModes[c.RedactMode] {
+		return fmt.Errorf("invalid redaction mode: %s", c.RedactMode)
+	}
+
+	// Unkeyed hashes of low-entropy values such as card numbers can be reversed by guessing
+	if c.RedactMode == "hash" && c.RedactHashKey == "" {
+		return fmt.Errorf("redaction hash key is required in hash mode")
+	}
+
+	validETagModes := map[string]bool{
+		"weak":   true,
+		"strong": true,
+		"off":    true,
+	}
+
+	// An unset ETag mode falls back to weak
+	if c.ETagMode != "" && !validETagModes[c.ETagMode] {
+		return fmt.Errorf("invalid ETag mode: %s", c.ETagMode)
+	}
+
+	validAuthResponseFormats := map[string]bool{
+		"simple": true,
+		"iam":    true,
+	}
+
+	// An unset response format falls back to simple
+	if c.AuthResponseFormat != "" && !validAuthResponseFormats[c.AuthResponseFormat] {
+		return fmt.Errorf("invalid authorizer response format: %s", c.AuthResponseFormat)
+	}
+
 	return nil
 }
 
@@ -16,7 +16,15 @@ func TestLoad(t *testing.T) {
 		"SERVICE_NAME", "SERVICE_VERSION", "ENVIRONMENT", "LOG_LEVEL", "LOG_FORMAT",
 		"AWS_LAMBDA_FUNCTION_NAME", "AWS_LAMBDA_FUNCTION_VERSION", "AWS_REGION",
 		"REQUEST_TIMEOUT", "RESPONSE_TIMEOUT", "ENABLE_TRACING", "ENABLE_METRICS",
-		"CACHE_MAX_AGE",
+		"CACHE_MAX_AGE", "ERROR_FORMAT", "PROBLEM_TYPE_BASE_URL",
+		"TIMEOUT_SAFETY_MARGIN", "MIN_REQUEST_BUDGET",
+		"AUTH_JWKS_URL", "AUTH_JWKS_CACHE_TTL", "AUTH_ISSUER", "AUTH_AUDIENCE",
+		"AUTH_API_KEYS", "AUTH_API_KEYS_PARAMETER", "AUTH_RESPONSE_FORMAT",
+		"CORS_ALLOWED_ORIGINS", "CORS_ALLOWED_METHODS", "CORS_ALLOWED_HEADERS",
+		"CORS_EXPOSED_HEADERS", "CORS_ALLOW_CREDENTIALS", "CORS_MAX_AGE",
+		"ENABLE_COMPRESSION", "COMPRESSION_MIN_SIZE", "COMPRESS_REST_API", "ETAG_MODE",
+		"METRICS_NAMESPACE", "MAX_BODY_BYTES", "MAX_JSON_DEPTH", "MAX_JSON_ARRAY_LENGTH", "MAX_JSON_OBJECT_KEYS",
+		"REDACT_FIELDS", "REDACT_BODY_PATHS", "REDACT_MODE", "REDACT_HASH_KEY",
 	}
 
 	for _, env := range envVars {
@@ -56,9 +64,28 @@ func TestLoad(t *testing.T) {
 				assert.Equal(t, "us-east-1", cfg.Region)
 				assert.Equal(t, 30*time.Second, cfg.RequestTimeout)
 				assert.Equal(t, 29*time.Second, cfg.ResponseTimeout)
+				assert.Equal(t, 500*time.Millisecond, cfg.TimeoutSafetyMargin)
+				assert.Equal(t, 100*time.Millisecond, cfg.MinRequestBudget)
 				assert.True(t, cfg.EnableTracing)
 				assert.True(t, cfg.EnableMetrics)
 				assert.Equal(t, 300, cfg.CacheMaxAge)
+				assert.True(t, cfg.EnableCompression)
+				assert.Equal(t, 1024, cfg.CompressionMinSize)
+				assert.False(t, cfg.CompressRESTAPI)
+				assert.Equal(t, "json", cfg.ErrorFormat)
+				assert.Empty(t, cfg.ProblemTypeBaseURL)
+				assert.Empty(t, cfg.AuthJWKSURL)
+				assert.Equal(t, time.Hour, cfg.AuthJWKSCacheTTL)
+				assert.Empty(t, cfg.AuthAPIKeys)
+				assert.Equal(t, "simple", cfg.AuthResponseFormat)
+				assert.Equal(t, []string{"*"}, cfg.CORSAllowedOrigins)
+				assert.Equal(t, []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}, cfg.CORSAllowedMethods)
+				assert.Contains(t, cfg.CORSAllowedHeaders, "Authorization")
+				assert.Empty(t, cfg.CORSExposedHeaders)
+				assert.False(t, cfg.CORSAllowCredentials)
+				assert.Equal(t, 10*time.Minute, cfg.CORSMaxAge)
+				assert.Empty(t, cfg.RedactFields)
+				assert.Equal(t, "mask", cfg.RedactMode)
 			},
 		},
 		{
@@ -74,9 +101,13 @@ func TestLoad(t *testing.T) {
 				"AWS_REGION":                   "us-west-2",
 				"REQUEST_TIMEOUT":              "45s",
 				"RESPONSE_TIMEOUT":             "40s",
+				"TIMEOUT_SAFETY_MARGIN":        "1s",
+				"MIN_REQUEST_BUDGET":           "250ms",
 				"ENABLE_TRACING":               "false",
 				"ENABLE_METRICS":               "false",
 				"CACHE_MAX_AGE":                "600",
+				"ERROR_FORMAT":                 "problem",
+				"PROBLEM_TYPE_BASE_URL":        "https://errors.example.com",
 			},
 			expectedError: false,
 			validateFunc: func(t *testing.T, cfg *Config) {
@@ -90,9 +121,13 @@ func TestLoad(t *testing.T) {
 				assert.Equal(t, "us-west-2", cfg.Region)
 				assert.Equal(t, 45*time.Second, cfg.RequestTimeout)
 				assert.Equal(t, 40*time.Second, cfg.ResponseTimeout)
+				assert.Equal(t, time.Second, cfg.TimeoutSafetyMargin)
+				assert.Equal(t, 250*time.Millisecond, cfg.MinRequestBudget)
 				assert.False(t, cfg.EnableTracing)
 				assert.False(t, cfg.EnableMetrics)
 				assert.Equal(t, 600, cfg.CacheMaxAge)
+				assert.Equal(t, "problem", cfg.ErrorFormat)
+				assert.Equal(t, "https://errors.example.com", cfg.ProblemTypeBaseURL)
 			},
 		},
 		{
@@ -109,6 +144,76 @@ func TestLoad(t *testing.T) {
 			},
 			expectedError: true,
 		},
+		{
+			name: "invalid error format",
+			envVars: map[string]string{
+				"ERROR_FORMAT": "xml",
+			},
+			expectedError: true,
+		},
+		{
+			name: "authorizer configuration",
+			envVars: map[string]string{
+				"AUTH_JWKS_URL":           "https://issuer.example.com/.well-known/jwks.json",
+				"AUTH_JWKS_CACHE_TTL":     "15m",
+				"AUTH_ISSUER":             "https://issuer.example.com",
+				"AUTH_AUDIENCE":           "api",
+				"AUTH_API_KEYS":           "key-1:partner-a,key-2:partner-b",
+				"AUTH_API_KEYS_PARAMETER": "/users-api/api-keys",
+				"AUTH_RESPONSE_FORMAT":    "iam",
+			},
+			expectedError: false,
+			validateFunc: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "https://issuer.example.com/.well-known/jwks.json", cfg.AuthJWKSURL)
+				assert.Equal(t, 15*time.Minute, cfg.AuthJWKSCacheTTL)
+				assert.Equal(t, "https://issuer.example.com", cfg.AuthIssuer)
+				assert.Equal(t, "api", cfg.AuthAudience)
+				assert.Equal(t, map[string]string{"key-1": "partner-a", "key-2": "partner-b"}, cfg.AuthAPIKeys)
+				assert.Equal(t, "/users-api/api-keys", cfg.AuthAPIKeysParameter)
+				assert.Equal(t, "iam", cfg.AuthResponseFormat)
+			},
+		},
+		{
+			name: "CORS configuration",
+			envVars: map[string]string{
+				"CORS_ALLOWED_ORIGINS":   "https://app.example.com,https://*.preview.example.com",
+				"CORS_ALLOWED_METHODS":   "GET,POST",
+				"CORS_ALLOWED_HEADERS":   "Content-Type,Authorization",
+				"CORS_EXPOSED_HEADERS":   "X-Request-ID",
+				"CORS_ALLOW_CREDENTIALS": "true",
+				"CORS_MAX_AGE":           "1h",
+			},
+			expectedError: false,
+			validateFunc: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, []string{"https://app.example.com", "https://*.preview.example.com"}, cfg.CORSAllowedOrigins)
+				assert.Equal(t, []string{"GET", "POST"}, cfg.CORSAllowedMethods)
+				assert.Equal(t, []string{"Content-Type", "Authorization"}, cfg.CORSAllowedHeaders)
+				assert.Equal(t, []string{"X-Request-ID"}, cfg.CORSExposedHeaders)
+				assert.True(t, cfg.CORSAllowCredentials)
+				assert.Equal(t, time.Hour, cfg.CORSMaxAge)
+			},
+		},
+		{
+			name: "CORS credentials for any origin",
+			envVars: map[string]string{
+				"CORS_ALLOW_CREDENTIALS": "true",
+			},
+			expectedError: true,
+		},
+		{
+			name: "negative CORS max age",
+			envVars: map[string]string{
+				"CORS_MAX_AGE": "-1s",
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid authorizer response format",
+			envVars: map[string]string{
+				"AUTH_RESPONSE_FORMAT": "token",
+			},
+			expectedError: true,
+		},
 		{
 			name: "invalid timeout configuration",
 			envVars: map[string]string{
@@ -117,6 +222,98 @@ func TestLoad(t *testing.T) {
 			},
 			expectedError: true,
 		},
+		{
+			name: "compression configuration",
+			envVars: map[string]string{
+				"ENABLE_COMPRESSION":   "false",
+				"COMPRESSION_MIN_SIZE": "4096",
+				"COMPRESS_REST_API":    "true",
+			},
+			expectedError: false,
+			validateFunc: func(t *testing.T, cfg *Config) {
+				assert.False(t, cfg.EnableCompression)
+				assert.Equal(t, 4096, cfg.CompressionMinSize)
+				assert.True(t, cfg.CompressRESTAPI)
+			},
+		},
+		{
+			name: "negative compression minimum size",
+			envVars: map[string]string{
+				"COMPRESSION_MIN_SIZE": "-1",
+			},
+			expectedError: true,
+		},
+		{
+			name: "request body limits",
+			envVars: map[string]string{
+				"MAX_BODY_BYTES":        "65536",
+				"MAX_JSON_DEPTH":        "8",
+				"MAX_JSON_ARRAY_LENGTH": "0",
+				"METRICS_NAMESPACE":     "Users",
+			},
+			expectedError: false,
+			validateFunc: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 65536, cfg.MaxBodyBytes)
+				assert.Equal(t, 8, cfg.MaxJSONDepth)
+				assert.Equal(t, 0, cfg.MaxJSONArrayLength)
+				assert.Equal(t, 1000, cfg.MaxJSONObjectKeys)
+				assert.Equal(t, "Users", cfg.MetricsNamespace)
+			},
+		},
+		{
+			name: "negative request body limit",
+			envVars: map[string]string{
+				"MAX_JSON_DEPTH": "-1",
+			},
+			expectedError: true,
+		},
+		{
+			name: "strong ETag mode",
+			envVars: map[string]string{
+				"ETAG_MODE": "strong",
+			},
+			expectedError: false,
+			validateFunc: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "strong", cfg.ETagMode)
+			},
+		},
+		{
+			name: "invalid ETag mode",
+			envVars: map[string]string{
+				"ETAG_MODE": "sometimes",
+			},
+			expectedError: true,
+		},
+		{
+			name: "redaction rules",
+			envVars: map[string]string{
+				"REDACT_FIELDS":     "ssn,X-Session-Id",
+				"REDACT_BODY_PATHS": "user.email,cards[*].number",
+				"REDACT_MODE":       "hash",
+				"REDACT_HASH_KEY":   "pepper",
+			},
+			expectedError: false,
+			validateFunc: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, []string{"ssn", "X-Session-Id"}, cfg.RedactFields)
+				assert.Equal(t, []string{"user.email", "cards[*].number"}, cfg.RedactBodyPaths)
+				assert.Equal(t, "hash", cfg.RedactMode)
+				assert.Equal(t, "pepper", cfg.RedactHashKey)
+			},
+		},
+		{
+			name: "invalid redaction mode",
+			envVars: map[string]string{
+				"REDACT_MODE": "encrypt",
+			},
+			expectedError: true,
+		},
+		{
+			name: "hash redaction without key",
+			envVars: map[string]string{
+				"REDACT_MODE": "hash",
+			},
+			expectedError: true,
+		},
 		{
 			name: "negative cache max age",
 			envVars: map[string]string{
@@ -373,6 +570,36 @@ func TestValidate(t *testing.T) {
 			expectedError: true,
 			errorContains: "invalid log format",
 		},
+		{
+			name: "invalid error format",
+			config: Config{
+				ServiceName:     "test-service",
+				ServiceVersion:  "1.0.0",
+				LogLevel:        "info",
+				LogFormat:       "json",
+				ErrorFormat:     "xml",
+				RequestTimeout:  30 * time.Second,
+				ResponseTimeout: 25 * time.Second,
+				CacheMaxAge:     300,
+			},
+			expectedError: true,
+			errorContains: "invalid error format",
+		},
+		{
+			name: "negative timeout safety margin",
+			config: Config{
+				ServiceName:         "test-service",
+				ServiceVersion:      "1.0.0",
+				LogLevel:            "info",
+				LogFormat:           "json",
+				RequestTimeout:      30 * time.Second,
+				ResponseTimeout:     25 * time.Second,
+				TimeoutSafetyMargin: -time.Second,
+				CacheMaxAge:         300,
+			},
+			expectedError: true,
+			errorContains: "timeout safety margin cannot be negative",
+		},
 		{
 			name: "zero request timeout",
 			config: Config{
@@ -415,6 +642,51 @@ func TestValidate(t *testing.T) {
 			expectedError: true,
 			errorContains: "response timeout must be less than request timeout",
 		},
+		{
+			name: "invalid ETag mode",
+			config: Config{
+				ServiceName:     "test-service",
+				ServiceVersion:  "1.0.0",
+				LogLevel:        "info",
+				LogFormat:       "json",
+				RequestTimeout:  30 * time.Second,
+				ResponseTimeout: 25 * time.Second,
+				CacheMaxAge:     300,
+				ETagMode:        "sometimes",
+			},
+			expectedError: true,
+			errorContains: "invalid ETag mode",
+		},
+		{
+			name: "invalid redaction mode",
+			config: Config{
+				ServiceName:     "test-service",
+				ServiceVersion:  "1.0.0",
+				LogLevel:        "info",
+				LogFormat:       "json",
+				RequestTimeout:  30 * time.Second,
+				ResponseTimeout: 25 * time.Second,
+				CacheMaxAge:     300,
+				RedactMode:      "encrypt",
+			},
+			expectedError: true,
+			errorContains: "invalid redaction mode",
+		},
+		{
+			name: "hash redaction without key",
+			config: Config{
+				ServiceName:     "test-service",
+				ServiceVersion:  "1.0.0",
+				LogLevel:        "info",
+				LogFormat:       "json",
+				RequestTimeout:  30 * time.Second,
+				ResponseTimeout: 25 * time.Second,
+				CacheMaxAge:     300,
+				RedactMode:      "hash",
+			},
+			expectedError: true,
+			errorContains: "redaction hash key is required",
+		},
 		{
 			name: "negative cache max age",
 			config: Config{
@@ -0,0 +1,116 @@
+// Package http provides HTTP response utilities for Lambda functions.
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings supported for response compression.
+const (
+	EncodingBrotli   = "br"
+	EncodingGzip     = "gzip"
+	EncodingIdentity = "identity"
+)
+
+// NegotiateEncoding picks the content coding for a response from an Accept-Encoding header
+// (RFC 9110, section 12.5.3), preferring earlier entries of supported on equal quality. It
+// returns an empty string when the client accepts none of them, so the body is sent as is.
+func NegotiateEncoding(acceptEncoding string, supported ...string) string {
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(entry, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		quality := codingQuality(params)
+		if coding == "*" {
+			wildcard = quality
+		} else {
+			qualities[coding] = quality
+		}
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range supported {
+		quality, listed := qualities[coding]
+		if !listed {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+// codingQuality returns the q parameter of an Accept-Encoding entry, 1 when absent.
+func codingQuality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return 0
+			}
+			return quality
+		}
+	}
+	return 1
+}
+
+// Compress encodes body with the given content coding.
+func Compress(body []byte, encoding string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	var writer io.WriteCloser
+	switch encoding {
+	case EncodingGzip:
+		writer = gzip.NewWriter(&buffer)
+	case EncodingBrotli:
+		writer = brotli.NewWriterLevel(&buffer, brotli.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("unsupported content coding %q", encoding)
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// IsCompressible reports whether responses of contentType benefit from compression: text,
+// JSON, XML and JavaScript do, while images, archives and other binary formats are usually
+// compressed already.
+func IsCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-ndjson":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+// Package http provides HTTP response utilities for Lambda functions.
+package http
+
+import (
+	nethttp "net/http"
+)
+
+// headerSetCookie is the name of the response header carrying cookies.
+const headerSetCookie = "Set-Cookie"
+
+// Cookie is a cookie set on the client with ResponseBuilder.WithCookie, including its
+// Path, Domain, Expires, MaxAge, Secure, HttpOnly, SameSite and Partitioned attributes.
+// A negative MaxAge deletes the cookie.
+type Cookie = nethttp.Cookie
+
+// SameSite is the SameSite attribute of a cookie.
+type SameSite = nethttp.SameSite
+
+// SameSite attribute values.
+const (
+	SameSiteDefaultMode = nethttp.SameSiteDefaultMode
+	SameSiteLaxMode     = nethttp.SameSiteLaxMode
+	SameSiteStrictMode  = nethttp.SameSiteStrictMode
+	SameSiteNoneMode    = nethttp.SameSiteNoneMode
+)
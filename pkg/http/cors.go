@@ -0,0 +1,181 @@
+// Package http provides HTTP response utilities for Lambda functions.
+package http
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy decides which cross-origin requests browsers may make (the Fetch standard's
+// CORS protocol) and produces the response headers saying so.
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins such as "https://app.example.com", patterns with
+	// one wildcard such as "https://*.example.com", or "*" for any origin.
+	AllowedOrigins []string
+	// AllowedMethods lists the methods preflight requests may ask for.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers preflight requests may ask for; "*" allows any.
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and authorization headers.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight result; zero leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// DefaultCORSPolicy allows any origin without credentials, with the methods and headers
+// used by the API.
+func DefaultCORSPolicy() CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "X-Amz-Date", "Authorization", "X-Api-Key", "X-Amz-Security-Token", "Idempotency-Key"},
+	}
+}
+
+// allowsAnyOrigin reports whether the policy allows every origin.
+func (p CORSPolicy) allowsAnyOrigin() bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsOrigin reports whether requests from origin are allowed.
+func (p CORSPolicy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		prefix, suffix, found := strings.Cut(allowed, "*")
+		if found && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+			strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin. Requests without
+// an Origin, which are same-origin or not from a browser, get none. The wildcard is only
+// sent without credentials, since browsers reject it on credentialed requests.
+func (p CORSPolicy) allowOrigin(origin string) (string, bool) {
+	if origin == "" {
+		return "", false
+	}
+	if p.allowsAnyOrigin() && !p.AllowCredentials {
+		return "*", true
+	}
+	if p.AllowsOrigin(origin) {
+		return origin, true
+	}
+	return "", false
+}
+
+// varies reports whether responses depend on the Origin request header, so caches must
+// key them by it.
+func (p CORSPolicy) varies() bool {
+	return !p.allowsAnyOrigin() || p.AllowCredentials
+}
+
+// ResponseHeaders returns the CORS headers of an actual (non-preflight) response to a
+// request from origin, which is empty for same-origin and non-browser requests.
+func (p CORSPolicy) ResponseHeaders(origin string) map[string]string {
+	headers := make(map[string]string)
+	if p.varies() {
+		headers["Vary"] = "Origin"
+	}
+
+	allowOrigin, ok := p.allowOrigin(origin)
+	if !ok {
+		return headers
+	}
+
+	headers["Access-Control-Allow-Origin"] = allowOrigin
+	if p.AllowCredentials {
+		headers["Access-Control-Allow-Credentials"] = "true"
+	}
+	if len(p.ExposedHeaders) > 0 {
+		headers["Access-Control-Expose-Headers"] = strings.Join(p.ExposedHeaders, ", ")
+	}
+	return headers
+}
+
+// IsPreflight reports whether a request is a CORS preflight request.
+func IsPreflight(method, origin, requestMethod string) bool {
+	return method == "OPTIONS" && origin != "" && requestMethod != ""
+}
+
+// PreflightHeaders returns the headers of the response to a preflight request from origin
+// asking to use requestMethod with requestHeaders (the Access-Control-Request-* values).
+// It reports false when the policy does not allow the request; the response then carries
+// no CORS headers and the browser blocks the actual request.
+func (p CORSPolicy) PreflightHeaders(origin, requestMethod, requestHeaders string) (map[string]string, bool) {
+	headers := map[string]string{
+		"Vary": "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+	}
+
+	allowOrigin, ok := p.allowOrigin(origin)
+	if !ok || !containsFold(p.AllowedMethods, requestMethod) {
+		return headers, false
+	}
+
+	allowHeaders := strings.Join(p.AllowedHeaders, ", ")
+	if containsFold(p.AllowedHeaders, "*") {
+		// Echo the requested headers: credentialed requests treat "*" as a header name
+		allowHeaders = requestHeaders
+	} else {
+		for _, name := range strings.Split(requestHeaders, ",") {
+			if name = strings.TrimSpace(name); name != "" && !containsFold(p.AllowedHeaders, name) {
+				return headers, false
+			}
+		}
+	}
+
+	headers["Access-Control-Allow-Origin"] = allowOrigin
+	headers["Access-Control-Allow-Methods"] = strings.Join(p.AllowedMethods, ", ")
+	if allowHeaders != "" {
+		headers["Access-Control-Allow-Headers"] = allowHeaders
+	}
+	if p.AllowCredentials {
+		headers["Access-Control-Allow-Credentials"] = "true"
+	}
+	if p.MaxAge > 0 {
+		headers["Access-Control-Max-Age"] = strconv.Itoa(int(p.MaxAge.Seconds()))
+	}
+	return headers, true
+}
+
+// AppendVary adds the header names in values to the Vary header value existing, skipping
+// names already listed.
+func AppendVary(existing string, values ...string) string {
+	names := make([]string, 0, 4)
+	for _, value := range append([]string{existing}, values...) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !containsFold(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
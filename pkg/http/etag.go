@@ -0,0 +1,120 @@
+// Package http provides HTTP response utilities for Lambda functions.
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	nethttp "net/http"
+	"strings"
+	"time"
+)
+
+// StrongETag returns value, such as a record version, as a strong entity tag: one that
+// changes whenever any byte of the representation changes.
+func StrongETag(value string) string {
+	return `"` + value + `"`
+}
+
+// WeakETag returns value as a weak entity tag: one that only changes when the meaning of
+// the representation does.
+func WeakETag(value string) string {
+	return `W/"` + value + `"`
+}
+
+// WeakenETag returns etag as a weak entity tag, for a representation whose bytes differ
+// from those the strong tag was computed for, such as a compressed body.
+func WeakenETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// ComputeETag returns an entity tag derived from a hash of body.
+func ComputeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	value := base64.RawURLEncoding.EncodeToString(sum[:16])
+	if weak {
+		return WeakETag(value)
+	}
+	return StrongETag(value)
+}
+
+// ComputeJSONETag returns an entity tag derived from a hash of the JSON encoding of v.
+func ComputeJSONETag(v interface{}, weak bool) (string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return ComputeETag(body, weak), nil
+}
+
+// entityTag is a parsed entity tag.
+type entityTag struct {
+	opaque string
+	weak   bool
+}
+
+// parseETags parses the entity tags of an If-Match or If-None-Match header value, which is
+// a comma-separated list. Malformed tags end the list.
+func parseETags(value string) []entityTag {
+	var tags []entityTag
+	for {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			return tags
+		}
+
+		tag := entityTag{}
+		if strings.HasPrefix(value, "W/") {
+			tag.weak = true
+			value = value[2:]
+		}
+		if !strings.HasPrefix(value, `"`) {
+			return tags
+		}
+		end := strings.IndexByte(value[1:], '"')
+		if end < 0 {
+			return tags
+		}
+		tag.opaque = value[1 : end+1]
+		tags = append(tags, tag)
+		value = value[end+2:]
+	}
+}
+
+// MatchETag reports whether the If-Match or If-None-Match header value header lists etag,
+// the current entity tag of the resource. "*" matches any current representation, so it
+// only fails when etag is empty. If-Match uses the strong comparison, where weak tags never
+// match, and If-None-Match the weak comparison, which ignores the weak flag.
+func MatchETag(header, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	current := parseETags(etag)
+	if len(current) != 1 || (strong && current[0].weak) {
+		return false
+	}
+	for _, tag := range parseETags(header) {
+		if tag.opaque == current[0].opaque && (!strong || !tag.weak) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatHTTPDate formats t as an HTTP date, as used by Last-Modified.
+func FormatHTTPDate(t time.Time) string {
+	return t.UTC().Format(nethttp.TimeFormat)
+}
+
+// ParseHTTPDate parses an HTTP date, as sent in If-Modified-Since.
+func ParseHTTPDate(value string) (time.Time, bool) {
+	t, err := nethttp.ParseTime(value)
+	return t, err == nil
+}
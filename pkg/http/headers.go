@@ -0,0 +1,92 @@
+package http
+
+import (
+	"net/textproto"
+	"strings"
+)
+
+// Headers holds header values keyed by canonical name, such as "Content-Type", so lookups
+// match whatever casing the client or trigger used. HTTP APIs and Function URLs lowercase
+// every name, while REST APIs and ALBs pass names through as sent.
+type Headers map[string][]string
+
+// CanonicalHeaderKey returns the canonical form of a header name, "content-type" becoming
+// "Content-Type".
+func CanonicalHeaderKey(name string) string {
+	return textproto.CanonicalMIMEHeaderKey(name)
+}
+
+// NewHeaders builds Headers from the single- and multi-value header maps of a trigger
+// event. Where both hold a name, as in REST API events, the multi-value map wins since it
+// keeps every value. A name sent in several casings keeps the values of the canonical
+// spelling first.
+func NewHeaders(values map[string]string, multiValues map[string][]string) Headers {
+	headers := make(Headers, len(values)+len(multiValues))
+	for name, value := range values {
+		headers.merge(name, []string{value})
+	}
+
+	multiValueHeaders := make(Headers, len(multiValues))
+	for name, list := range multiValues {
+		multiValueHeaders.merge(name, list)
+	}
+	for key, list := range multiValueHeaders {
+		headers[key] = list
+	}
+	return headers
+}
+
+// merge adds the values of a header sent as name, ahead of those already merged from
+// other casings if name is canonical.
+func (h Headers) merge(name string, values []string) {
+	key := CanonicalHeaderKey(name)
+	if name == key {
+		h[key] = append(append([]string(nil), values...), h[key]...)
+	} else {
+		h[key] = append(h[key], values...)
+	}
+}
+
+// Get returns the first value of the named header, or an empty string.
+func (h Headers) Get(name string) string {
+	if values := h[CanonicalHeaderKey(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values returns every value of the named header as delivered.
+func (h Headers) Values(name string) []string {
+	return h[CanonicalHeaderKey(name)]
+}
+
+// Has reports whether the named header is present.
+func (h Headers) Has(name string) bool {
+	_, ok := h[CanonicalHeaderKey(name)]
+	return ok
+}
+
+// List returns the elements of a comma-separated list header such as Accept or
+// X-Forwarded-For, across all its values. HTTP APIs join repeated headers with commas,
+// so this reads them the same way whichever trigger delivered the request.
+func (h Headers) List(name string) []string {
+	var elements []string
+	for _, value := range h.Values(name) {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				elements = append(elements, element)
+			}
+		}
+	}
+	return elements
+}
+
+// Set replaces the values of the named header.
+func (h Headers) Set(name, value string) {
+	h[CanonicalHeaderKey(name)] = []string{value}
+}
+
+// Del removes the named header.
+func (h Headers) Del(name string) {
+	delete(h, CanonicalHeaderKey(name))
+}
@@ -0,0 +1,57 @@
+// Package http provides HTTP response utilities for Lambda functions.
+package http
+
+import (
+	"mime"
+	"strings"
+)
+
+// Media types of request bodies the binding layer decodes.
+const (
+	ContentTypeJSON           = "application/json"
+	ContentTypeFormURLEncoded = "application/x-www-form-urlencoded"
+	ContentTypeMultipartForm  = "multipart/form-data"
+)
+
+// MediaType is a parsed Content-Type header value, such as
+// "application/json; charset=utf-8". Type and parameter names are lowercase.
+type MediaType struct {
+	Type   string
+	Params map[string]string
+}
+
+// ParseMediaType parses a Content-Type header value.
+func ParseMediaType(value string) (MediaType, error) {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return MediaType{}, err
+	}
+	return MediaType{Type: mediaType, Params: params}, nil
+}
+
+// IsJSON reports whether the media type is JSON: application/json or a type with the
+// +json structured syntax suffix, such as application/merge-patch+json.
+func (m MediaType) IsJSON() bool {
+	return m.Type == ContentTypeJSON || strings.HasSuffix(m.Type, "+json")
+}
+
+// Charset returns the lowercase charset parameter, or an empty string when there is none.
+func (m MediaType) Charset() string {
+	return strings.ToLower(m.Params["charset"])
+}
+
+// Matches reports whether the media type is covered by pattern, which is a media type
+// such as "multipart/form-data", a range such as "image/*" or "*/*". The pattern
+// "application/json" covers every JSON media type, including +json ones.
+func (m MediaType) Matches(pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	switch {
+	case pattern == "*/*" || pattern == m.Type:
+		return true
+	case pattern == ContentTypeJSON:
+		return m.IsJSON()
+	case strings.HasSuffix(pattern, "/*"):
+		return strings.HasPrefix(m.Type, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+// Package http provides HTTP response utilities for Lambda functions.
+package http
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ContentTypeProblemJSON is the media type of RFC 7807 problem details responses.
+const ContentTypeProblemJSON = "application/problem+json"
+
+// ErrorFormat selects the body format of error responses.
+type ErrorFormat string
+
+const (
+	// ErrorFormatJSON renders errors as ErrorResponse.
+	ErrorFormatJSON ErrorFormat = "json"
+	// ErrorFormatProblem renders errors as RFC 7807 ProblemDetails.
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// ProblemDetails represents an RFC 7807 problem details response, extended with a
+// machine-readable code and the same request metadata as ErrorResponse.
+type ProblemDetails struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Errors    []FieldError           `json:"errors,omitempty"`
+	RequestID string                 `json:"requestId,omitempty"`
+	Timestamp string                 `json:"timestamp"`
+}
+
+// ProblemType returns the problem type URI for an error code. Codes such as NOT_FOUND
+// become baseURL + "/not-found"; without a base URL or code the type is "about:blank".
+func ProblemType(baseURL, code string) string {
+	if baseURL == "" || code == "" {
+		return "about:blank"
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+// NegotiateErrorFormat picks the error format from an Accept header. Clients listing
+// application/problem+json receive problem details and clients listing application/json
+// (without problem+json) receive ErrorResponse; otherwise fallback is used.
+func NegotiateErrorFormat(accept string, fallback ErrorFormat) ErrorFormat {
+	acceptsJSON := false
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		if !acceptableQuality(params) {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case ContentTypeProblemJSON:
+			return ErrorFormatProblem
+		case "application/json":
+			acceptsJSON = true
+		}
+	}
+
+	if acceptsJSON {
+		return ErrorFormatJSON
+	}
+	return fallback
+}
+
+// acceptableQuality reports whether a media range's parameters leave it acceptable (q > 0).
+func acceptableQuality(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err == nil && quality > 0
+		}
+	}
+	return true
+}
@@ -2,28 +2,125 @@
 package http
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Response represents a standard HTTP response for API Gateway.
+// Response represents a standard HTTP response for API Gateway. When IsBase64Encoded is
+// set, Body holds base64-encoded bytes that API Gateway decodes before sending.
+//
+// Headers with several values, other than cookies, go in MultiValueHeaders; a name may
+// appear in both maps, in which case the Headers value comes first. Cookies holds
+// serialized Set-Cookie values, which each trigger sends in its own way.
 type Response struct {
-	StatusCode int               `json:"statusCode"`
-	Headers    map[string]string `json:"headers"`
-	Body       string            `json:"body"`
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+}
+
+// BodyBytes returns the raw bytes of the body, decoding base64-encoded bodies.
+func (r Response) BodyBytes() ([]byte, error) {
+	if !r.IsBase64Encoded {
+		return []byte(r.Body), nil
+	}
+	return base64.StdEncoding.DecodeString(r.Body)
+}
+
+// AllHeaders returns every header of the response with all its values, including the
+// cookies as Set-Cookie values.
+func (r Response) AllHeaders() map[string][]string {
+	headers := make(map[string][]string, len(r.Headers)+len(r.MultiValueHeaders)+1)
+	for key, value := range r.Headers {
+		headers[key] = []string{value}
+	}
+	for key, values := range r.MultiValueHeaders {
+		headers[key] = append(headers[key], values...)
+	}
+	if len(r.Cookies) > 0 {
+		headers[headerSetCookie] = append(headers[headerSetCookie], r.Cookies...)
+	}
+	return headers
+}
+
+// JoinedHeaders returns the response headers with one value per name, joining the values
+// of multi-value headers with commas, and the Set-Cookie values separately since cookies
+// cannot be joined that way.
+func (r Response) JoinedHeaders() (map[string]string, []string) {
+	if len(r.MultiValueHeaders) == 0 {
+		return r.Headers, r.Cookies
+	}
+
+	headers := make(map[string]string, len(r.Headers)+len(r.MultiValueHeaders))
+	for key, value := range r.Headers {
+		headers[key] = value
+	}
+
+	cookies := append([]string(nil), r.Cookies...)
+	for key, values := range r.MultiValueHeaders {
+		if strings.EqualFold(key, headerSetCookie) {
+			cookies = append(cookies, values...)
+			continue
+		}
+		if len(values) == 0 {
+			continue
+		}
+		joined := strings.Join(values, ", ")
+		if existing, ok := headers[key]; ok {
+			joined = existing + ", " + joined
+		}
+		headers[key] = joined
+	}
+	return headers, cookies
 }
 
 // ErrorResponse represents a standard error response structure.
 type ErrorResponse struct {
-	Message   string `json:"message"`
-	Error     string `json:"error,omitempty"`
-	RequestID string `json:"requestId,omitempty"`
-	Timestamp string `json:"timestamp"`
-	Path      string `json:"path,omitempty"`
+	Message   string                 `json:"message"`
+	Error     string                 `json:"error,omitempty"`
+	Code      string                 `json:"code,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Errors    []FieldError           `json:"errors,omitempty"`
+	RequestID string                 `json:"requestId,omitempty"`
+	Timestamp string                 `json:"timestamp"`
+	Path      string                 `json:"path,omitempty"`
+}
+
+// FieldError describes a single invalid field in a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+// FieldErrorProvider is implemented by errors that carry per-field violations.
+// Error responses built from such errors list every violation in the errors array.
+type FieldErrorProvider interface {
+	FieldErrors() []FieldError
 }
 
+// ErrorCoder is implemented by errors that carry a stable, machine-readable error code.
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// ErrorDetailProvider is implemented by errors that carry structured details.
+// Error responses built from such errors include the details.
+type ErrorDetailProvider interface {
+	ErrorDetails() map[string]interface{}
+}
+
+// RedactionFunc reports whether the internal details of err must be left out of an error
+// response with the given status code.
+type RedactionFunc func(statusCode int, err error) bool
+
 // SuccessResponse represents a standard success response wrapper.
 type SuccessResponse struct {
 	Data      interface{} `json:"data"`
@@ -33,15 +130,22 @@ type SuccessResponse struct {
 
 // ResponseBuilder helps build HTTP responses with consistent structure.
 type ResponseBuilder struct {
-	requestID string
-	path      string
-	headers   map[string]string
+	requestID          string
+	path               string
+	headers            map[string]string
+	multiValueHeaders  map[string][]string
+	cookies            []string
+	errorFormat        ErrorFormat
+	errorCode          string
+	problemTypeBaseURL string
+	redact             RedactionFunc
 }
 
 // NewResponseBuilder creates a new response builder.
 func NewResponseBuilder() *ResponseBuilder {
 	return &ResponseBuilder{
-		headers: make(map[string]string),
+		headers:     make(map[string]string),
+		errorFormat: ErrorFormatJSON,
 	}
 }
 
@@ -57,6 +161,33 @@ func (rb *ResponseBuilder) WithPath(path string) *ResponseBuilder {
 	return rb
 }
 
+// WithErrorFormat sets the body format of error responses.
+func (rb *ResponseBuilder) WithErrorFormat(format ErrorFormat) *ResponseBuilder {
+	rb.errorFormat = format
+	return rb
+}
+
+// WithErrorCode sets the machine-readable code included in error responses. Without it,
+// the code comes from the error passed to the response method if it implements ErrorCoder.
+func (rb *ResponseBuilder) WithErrorCode(code string) *ResponseBuilder {
+	rb.errorCode = code
+	return rb
+}
+
+// WithProblemTypeBaseURL sets the base URL of problem type URIs. See ProblemType.
+func (rb *ResponseBuilder) WithProblemTypeBaseURL(baseURL string) *ResponseBuilder {
+	rb.problemTypeBaseURL = baseURL
+	return rb
+}
+
+// WithRedaction sets the function deciding whether error details are redacted. Redacted
+// responses keep the message, code and request ID, but omit the error text and details,
+// so callers can quote the request ID to find the full error in logs and traces.
+func (rb *ResponseBuilder) WithRedaction(redact RedactionFunc) *ResponseBuilder {
+	rb.redact = redact
+	return rb
+}
+
 // WithHeader adds a header to the response.
 func (rb *ResponseBuilder) WithHeader(key, value string) *ResponseBuilder {
 	rb.headers[key] = value
@@ -71,7 +202,27 @@ func (rb *ResponseBuilder) WithHeaders(headers map[string]string) *ResponseBuild
 	return rb
 }
 
-// WithCORS adds CORS headers to the response.
+// AddHeader adds a value to a header of the response, keeping the values added before.
+func (rb *ResponseBuilder) AddHeader(key, value string) *ResponseBuilder {
+	if rb.multiValueHeaders == nil {
+		rb.multiValueHeaders = make(map[string][]string)
+	}
+	rb.multiValueHeaders[key] = append(rb.multiValueHeaders[key], value)
+	return rb
+}
+
+// WithCookie adds a Set-Cookie header for cookie to the response. Cookies with an invalid
+// name are left out.
+func (rb *ResponseBuilder) WithCookie(cookie *Cookie) *ResponseBuilder {
+	if value := cookie.String(); value != "" {
+		rb.cookies = append(rb.cookies, value)
+	}
+	return rb
+}
+
+// WithCORS adds CORS headers allowing any origin to the response.
+//
+// Deprecated: use WithCORSPolicy, which honors the request's origin and credentials.
 func (rb *ResponseBuilder) WithCORS() *ResponseBuilder {
 	rb.headers["Access-Control-Allow-Origin"] = "*"
 	rb.headers["Access-Control-Allow-Headers"] = "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token"
@@ -79,12 +230,35 @@ func (rb *ResponseBuilder) WithCORS() *ResponseBuilder {
 	return rb
 }
 
+// WithCORSPolicy adds the headers policy sets on responses to requests from origin.
+func (rb *ResponseBuilder) WithCORSPolicy(policy CORSPolicy, origin string) *ResponseBuilder {
+	for key, value := range policy.ResponseHeaders(origin) {
+		if key == "Vary" {
+			value = AppendVary(rb.headers["Vary"], value)
+		}
+		rb.headers[key] = value
+	}
+	return rb
+}
+
 // WithCacheControl adds cache control headers.
 func (rb *ResponseBuilder) WithCacheControl(maxAge int) *ResponseBuilder {
 	rb.headers["Cache-Control"] = fmt.Sprintf("max-age=%d", maxAge)
 	return rb
 }
 
+// WithETag sets the entity tag of the response, such as StrongETag(version).
+func (rb *ResponseBuilder) WithETag(etag string) *ResponseBuilder {
+	rb.headers["ETag"] = etag
+	return rb
+}
+
+// WithLastModified sets the time the resource in the response was last modified.
+func (rb *ResponseBuilder) WithLastModified(t time.Time) *ResponseBuilder {
+	rb.headers["Last-Modified"] = FormatHTTPDate(t)
+	return rb
+}
+
 // OK creates a 200 OK response with the given data.
 func (rb *ResponseBuilder) OK(data interface{}) Response {
 	return rb.buildResponse(200, data)
@@ -130,6 +304,21 @@ func (rb *ResponseBuilder) Conflict(message string, err error) Response {
 	return rb.buildErrorResponse(409, message, err)
 }
 
+// PreconditionFailed creates a 412 Precondition Failed error response.
+func (rb *ResponseBuilder) PreconditionFailed(message string) Response {
+	return rb.buildErrorResponse(412, message, nil)
+}
+
+// PayloadTooLarge creates a 413 Payload Too Large error response.
+func (rb *ResponseBuilder) PayloadTooLarge(message string, err error) Response {
+	return rb.buildErrorResponse(413, message, err)
+}
+
+// UnsupportedMediaType creates a 415 Unsupported Media Type error response.
+func (rb *ResponseBuilder) UnsupportedMediaType(message string, err error) Response {
+	return rb.buildErrorResponse(415, message, err)
+}
+
 // UnprocessableEntity creates a 422 Unprocessable Entity error response.
 func (rb *ResponseBuilder) UnprocessableEntity(message string, err error) Response {
 	return rb.buildErrorResponse(422, message, err)
@@ -145,20 +334,106 @@ func (rb *ResponseBuilder) InternalServerError(message string, err error) Respon
 	return rb.buildErrorResponse(500, message, err)
 }
 
+// BadGateway creates a 502 Bad Gateway error response.
+func (rb *ResponseBuilder) BadGateway(message string) Response {
+	return rb.buildErrorResponse(502, message, nil)
+}
+
 // ServiceUnavailable creates a 503 Service Unavailable error response.
 func (rb *ResponseBuilder) ServiceUnavailable(message string) Response {
 	return rb.buildErrorResponse(503, message, nil)
 }
 
+// GatewayTimeout creates a 504 Gateway Timeout error response.
+func (rb *ResponseBuilder) GatewayTimeout(message string) Response {
+	return rb.buildErrorResponse(504, message, nil)
+}
+
+// CustomError creates an error response with a custom status code.
+func (rb *ResponseBuilder) CustomError(statusCode int, message string, err error) Response {
+	return rb.buildErrorResponse(statusCode, message, err)
+}
+
 // Custom creates a response with a custom status code and data.
 func (rb *ResponseBuilder) Custom(statusCode int, data interface{}) Response {
 	return rb.buildResponse(statusCode, data)
 }
 
+// Binary creates a response whose body is the raw bytes of body, such as a file download.
+// The body is sent base64-encoded, as API Gateway requires for binary content.
+func (rb *ResponseBuilder) Binary(statusCode int, contentType string, body []byte) Response {
+	response := rb.newResponse(statusCode, base64.StdEncoding.EncodeToString(body))
+	response.Headers["Content-Type"] = contentType
+	response.IsBase64Encoded = true
+	return response
+}
+
+// Replay creates a response from a saved one, such as the response to an earlier request
+// with the same idempotency key. The saved status, body, headers and cookies are kept, but
+// the builder's request ID, headers and cookies replace or add to the saved ones, so the
+// response describes the current request.
+func (rb *ResponseBuilder) Replay(saved Response) Response {
+	response := rb.newResponse(saved.StatusCode, saved.Body)
+	response.IsBase64Encoded = saved.IsBase64Encoded
+
+	headers := make(map[string]string, len(saved.Headers)+len(response.Headers))
+	for key, value := range saved.Headers {
+		headers[key] = value
+	}
+	for key, value := range response.Headers {
+		// The saved Content-Type describes the saved body; the builder only has a default
+		if strings.EqualFold(key, "Content-Type") {
+			if _, ok := rb.headers[key]; !ok {
+				continue
+			}
+		}
+		for savedKey := range headers {
+			if strings.EqualFold(savedKey, key) {
+				delete(headers, savedKey)
+			}
+		}
+		headers[key] = value
+	}
+	response.Headers = headers
+
+	if len(saved.MultiValueHeaders) > 0 {
+		multiValueHeaders := make(map[string][]string, len(saved.MultiValueHeaders)+len(response.MultiValueHeaders))
+		for key, values := range saved.MultiValueHeaders {
+			multiValueHeaders[key] = append([]string(nil), values...)
+		}
+		for key, values := range response.MultiValueHeaders {
+			multiValueHeaders[key] = append(multiValueHeaders[key], values...)
+		}
+		response.MultiValueHeaders = multiValueHeaders
+	}
+	if len(saved.Cookies) > 0 {
+		response.Cookies = append(append([]string(nil), saved.Cookies...), response.Cookies...)
+	}
+
+	return response
+}
+
+// newResponse creates a response with the builder's headers and cookies and body.
+func (rb *ResponseBuilder) newResponse(statusCode int, body string) Response {
+	response := Response{
+		StatusCode: statusCode,
+		Headers:    rb.getDefaultHeaders(),
+		Body:       body,
+	}
+	if len(rb.multiValueHeaders) > 0 {
+		response.MultiValueHeaders = make(map[string][]string, len(rb.multiValueHeaders))
+		for key, values := range rb.multiValueHeaders {
+			response.MultiValueHeaders[key] = append([]string(nil), values...)
+		}
+	}
+	if len(rb.cookies) > 0 {
+		response.Cookies = append([]string(nil), rb.cookies...)
+	}
+	return response
+}
+
 // buildResponse creates a response with the given status code and data.
 func (rb *ResponseBuilder) buildResponse(statusCode int, data interface{}) Response {
-	headers := rb.getDefaultHeaders()
-
 	var body string
 	if data != nil {
 		if statusCode >= 200 && statusCode < 300 {
@@ -177,35 +452,65 @@ func (rb *ResponseBuilder) buildResponse(statusCode int, data interface{}) Respo
 		}
 	}
 
-	return Response{
-		StatusCode: statusCode,
-		Headers:    headers,
-		Body:       body,
-	}
+	return rb.newResponse(statusCode, body)
 }
 
 // buildErrorResponse creates an error response with the given status code and message.
 func (rb *ResponseBuilder) buildErrorResponse(statusCode int, message string, err error) Response {
-	headers := rb.getDefaultHeaders()
+	response := rb.newResponse(statusCode, "")
 
 	errorResponse := ErrorResponse{
 		Message:   message,
+		Code:      rb.errorCode,
 		RequestID: rb.requestID,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Path:      rb.path,
 	}
 
+	redacted := rb.redact != nil && rb.redact(statusCode, err)
+
 	if err != nil {
-		errorResponse.Error = err.Error()
-	}
+		if !redacted {
+			errorResponse.Error = err.Error()
+		}
 
-	bodyBytes, _ := json.Marshal(errorResponse)
+		var provider FieldErrorProvider
+		if errors.As(err, &provider) {
+			errorResponse.Errors = provider.FieldErrors()
+		}
 
-	return Response{
-		StatusCode: statusCode,
-		Headers:    headers,
-		Body:       string(bodyBytes),
+		var coder ErrorCoder
+		if errorResponse.Code == "" && errors.As(err, &coder) {
+			errorResponse.Code = coder.ErrorCode()
+		}
+
+		var detailProvider ErrorDetailProvider
+		if !redacted && errors.As(err, &detailProvider) {
+			errorResponse.Details = detailProvider.ErrorDetails()
+		}
 	}
+
+	var bodyBytes []byte
+	if rb.errorFormat == ErrorFormatProblem {
+		response.Headers["Content-Type"] = ContentTypeProblemJSON
+		bodyBytes, _ = json.Marshal(ProblemDetails{
+			Type:      ProblemType(rb.problemTypeBaseURL, errorResponse.Code),
+			Title:     GetStatusText(statusCode),
+			Status:    statusCode,
+			Detail:    errorResponse.Message,
+			Instance:  errorResponse.Path,
+			Code:      errorResponse.Code,
+			Details:   errorResponse.Details,
+			Errors:    errorResponse.Errors,
+			RequestID: errorResponse.RequestID,
+			Timestamp: errorResponse.Timestamp,
+		})
+	} else {
+		bodyBytes, _ = json.Marshal(errorResponse)
+	}
+
+	response.Body = string(bodyBytes)
+	return response
 }
 
 // getDefaultHeaders returns the default headers for all responses.
@@ -267,16 +572,22 @@ func GetStatusText(statusCode int) string {
 		200: "OK",
 		201: "Created",
 		204: "No Content",
+		304: "Not Modified",
 		400: "Bad Request",
 		401: "Unauthorized",
 		403: "Forbidden",
 		404: "Not Found",
 		405: "Method Not Allowed",
 		409: "Conflict",
+		412: "Precondition Failed",
+		413: "Payload Too Large",
+		415: "Unsupported Media Type",
 		422: "Unprocessable Entity",
 		429: "Too Many Requests",
 		500: "Internal Server Error",
+		502: "Bad Gateway",
 		503: "Service Unavailable",
+		504: "Gateway Timeout",
 	}
 
 	if text, exists := statusTexts[statusCode]; exists {
@@ -0,0 +1,152 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Scopes returns the OAuth scopes granted by the token, read from the space-separated
+// scope claim or the scp claim used by some identity providers.
+func (c *Claims) Scopes() []string {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+	return claimStrings(c.Raw["scp"])
+}
+
+// Roles returns the roles granted by the token, read from the roles claim or, for Cognito
+// tokens, the cognito:groups claim.
+func (c *Claims) Roles() []string {
+	if roles := claimStrings(c.Raw["roles"]); len(roles) > 0 {
+		return roles
+	}
+	return claimStrings(c.Raw["cognito:groups"])
+}
+
+// HasScope reports whether the token grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	return containsString(c.Scopes(), scope)
+}
+
+// HasRole reports whether the token grants role.
+func (c *Claims) HasRole(role string) bool {
+	return containsString(c.Roles(), role)
+}
+
+// claimStrings reads a claim holding a space-separated string or an array of strings.
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// AuthorizationPolicy is a custom authorization check. It returns false with a reason to
+// deny the request.
+type AuthorizationPolicy func(ctx context.Context, claims *Claims, request *Request) (allowed bool, reason string)
+
+// Authorization declares what a caller needs to perform an operation on a resource.
+type Authorization struct {
+	// Resource and Operation name what is being protected, for ForbiddenError and audit logs.
+	Resource  string
+	Operation string
+	// Scopes must all be granted.
+	Scopes []string
+	// Roles must include at least one of these when set.
+	Roles []string
+	// Policy, when set, runs after the scope and role checks pass.
+	Policy AuthorizationPolicy
+}
+
+// evaluate decides whether claims satisfy the authorization, returning the reason for a denial.
+func (a Authorization) evaluate(ctx context.Context, claims *Claims, request *Request) (bool, string) {
+	for _, scope := range a.Scopes {
+		if !claims.HasScope(scope) {
+			return false, fmt.Sprintf("missing required scope %q", scope)
+		}
+	}
+
+	if len(a.Roles) > 0 {
+		allowed := false
+		for _, role := range a.Roles {
+			if claims.HasRole(role) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false, fmt.Sprintf("requires one of the roles %s", strings.Join(a.Roles, ", "))
+		}
+	}
+
+	if a.Policy != nil {
+		return a.Policy(ctx, claims, request)
+	}
+
+	return true, ""
+}
+
+// Authorize allows the request only if the caller's claims, stored by JWTAuth or passed on
+// by a Lambda authorizer, satisfy the authorization. Requests without claims fail with an
+// UnauthorizedError; denied requests fail with a ForbiddenError naming the resource and
+// operation. Every decision is logged and annotated on the trace for auditing. Apply it per route:
+//
+//	router.DELETE("/users/{id}", deleteUser, handler.Authorize(lambda.Authorization{
+//		Resource: "user", Operation: "delete", Scopes: []string{"users:write"}, Roles: []string{"admin"},
+//	}))
+func (h *Handler) Authorize(authorization Authorization) Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			claims, ok := ClaimsFromContext(ctx)
+			if !ok {
+				if authorizerContext, found := request.AuthorizerContext(); found {
+					claims, ok = authorizerContext.Claims(), true
+				}
+			}
+			if !ok {
+				return nil, NewUnauthorizedErrorWithReason("Authentication required", AuthReasonMissingToken)
+			}
+
+			allowed, reason := authorization.evaluate(ctx, claims, request)
+
+			decision := "allow"
+			if !allowed {
+				decision = "deny"
+			}
+			h.tracer.AddAnnotation(ctx, "authz_decision", decision)
+			h.tracer.AddAnnotation(ctx, "authz_resource", authorization.Resource)
+			h.tracer.AddAnnotation(ctx, "authz_operation", authorization.Operation)
+
+			logger := h.logger.WithFields(map[string]interface{}{
+				"subject":   claims.Subject,
+				"resource":  authorization.Resource,
+				"operation": authorization.Operation,
+				"decision":  decision,
+				"reason":    reason,
+				"method":    request.Method,
+				"path":      request.Path,
+			}).WithContext(ctx)
+
+			if !allowed {
+				logger.Warn("Authorization denied")
+				return nil, NewResourceForbiddenError(authorization.Resource, authorization.Operation, reason)
+			}
+
+			logger.Info("Authorization granted")
+			return next(ctx, request)
+		}
+	}
+}
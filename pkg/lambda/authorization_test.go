@@ -0,0 +1,153 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaims_ScopesAndRoles(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *Claims
+		expectedScopes []string
+		expectedRoles  []string
+	}{
+		{
+			name:           "scope string and roles array",
+			claims:         &Claims{Scope: "users:read users:write", Raw: map[string]interface{}{"roles": []interface{}{"admin"}}},
+			expectedScopes: []string{"users:read", "users:write"},
+			expectedRoles:  []string{"admin"},
+		},
+		{
+			name:           "scp array and cognito groups",
+			claims:         &Claims{Raw: map[string]interface{}{"scp": []interface{}{"users:read"}, "cognito:groups": []interface{}{"support"}}},
+			expectedScopes: []string{"users:read"},
+			expectedRoles:  []string{"support"},
+		},
+		{
+			name:   "none",
+			claims: &Claims{Raw: map[string]interface{}{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedScopes, tt.claims.Scopes())
+			assert.Equal(t, tt.expectedRoles, tt.claims.Roles())
+		})
+	}
+}
+
+func TestHandler_Authorize(t *testing.T) {
+	reader := &Claims{Subject: "user-1", Scope: "users:read", Raw: map[string]interface{}{"roles": []interface{}{"member"}}}
+	admin := &Claims{Subject: "user-2", Scope: "users:read users:write", Raw: map[string]interface{}{"roles": []interface{}{"admin"}}}
+	ownerOnly := func(ctx context.Context, claims *Claims, request *Request) (bool, string) {
+		if request.PathParam("id") != claims.Subject {
+			return false, "users may only delete themselves"
+		}
+		return true, ""
+	}
+
+	tests := []struct {
+		name          string
+		authorization Authorization
+		claims        *Claims
+		request       *Request
+		expectedErr   func(error) bool
+	}{
+		{
+			name:          "scope granted",
+			authorization: Authorization{Resource: "user", Operation: "read", Scopes: []string{"users:read"}},
+			claims:        reader,
+		},
+		{
+			name:          "missing scope",
+			authorization: Authorization{Resource: "user", Operation: "update", Scopes: []string{"users:write"}},
+			claims:        reader,
+			expectedErr:   IsForbiddenError,
+		},
+		{
+			name:          "any role",
+			authorization: Authorization{Resource: "user", Operation: "delete", Roles: []string{"admin", "support"}},
+			claims:        admin,
+		},
+		{
+			name:          "missing role",
+			authorization: Authorization{Resource: "user", Operation: "delete", Roles: []string{"admin"}},
+			claims:        reader,
+			expectedErr:   IsForbiddenError,
+		},
+		{
+			name:          "policy allows",
+			authorization: Authorization{Resource: "user", Operation: "delete", Policy: ownerOnly},
+			claims:        reader,
+			request:       &Request{PathParameters: map[string]string{"id": "user-1"}},
+		},
+		{
+			name:          "policy denies",
+			authorization: Authorization{Resource: "user", Operation: "delete", Policy: ownerOnly},
+			claims:        reader,
+			request:       &Request{PathParameters: map[string]string{"id": "user-2"}},
+			expectedErr:   IsForbiddenError,
+		},
+		{
+			name:          "claims from Lambda authorizer",
+			authorization: Authorization{Resource: "user", Operation: "delete", Scopes: []string{"users:write"}, Roles: []string{"admin"}},
+			request: &Request{Event: events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						Lambda: map[string]interface{}{"principalId": "user-2", "scope": "users:read users:write", "roles": "admin"},
+					},
+				},
+			}},
+		},
+		{
+			name:          "unauthenticated",
+			authorization: Authorization{Resource: "user", Operation: "read"},
+			expectedErr:   IsUnauthorizedError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			called := false
+			handler := h.Authorize(tt.authorization)(func(ctx context.Context, request *Request) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = ContextWithClaims(ctx, tt.claims)
+			}
+			request := tt.request
+			if request == nil {
+				request = &Request{}
+			}
+
+			_, err := handler(ctx, request)
+
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				assert.True(t, called)
+				return
+			}
+
+			assert.True(t, tt.expectedErr(err), "unexpected error %v", err)
+			assert.False(t, called)
+
+			var forbidden *ForbiddenError
+			if errors.As(err, &forbidden) {
+				assert.Equal(t, tt.authorization.Resource, forbidden.Resource)
+				assert.Equal(t, tt.authorization.Operation, forbidden.Operation)
+				assert.NotEmpty(t, forbidden.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Keys of the context a Lambda authorizer returns to API Gateway. API Gateway only passes
+// on string, number and boolean values, so lists are joined with spaces.
+const (
+	AuthorizerKeyPrincipal = "principalId"
+	AuthorizerKeyAuthType  = "authType"
+	AuthorizerKeySubject   = "sub"
+	AuthorizerKeyIssuer    = "iss"
+	AuthorizerKeyScope     = "scope"
+	AuthorizerKeyRoles     = "roles"
+)
+
+// Credential types recorded under AuthorizerKeyAuthType.
+const (
+	AuthTypeJWT    = "jwt"
+	AuthTypeAPIKey = "api_key"
+)
+
+// AuthorizerContext is the context a Lambda authorizer attached to a request.
+type AuthorizerContext map[string]interface{}
+
+// NewAuthorizerContext builds the context an authorizer returns for an authenticated
+// principal. claims is nil for credentials other than JWTs.
+func NewAuthorizerContext(principal, authType string, claims *Claims) AuthorizerContext {
+	authorizerContext := AuthorizerContext{
+		AuthorizerKeyPrincipal: principal,
+		AuthorizerKeyAuthType:  authType,
+	}
+
+	if claims != nil {
+		authorizerContext[AuthorizerKeySubject] = claims.Subject
+		authorizerContext[AuthorizerKeyIssuer] = claims.Issuer
+		if scopes := claims.Scopes(); len(scopes) > 0 {
+			authorizerContext[AuthorizerKeyScope] = strings.Join(scopes, " ")
+		}
+		if roles := claims.Roles(); len(roles) > 0 {
+			authorizerContext[AuthorizerKeyRoles] = strings.Join(roles, " ")
+		}
+	}
+
+	return authorizerContext
+}
+
+// AuthorizerContextFromEvent returns the Lambda authorizer context of an HTTP API or REST
+// API event. It reports false when the request was not authorized by a Lambda authorizer.
+func AuthorizerContextFromEvent(event interface{}) (AuthorizerContext, bool) {
+	switch e := event.(type) {
+	case events.APIGatewayV2HTTPRequest:
+		if e.RequestContext.Authorizer != nil && len(e.RequestContext.Authorizer.Lambda) > 0 {
+			return AuthorizerContext(e.RequestContext.Authorizer.Lambda), true
+		}
+	case events.APIGatewayProxyRequest:
+		// REST APIs merge the context into the authorizer map next to principalId
+		if _, ok := e.RequestContext.Authorizer[AuthorizerKeyPrincipal]; ok {
+			return AuthorizerContext(e.RequestContext.Authorizer), true
+		}
+	}
+	return nil, false
+}
+
+// AuthorizerContext returns the Lambda authorizer context of the request's event.
+func (r *Request) AuthorizerContext() (AuthorizerContext, bool) {
+	return AuthorizerContextFromEvent(r.Event)
+}
+
+// String returns the value under key as a string, or an empty string if it is absent.
+func (c AuthorizerContext) String(key string) string {
+	switch value := c[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Principal returns the principal the authorizer identified.
+func (c AuthorizerContext) Principal() string {
+	return c.String(AuthorizerKeyPrincipal)
+}
+
+// AuthType returns the kind of credential the caller presented, such as AuthTypeJWT.
+func (c AuthorizerContext) AuthType() string {
+	return c.String(AuthorizerKeyAuthType)
+}
+
+// Subject returns the sub claim of the caller's token.
+func (c AuthorizerContext) Subject() string {
+	return c.String(AuthorizerKeySubject)
+}
+
+// Scopes returns the OAuth scopes granted to the caller.
+func (c AuthorizerContext) Scopes() []string {
+	return strings.Fields(c.String(AuthorizerKeyScope))
+}
+
+// Roles returns the roles granted to the caller.
+func (c AuthorizerContext) Roles() []string {
+	return strings.Fields(c.String(AuthorizerKeyRoles))
+}
+
+// Claims converts the context into Claims, so that middleware such as Authorize can run
+// behind a Lambda authorizer. Every context value is available in Raw.
+func (c AuthorizerContext) Claims() *Claims {
+	claims := &Claims{
+		Issuer:  c.String(AuthorizerKeyIssuer),
+		Subject: c.Subject(),
+		Scope:   c.String(AuthorizerKeyScope),
+		Raw:     make(map[string]interface{}, len(c)),
+	}
+	if claims.Subject == "" {
+		claims.Subject = c.Principal()
+	}
+	for key, value := range c {
+		claims.Raw[key] = value
+	}
+	return claims
+}
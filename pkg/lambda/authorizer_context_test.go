@@ -0,0 +1,148 @@
+package lambda
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuthorizerContext(t *testing.T) {
+	claims := &Claims{
+		Issuer:  "https://issuer.example.com",
+		Subject: "user-1",
+		Scope:   "users:read users:write",
+		Raw:     map[string]interface{}{"cognito:groups": []interface{}{"admin", "support"}},
+	}
+
+	authorizerContext := NewAuthorizerContext("user-1", AuthTypeJWT, claims)
+
+	assert.Equal(t, AuthorizerContext{
+		"principalId": "user-1",
+		"authType":    "jwt",
+		"sub":         "user-1",
+		"iss":         "https://issuer.example.com",
+		"scope":       "users:read users:write",
+		"roles":       "admin support",
+	}, authorizerContext)
+
+	apiKeyContext := NewAuthorizerContext("partner-a", AuthTypeAPIKey, nil)
+	assert.Equal(t, AuthorizerContext{"principalId": "partner-a", "authType": "api_key"}, apiKeyContext)
+}
+
+func TestAuthorizerContextFromEvent(t *testing.T) {
+	tests := []struct {
+		name              string
+		event             interface{}
+		expectFound       bool
+		expectedPrincipal string
+		expectedScopes    []string
+		expectedRoles     []string
+	}{
+		{
+			name: "HTTP API Lambda authorizer",
+			event: events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						Lambda: map[string]interface{}{"principalId": "user-1", "authType": "jwt", "scope": "users:read", "roles": "admin support"},
+					},
+				},
+			},
+			expectFound:       true,
+			expectedPrincipal: "user-1",
+			expectedScopes:    []string{"users:read"},
+			expectedRoles:     []string{"admin", "support"},
+		},
+		{
+			name: "REST API Lambda authorizer",
+			event: events.APIGatewayProxyRequest{
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Authorizer: map[string]interface{}{"principalId": "partner-a", "authType": "api_key"},
+				},
+			},
+			expectFound:       true,
+			expectedPrincipal: "partner-a",
+		},
+		{
+			name: "HTTP API JWT authorizer",
+			event: events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{Claims: map[string]string{"sub": "user-1"}},
+					},
+				},
+			},
+		},
+		{
+			name:  "no authorizer",
+			event: events.APIGatewayV2HTTPRequest{},
+		},
+		{
+			name:  "other event",
+			event: events.ALBTargetGroupRequest{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizerContext, found := (&Request{Event: tt.event}).AuthorizerContext()
+
+			assert.Equal(t, tt.expectFound, found)
+			assert.Equal(t, tt.expectedPrincipal, authorizerContext.Principal())
+			assert.Equal(t, tt.expectedScopes, nilIfEmpty(authorizerContext.Scopes()))
+			assert.Equal(t, tt.expectedRoles, nilIfEmpty(authorizerContext.Roles()))
+		})
+	}
+}
+
+func TestAuthorizerContext_String(t *testing.T) {
+	authorizerContext := AuthorizerContext{
+		"text":   "value",
+		"number": json.Number("42"),
+		"float":  float64(1.5),
+		"flag":   true,
+	}
+
+	assert.Equal(t, "value", authorizerContext.String("text"))
+	assert.Equal(t, "42", authorizerContext.String("number"))
+	assert.Equal(t, "1.5", authorizerContext.String("float"))
+	assert.Equal(t, "true", authorizerContext.String("flag"))
+	assert.Equal(t, "", authorizerContext.String("missing"))
+}
+
+func TestAuthorizerContext_Claims(t *testing.T) {
+	t.Run("JWT caller", func(t *testing.T) {
+		claims := AuthorizerContext{
+			"principalId": "user-1",
+			"sub":         "user-1",
+			"iss":         "https://issuer.example.com",
+			"scope":       "users:read users:write",
+			"roles":       "admin",
+			"tier":        "gold",
+		}.Claims()
+
+		require.NotNil(t, claims)
+		assert.Equal(t, "user-1", claims.Subject)
+		assert.Equal(t, "https://issuer.example.com", claims.Issuer)
+		assert.True(t, claims.HasScope("users:write"))
+		assert.True(t, claims.HasRole("admin"))
+		assert.Equal(t, "gold", claims.Raw["tier"])
+	})
+
+	t.Run("API key caller", func(t *testing.T) {
+		claims := AuthorizerContext{"principalId": "partner-a", "authType": "api_key"}.Claims()
+
+		assert.Equal(t, "partner-a", claims.Subject)
+		assert.Empty(t, claims.Scopes())
+	})
+}
+
+// nilIfEmpty normalizes empty slices so they compare equal to nil.
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,478 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lambda-go-template/pkg/http"
+)
+
+// TypedHandlerFunc represents a handler that receives a decoded request struct and returns a typed response.
+type TypedHandlerFunc[Req any, Resp any] func(ctx context.Context, request Req) (Resp, error)
+
+// BindOptions controls how requests are decoded into structs.
+type BindOptions struct {
+	// DisallowUnknownFields rejects JSON bodies containing fields the target struct does not declare.
+	DisallowUnknownFields bool
+}
+
+// TypedHandler adapts a typed handler into a RequestHandlerFunc. The request is bound into a
+// new Req using Bind with default options and checked with Validate before the handler runs.
+func TypedHandler[Req any, Resp any](handlerFunc TypedHandlerFunc[Req, Resp]) RequestHandlerFunc {
+	return TypedHandlerWithOptions(handlerFunc, BindOptions{})
+}
+
+// TypedHandlerWithOptions adapts a typed handler into a RequestHandlerFunc using the given bind options.
+func TypedHandlerWithOptions[Req any, Resp any](handlerFunc TypedHandlerFunc[Req, Resp], options BindOptions) RequestHandlerFunc {
+	return func(ctx context.Context, request *Request) (interface{}, error) {
+		var input Req
+		if err := BindWithOptions(request, &input, options); err != nil {
+			return nil, err
+		}
+
+		if err := Validate(&input); err != nil {
+			return nil, err
+		}
+
+		return handlerFunc(ctx, input)
+	}
+}
+
+// Bind decodes the request into target, which must be a pointer to a struct.
+//
+// The body is decoded first according to its Content-Type. JSON bodies, including +json
+// types and bodies without a Content-Type, are decoded using the struct's json tags.
+// application/x-www-form-urlencoded and multipart/form-data bodies fill fields by their
+// `form:"name"` tag, or else their json tag, and uploaded files fill FormFile, *FormFile
+// and []*FormFile fields. Other media types get an UnsupportedMediaTypeError. Fields tagged with
+// `path:"name"`, `query:"name"` or `header:"Name"` are then populated from the path
+// parameters, query string and headers respectively, overriding any body value.
+// Decode failures are returned as a ValidationError naming the offending field.
+func Bind(request *Request, target interface{}) error {
+	return BindWithOptions(request, target, BindOptions{})
+}
+
+// BindWithOptions decodes the request into target using the given options.
+func BindWithOptions(request *Request, target interface{}, options BindOptions) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return NewInternalError(fmt.Sprintf("bind target must be a non-nil pointer, got %T", target), nil)
+	}
+
+	// Allocate through pointer-to-pointer targets such as **T
+	for value.Elem().Kind() == reflect.Ptr {
+		if value.Elem().IsNil() {
+			value.Elem().Set(reflect.New(value.Elem().Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	if value.Elem().Kind() != reflect.Struct {
+		return NewInternalError(fmt.Sprintf("bind target must point to a struct, got %T", target), nil)
+	}
+
+	if err := bindBody(request, value, options); err != nil {
+		return err
+	}
+
+	return bindFields(request, value.Elem())
+}
+
+// bindBody decodes the request body into target, a pointer to a struct.
+func bindBody(request *Request, target reflect.Value, options BindOptions) error {
+	if request.Body == "" {
+		return nil
+	}
+
+	mediaType, err := requestMediaType(request)
+	if err != nil {
+		return err
+	}
+
+	body, err := requestBody(request)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mediaType.IsJSON():
+		return bindJSON(body, target.Interface(), options)
+	case mediaType.Type == http.ContentTypeFormURLEncoded || mediaType.Type == http.ContentTypeMultipartForm:
+		form, err := parseFormBody(mediaType, body)
+		if err != nil {
+			return err
+		}
+		return bindForm(form, target.Elem(), options)
+	}
+
+	return NewUnsupportedMediaTypeError(fmt.Sprintf("Content-Type %s cannot be decoded", mediaType.Type), mediaType.Type, bindableContentTypes)
+}
+
+// bindJSON decodes a JSON body into target.
+func bindJSON(body []byte, target interface{}, options BindOptions) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if options.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(target); err != nil {
+		return jsonDecodeError(err)
+	}
+
+	// Reject trailing data after the first JSON value
+	if _, err := decoder.Token(); err != io.EOF {
+		return NewValidationError("request body must contain a single JSON value", "body", nil)
+	}
+
+	return nil
+}
+
+// jsonDecodeError converts an encoding/json error into a ValidationError naming the field.
+func jsonDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return NewValidationErrorWithCause(fmt.Sprintf("must be of type %s", typeErr.Type), field, typeErr.Value, err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return NewValidationErrorWithCause("Invalid JSON in request body", "body", nil, err)
+	}
+
+	// encoding/json reports unknown fields only through the error text
+	if msg := err.Error(); strings.HasPrefix(msg, "json: unknown field ") {
+		field := strings.Trim(strings.TrimPrefix(msg, "json: unknown field "), `"`)
+		return NewValidationErrorWithCause("unknown field", field, nil, err)
+	}
+
+	return NewValidationErrorWithCause("Invalid JSON in request body", "body", nil, err)
+}
+
+// bindingSource identifies where a tagged struct field takes its value from.
+type bindingSource string
+
+const (
+	bindingSourcePath   bindingSource = "path"
+	bindingSourceQuery  bindingSource = "query"
+	bindingSourceHeader bindingSource = "header"
+	bindingSourceForm   bindingSource = "form"
+)
+
+// fieldBinding describes a struct field populated from the path, query string, headers or
+// form body.
+type fieldBinding struct {
+	index  []int
+	source bindingSource
+	name   string
+}
+
+// fieldBindingCache caches the tagged fields of each bound struct type.
+var fieldBindingCache sync.Map // map[reflect.Type][]fieldBinding
+
+// formBindingCache caches the fields form bodies populate for each bound struct type.
+var formBindingCache sync.Map // map[reflect.Type][]fieldBinding
+
+// formFileType and formFilePtrType are the field types uploaded files are bound to.
+var (
+	formFileType    = reflect.TypeOf(FormFile{})
+	formFilePtrType = reflect.TypeOf(&FormFile{})
+)
+
+// bindForm populates target from a decoded form body.
+func bindForm(form *Form, target reflect.Value, options BindOptions) error {
+	bindings := formBindingsFor(target.Type())
+
+	if options.DisallowUnknownFields {
+		known := make(map[string]bool, len(bindings))
+		for _, binding := range bindings {
+			known[binding.name] = true
+		}
+		for name := range form.Values {
+			if !known[name] {
+				return NewValidationError("unknown field", name, nil)
+			}
+		}
+		for name := range form.Files {
+			if !known[name] {
+				return NewValidationError("unknown field", name, nil)
+			}
+		}
+	}
+
+	for _, binding := range bindings {
+		field := target.FieldByIndex(binding.index)
+
+		switch field.Type() {
+		case formFileType, formFilePtrType, reflect.SliceOf(formFilePtrType):
+			files := form.Files[binding.name]
+			if len(files) == 0 {
+				continue
+			}
+			switch field.Type() {
+			case formFileType:
+				field.Set(reflect.ValueOf(*files[0]))
+			case formFilePtrType:
+				field.Set(reflect.ValueOf(files[0]))
+			default:
+				field.Set(reflect.ValueOf(files))
+			}
+			continue
+		}
+
+		values, ok := form.Values[binding.name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if err := setFieldValue(field, values, binding.source); err != nil {
+			return bindingError(err, binding, values)
+		}
+	}
+
+	return nil
+}
+
+// formBindingsFor returns the fields of a struct type that form bodies populate: those not
+// bound to the path, query string or headers, named by their form or json tag.
+func formBindingsFor(structType reflect.Type) []fieldBinding {
+	if cached, ok := formBindingCache.Load(structType); ok {
+		return cached.([]fieldBinding)
+	}
+
+	var bindings []fieldBinding
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, nested := range formBindingsFor(field.Type) {
+				nested.index = append([]int{i}, nested.index...)
+				bindings = append(bindings, nested)
+			}
+			continue
+		}
+
+		if !field.IsExported() || boundOutsideBody(field) {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("form")
+		if !ok {
+			name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		bindings = append(bindings, fieldBinding{index: []int{i}, source: bindingSourceForm, name: name})
+	}
+
+	formBindingCache.Store(structType, bindings)
+	return bindings
+}
+
+// boundOutsideBody reports whether a field takes its value from the path, query string or headers.
+func boundOutsideBody(field reflect.StructField) bool {
+	for _, source := range []bindingSource{bindingSourcePath, bindingSourceQuery, bindingSourceHeader} {
+		if name, ok := field.Tag.Lookup(string(source)); ok && name != "" && name != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// bindFields populates tagged fields from the request's path parameters, query string and headers.
+func bindFields(request *Request, target reflect.Value) error {
+	for _, binding := range fieldBindingsFor(target.Type()) {
+		values, ok := lookupBindingValues(request, binding)
+		if !ok {
+			continue
+		}
+
+		field := target.FieldByIndex(binding.index)
+		if err := setFieldValue(field, values, binding.source); err != nil {
+			return bindingError(err, binding, values)
+		}
+	}
+
+	return nil
+}
+
+// bindingError reports a value that could not be bound: a ValidationError for bad input,
+// or an InternalError when the field's type cannot be bound at all.
+func bindingError(err error, binding fieldBinding, values []string) error {
+	var unsupported *unsupportedFieldTypeError
+	if errors.As(err, &unsupported) {
+		return NewInternalError(fmt.Sprintf("cannot bind %s %q to field of type %s", binding.source, binding.name, unsupported.fieldType), nil)
+	}
+	return NewValidationErrorWithCause(err.Error(), binding.name, strings.Join(values, ","), err)
+}
+
+// fieldBindingsFor returns the tagged fields of a struct type, including promoted fields of embedded structs.
+func fieldBindingsFor(structType reflect.Type) []fieldBinding {
+	if cached, ok := fieldBindingCache.Load(structType); ok {
+		return cached.([]fieldBinding)
+	}
+
+	var bindings []fieldBinding
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, nested := range fieldBindingsFor(field.Type) {
+				nested.index = append([]int{i}, nested.index...)
+				bindings = append(bindings, nested)
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, source := range []bindingSource{bindingSourcePath, bindingSourceQuery, bindingSourceHeader} {
+			if name, ok := field.Tag.Lookup(string(source)); ok && name != "" && name != "-" {
+				bindings = append(bindings, fieldBinding{index: []int{i}, source: source, name: name})
+				break
+			}
+		}
+	}
+
+	fieldBindingCache.Store(structType, bindings)
+	return bindings
+}
+
+// lookupBindingValues returns the raw values for a binding and whether the source supplied any.
+func lookupBindingValues(request *Request, binding fieldBinding) ([]string, bool) {
+	switch binding.source {
+	case bindingSourcePath:
+		value, ok := request.PathParameters[binding.name]
+		return []string{value}, ok
+	case bindingSourceQuery:
+		if values, ok := request.MultiValueQueryParameters[binding.name]; ok && len(values) > 0 {
+			return values, true
+		}
+		value, ok := request.QueryParameters[binding.name]
+		return []string{value}, ok
+	case bindingSourceHeader:
+		value := request.Header(binding.name)
+		return []string{value}, value != ""
+	}
+	return nil, false
+}
+
+// timeType and durationType are matched before kind-based conversion.
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// setFieldValue converts raw string values from source into the field's type and assigns them.
+func setFieldValue(field reflect.Value, values []string, source bindingSource) error {
+	if field.Kind() == reflect.Ptr {
+		element := reflect.New(field.Type().Elem())
+		if err := setFieldValue(element.Elem(), values, source); err != nil {
+			return err
+		}
+		field.Set(element)
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice {
+		// A single comma-joined query value (as HTTP API delivers repeated query keys) is
+		// split; form values and headers may contain commas of their own
+		if len(values) == 1 && source == bindingSourceQuery {
+			values = strings.Split(values[0], ",")
+		}
+
+		slice := reflect.MakeSlice(field.Type(), 0, len(values))
+		for _, value := range values {
+			element := reflect.New(field.Type().Elem()).Elem()
+			if err := setScalarValue(element, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, element)
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setScalarValue(field, values[len(values)-1])
+}
+
+// setScalarValue converts a single raw string into a scalar field.
+func setScalarValue(field reflect.Value, value string) error {
+	switch field.Type() {
+	case timeType:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("must be an RFC 3339 timestamp")
+		}
+		field.Set(reflect.ValueOf(parsed))
+		return nil
+	case durationType:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration")
+		}
+		field.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be a boolean")
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a non-negative integer")
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		field.SetFloat(parsed)
+	default:
+		return &unsupportedFieldTypeError{fieldType: field.Type()}
+	}
+
+	return nil
+}
+
+// unsupportedFieldTypeError reports a bound field whose type has no conversion from
+// strings. It is a mistake in the struct, not in the request.
+type unsupportedFieldTypeError struct {
+	fieldType reflect.Type
+}
+
+func (e *unsupportedFieldTypeError) Error() string {
+	return fmt.Sprintf("unsupported field type %s", e.fieldType)
+}
@@ -0,0 +1,210 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindAddress struct {
+	City string `json:"city"`
+}
+
+type bindPaging struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+type bindRequest struct {
+	bindPaging
+
+	ID        string        `json:"-" path:"id"`
+	APIKey    string        `json:"-" header:"X-Api-Key"`
+	Tags      []string      `json:"-" query:"tag"`
+	Verbose   *bool         `json:"-" query:"verbose"`
+	Since     time.Time     `json:"-" query:"since"`
+	Timeout   time.Duration `json:"-" query:"timeout"`
+	Name      string        `json:"name"`
+	Age       int           `json:"age"`
+	Address   bindAddress   `json:"address"`
+	Untouched string        `json:"-"`
+}
+
+func TestBind(t *testing.T) {
+	request := &Request{
+		Body:           `{"name":"Alice","age":30,"address":{"city":"Lisbon"}}`,
+		PathParameters: map[string]string{"id": "42"},
+		QueryParameters: map[string]string{
+			"limit":   "10",
+			"offset":  "20",
+			"verbose": "true",
+			"tag":     "a,b",
+			"since":   "2024-01-15T10:30:00Z",
+			"timeout": "5s",
+		},
+		Headers: map[string]string{"x-api-key": "secret"},
+	}
+
+	var input bindRequest
+	require.NoError(t, Bind(request, &input))
+
+	assert.Equal(t, "42", input.ID)
+	assert.Equal(t, "secret", input.APIKey)
+	assert.Equal(t, 10, input.Limit)
+	assert.Equal(t, 20, input.Offset)
+	assert.Equal(t, []string{"a", "b"}, input.Tags)
+	require.NotNil(t, input.Verbose)
+	assert.True(t, *input.Verbose)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), input.Since)
+	assert.Equal(t, 5*time.Second, input.Timeout)
+	assert.Equal(t, "Alice", input.Name)
+	assert.Equal(t, 30, input.Age)
+	assert.Equal(t, "Lisbon", input.Address.City)
+	assert.Empty(t, input.Untouched)
+}
+
+func TestBind_MultiValueQuery(t *testing.T) {
+	request := &Request{
+		MultiValueQueryParameters: map[string][]string{"tag": {"a", "b", "c"}},
+	}
+
+	var input bindRequest
+	require.NoError(t, Bind(request, &input))
+	assert.Equal(t, []string{"a", "b", "c"}, input.Tags)
+}
+
+func TestBind_Base64Body(t *testing.T) {
+	request := &Request{
+		Body:            base64.StdEncoding.EncodeToString([]byte(`{"name":"Bob"}`)),
+		IsBase64Encoded: true,
+	}
+
+	var input bindRequest
+	require.NoError(t, Bind(request, &input))
+	assert.Equal(t, "Bob", input.Name)
+}
+
+func TestBind_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       *Request
+		options       BindOptions
+		expectedField string
+	}{
+		{
+			name:          "malformed JSON",
+			request:       &Request{Body: `{"name":`},
+			expectedField: "body",
+		},
+		{
+			name:          "wrong JSON type",
+			request:       &Request{Body: `{"age":"thirty"}`},
+			expectedField: "age",
+		},
+		{
+			name:          "wrong nested JSON type",
+			request:       &Request{Body: `{"address":{"city":7}}`},
+			expectedField: "address.city",
+		},
+		{
+			name:          "unknown field",
+			request:       &Request{Body: `{"name":"Alice","role":"admin"}`},
+			options:       BindOptions{DisallowUnknownFields: true},
+			expectedField: "role",
+		},
+		{
+			name:          "trailing data",
+			request:       &Request{Body: `{"name":"Alice"} {}`},
+			expectedField: "body",
+		},
+		{
+			name:          "invalid query integer",
+			request:       &Request{QueryParameters: map[string]string{"limit": "ten"}},
+			expectedField: "limit",
+		},
+		{
+			name:          "invalid query boolean",
+			request:       &Request{QueryParameters: map[string]string{"verbose": "maybe"}},
+			expectedField: "verbose",
+		},
+		{
+			name:          "invalid query timestamp",
+			request:       &Request{QueryParameters: map[string]string{"since": "yesterday"}},
+			expectedField: "since",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input bindRequest
+			err := BindWithOptions(tt.request, &input, tt.options)
+
+			require.Error(t, err)
+			validationErr, ok := err.(*ValidationError)
+			require.True(t, ok, "expected ValidationError, got %T", err)
+			assert.Equal(t, tt.expectedField, validationErr.Field)
+		})
+	}
+}
+
+func TestBind_UnknownFieldsAllowedByDefault(t *testing.T) {
+	var input bindRequest
+	err := Bind(&Request{Body: `{"name":"Alice","role":"admin"}`}, &input)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", input.Name)
+}
+
+func TestBind_InvalidTarget(t *testing.T) {
+	var notStruct string
+	assert.True(t, IsInternalError(Bind(&Request{}, &notStruct)))
+	assert.True(t, IsInternalError(Bind(&Request{}, bindRequest{})))
+
+	// A field type that cannot be bound is a mistake in the struct, not the request
+	var unsupported struct {
+		Filter map[string]string `query:"filter"`
+	}
+	assert.True(t, IsInternalError(Bind(&Request{QueryParameters: map[string]string{"filter": "x"}}, &unsupported)))
+}
+
+func TestTypedHandler(t *testing.T) {
+	type getUserRequest struct {
+		ID int `path:"id"`
+	}
+	type getUserResponse struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	handler := TypedHandler(func(ctx context.Context, request getUserRequest) (*getUserResponse, error) {
+		return &getUserResponse{ID: request.ID, Name: "Alice"}, nil
+	})
+
+	result, err := handler(context.Background(), &Request{PathParameters: map[string]string{"id": "7"}})
+	require.NoError(t, err)
+	assert.Equal(t, &getUserResponse{ID: 7, Name: "Alice"}, result)
+
+	_, err = handler(context.Background(), &Request{PathParameters: map[string]string{"id": "abc"}})
+	assert.True(t, IsValidationError(err))
+}
+
+func TestTypedHandlerWithOptions_PointerRequest(t *testing.T) {
+	type createUserRequest struct {
+		Name string `json:"name"`
+	}
+
+	handler := TypedHandlerWithOptions(func(ctx context.Context, request *createUserRequest) (string, error) {
+		return request.Name, nil
+	}, BindOptions{DisallowUnknownFields: true})
+
+	result, err := handler(context.Background(), &Request{Body: `{"name":"Alice"}`})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", result)
+
+	_, err = handler(context.Background(), &Request{Body: `{"name":"Alice","admin":true}`})
+	assert.True(t, IsValidationError(err))
+}
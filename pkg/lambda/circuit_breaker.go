@@ -0,0 +1,317 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"lambda-go-template/pkg/observability"
+)
+
+// ErrCircuitOpen is wrapped by the ExternalServiceError returned while a circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// ConsecutiveFailures trips the circuit after this many failures in a row. Zero disables it.
+	ConsecutiveFailures int
+	// FailureRate trips the circuit when the share of failed calls within Window reaches it,
+	// once at least MinRequests calls completed. Zero disables it.
+	FailureRate float64
+	MinRequests int
+	Window      time.Duration
+	// OpenTimeout is how long the circuit stays open before letting probe calls through.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe calls allowed while half-open. The circuit
+	// closes once they all succeed and opens again on the first failure.
+	HalfOpenRequests int
+	// IsFailure decides which errors count against the dependency. Defaults to
+	// IsDependencyFailure.
+	IsFailure func(err error) bool
+}
+
+// DefaultCircuitBreakerConfig returns a configuration that trips after five consecutive
+// failures or a 50% failure rate over a minute, and probes again after 30 seconds.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		ConsecutiveFailures: 5,
+		FailureRate:         0.5,
+		MinRequests:         10,
+		Window:              time.Minute,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenRequests:    1,
+	}
+}
+
+// IsDependencyFailure reports whether err indicates an unhealthy dependency. Client errors
+// such as validation, not found, conflict and authorization errors, and cancellations by
+// the caller, do not count.
+func IsDependencyFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	switch {
+	case IsValidationError(err), IsNotFoundError(err), IsConflictError(err),
+		IsUnauthorizedError(err), IsForbiddenError(err), IsBusinessLogicError(err):
+		return false
+	}
+
+	return true
+}
+
+// CircuitBreaker stops calling a failing dependency for a while so requests fail fast
+// instead of waiting for it to time out.
+//
+// Create breakers once per container, outside the handler, so their state carries over
+// between warm invocations. A CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+	logger *observability.Logger
+	tracer *observability.Tracer
+	now    func() time.Time
+
+	mu                  sync.Mutex
+	state               CircuitState
+	generation          uint64
+	expiry              time.Time // end of the counting window when closed, of the open period when open
+	requests            int
+	failures            int
+	consecutiveFailures int
+	probes              int
+	probeSuccesses      int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker for the named dependency.
+func NewCircuitBreaker(name string, config CircuitBreakerConfig, logger *observability.Logger, tracer *observability.Tracer) *CircuitBreaker {
+	if config.HalfOpenRequests < 1 {
+		config.HalfOpenRequests = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = IsDependencyFailure
+	}
+
+	cb := &CircuitBreaker{
+		name:   name,
+		config: config,
+		logger: logger,
+		tracer: tracer,
+		now:    time.Now,
+	}
+	cb.resetWindow(cb.now())
+
+	return cb
+}
+
+// Name returns the name of the protected dependency.
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// State returns the current state of the circuit.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.currentState(context.Background(), cb.now())
+}
+
+// Execute calls fn unless the circuit is open, in which case it returns a retryable
+// ExternalServiceError wrapping ErrCircuitOpen without calling fn.
+func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(context.Context) error) error {
+	generation, err := cb.before(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if value := recover(); value != nil {
+			cb.after(ctx, generation, fmt.Errorf("panic: %v", value))
+			panic(value)
+		}
+	}()
+
+	err = fn(ctx)
+	cb.after(ctx, generation, err)
+	return err
+}
+
+// ExecuteWithBreaker calls fn through the circuit breaker and returns its result.
+func ExecuteWithBreaker[T any](ctx context.Context, cb *CircuitBreaker, fn func(context.Context) (T, error)) (T, error) {
+	var result T
+	err := cb.Execute(ctx, func(ctx context.Context) error {
+		value, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		result = value
+		return nil
+	})
+	return result, err
+}
+
+// before admits a call, returning the generation it belongs to.
+func (cb *CircuitBreaker) before(ctx context.Context) (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	switch cb.currentState(ctx, now) {
+	case CircuitOpen:
+		return 0, cb.openError(ctx, cb.expiry.Sub(now))
+	case CircuitHalfOpen:
+		if cb.probes >= cb.config.HalfOpenRequests {
+			return 0, cb.openError(ctx, defaultRetryAfter)
+		}
+		cb.probes++
+	}
+
+	return cb.generation, nil
+}
+
+// after records the outcome of a call admitted in the given generation. Outcomes of calls
+// that started before the last state change are ignored.
+func (cb *CircuitBreaker) after(ctx context.Context, generation uint64, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	state := cb.currentState(ctx, now)
+	if generation != cb.generation {
+		return
+	}
+
+	failed := cb.config.IsFailure(err)
+
+	switch state {
+	case CircuitClosed:
+		cb.requests++
+		if !failed {
+			cb.consecutiveFailures = 0
+			return
+		}
+		cb.failures++
+		cb.consecutiveFailures++
+		if cb.shouldTrip() {
+			cb.setState(ctx, CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			cb.setState(ctx, CircuitOpen, now)
+			return
+		}
+		cb.probeSuccesses++
+		if cb.probeSuccesses >= cb.config.HalfOpenRequests {
+			cb.setState(ctx, CircuitClosed, now)
+		}
+	}
+}
+
+// shouldTrip reports whether the closed circuit has seen enough failures to open.
+func (cb *CircuitBreaker) shouldTrip() bool {
+	if cb.config.ConsecutiveFailures > 0 && cb.consecutiveFailures >= cb.config.ConsecutiveFailures {
+		return true
+	}
+
+	if cb.config.FailureRate > 0 && cb.requests >= cb.config.MinRequests {
+		return float64(cb.failures)/float64(cb.requests) >= cb.config.FailureRate
+	}
+
+	return false
+}
+
+// currentState applies time-based transitions: an expired open period moves the circuit to
+// half-open and an expired counting window clears the closed circuit's counts.
+func (cb *CircuitBreaker) currentState(ctx context.Context, now time.Time) CircuitState {
+	switch cb.state {
+	case CircuitClosed:
+		if !cb.expiry.IsZero() && !now.Before(cb.expiry) {
+			cb.resetWindow(now)
+		}
+	case CircuitOpen:
+		if !now.Before(cb.expiry) {
+			cb.setState(ctx, CircuitHalfOpen, now)
+		}
+	}
+	return cb.state
+}
+
+// setState moves the circuit to state, starting a new generation.
+func (cb *CircuitBreaker) setState(ctx context.Context, state CircuitState, now time.Time) {
+	previous := cb.state
+	fields := map[string]interface{}{
+		"circuit":              cb.name,
+		"from":                 previous.String(),
+		"to":                   state.String(),
+		"requests":             cb.requests,
+		"failures":             cb.failures,
+		"consecutive_failures": cb.consecutiveFailures,
+	}
+
+	cb.state = state
+	cb.generation++
+	cb.probes = 0
+	cb.probeSuccesses = 0
+
+	switch state {
+	case CircuitClosed:
+		cb.consecutiveFailures = 0
+		cb.resetWindow(now)
+	case CircuitOpen:
+		cb.expiry = now.Add(cb.config.OpenTimeout)
+	}
+
+	// Annotation keys only allow letters, digits and underscores, so the breaker's name
+	// goes into metadata rather than the key
+	cb.tracer.AddAnnotation(ctx, "circuit_state", state.String())
+	cb.tracer.AddMetadata(ctx, "circuit_breaker", map[string]string{"name": cb.name, "from": previous.String(), "to": state.String()})
+	logger := cb.logger.WithFields(fields).WithContext(ctx)
+	if state == CircuitOpen {
+		logger.Warn("Circuit breaker opened")
+	} else {
+		logger.Info("Circuit breaker state changed")
+	}
+}
+
+// resetWindow clears the failure rate counts and starts a new counting window.
+func (cb *CircuitBreaker) resetWindow(now time.Time) {
+	cb.requests = 0
+	cb.failures = 0
+	cb.expiry = time.Time{}
+	if cb.config.Window > 0 {
+		cb.expiry = now.Add(cb.config.Window)
+	}
+}
+
+// openError builds the error returned for calls rejected by the circuit.
+func (cb *CircuitBreaker) openError(ctx context.Context, retryAfter time.Duration) error {
+	cb.tracer.AddAnnotation(ctx, "circuit_open", cb.name)
+	return NewExternalServiceError(cb.name, "circuit breaker is open", 0, true, ErrCircuitOpen).
+		WithRetryAfter(retryAfter)
+}
@@ -0,0 +1,169 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/observability"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a manually advanced clock for circuit breaker tests.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestCircuitBreaker(t *testing.T, config CircuitBreakerConfig) (*CircuitBreaker, *testClock) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker("payments", config, testutil.TestLogger(t), testutil.TestTracer())
+	cb.now = clock.Now
+	cb.resetWindow(clock.Now())
+	return cb, clock
+}
+
+func failWith(err error) func(context.Context) error {
+	return func(context.Context) error { return err }
+}
+
+func TestCircuitBreaker_ConsecutiveFailures(t *testing.T) {
+	cb, clock := newTestCircuitBreaker(t, CircuitBreakerConfig{ConsecutiveFailures: 3, OpenTimeout: 10 * time.Second})
+	downstream := NewExternalServiceError("payments", "unavailable", 503, true, nil)
+	ctx := context.Background()
+
+	// A success resets the consecutive count
+	cb.Execute(ctx, failWith(downstream))
+	cb.Execute(ctx, failWith(downstream))
+	require.NoError(t, cb.Execute(ctx, failWith(nil)))
+	cb.Execute(ctx, failWith(downstream))
+	cb.Execute(ctx, failWith(downstream))
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	cb.Execute(ctx, failWith(downstream))
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	// Open circuits fail fast without calling the dependency
+	called := false
+	err := cb.Execute(ctx, func(context.Context) error {
+		called = true
+		return nil
+	})
+	assert.False(t, called)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.True(t, IsRetryableError(err))
+
+	var extErr *ExternalServiceError
+	require.True(t, errors.As(err, &extErr))
+	assert.Equal(t, "payments", extErr.Service)
+	assert.Equal(t, 10*time.Second, extErr.RetryAfter)
+
+	clock.Advance(4 * time.Second)
+	require.True(t, errors.As(cb.Execute(ctx, failWith(nil)), &extErr))
+	assert.Equal(t, 6*time.Second, extErr.RetryAfter)
+}
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	cb, clock := newTestCircuitBreaker(t, CircuitBreakerConfig{FailureRate: 0.5, MinRequests: 4, Window: time.Minute, OpenTimeout: time.Second})
+	downstream := errors.New("connection reset")
+	ctx := context.Background()
+
+	cb.Execute(ctx, failWith(downstream))
+	cb.Execute(ctx, failWith(nil))
+	cb.Execute(ctx, failWith(downstream))
+	assert.Equal(t, CircuitClosed, cb.State(), "below minimum request count")
+
+	// A new window forgets earlier failures
+	clock.Advance(time.Minute)
+	cb.Execute(ctx, failWith(nil))
+	cb.Execute(ctx, failWith(downstream))
+	cb.Execute(ctx, failWith(nil))
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	cb.Execute(ctx, failWith(downstream))
+	assert.Equal(t, CircuitOpen, cb.State())
+}
+
+func TestCircuitBreaker_IgnoresClientErrors(t *testing.T) {
+	cb, _ := newTestCircuitBreaker(t, CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Second})
+
+	cb.Execute(context.Background(), failWith(NewResourceNotFoundError("user", "42", "user not found")))
+	cb.Execute(context.Background(), failWith(NewValidationError("bad id", "id", "x")))
+	cb.Execute(context.Background(), failWith(context.Canceled))
+
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	downstream := NewTimeoutError("query", time.Second)
+	ctx := context.Background()
+
+	t.Run("probe success closes", func(t *testing.T) {
+		cb, clock := newTestCircuitBreaker(t, CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Second, HalfOpenRequests: 1})
+		cb.Execute(ctx, failWith(downstream))
+		require.Equal(t, CircuitOpen, cb.State())
+
+		clock.Advance(time.Second)
+		assert.Equal(t, CircuitHalfOpen, cb.State())
+
+		// Only HalfOpenRequests probes are admitted concurrently
+		err := cb.Execute(ctx, func(ctx context.Context) error {
+			assert.ErrorIs(t, cb.Execute(ctx, failWith(nil)), ErrCircuitOpen)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, CircuitClosed, cb.State())
+	})
+
+	t.Run("probe failure reopens", func(t *testing.T) {
+		cb, clock := newTestCircuitBreaker(t, CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Second})
+		cb.Execute(ctx, failWith(downstream))
+		clock.Advance(time.Second)
+
+		cb.Execute(ctx, failWith(downstream))
+		assert.Equal(t, CircuitOpen, cb.State())
+	})
+}
+
+func TestCircuitBreaker_Panic(t *testing.T) {
+	cb, _ := newTestCircuitBreaker(t, CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Second})
+
+	assert.Panics(t, func() {
+		cb.Execute(context.Background(), func(context.Context) error { panic("boom") })
+	})
+	assert.Equal(t, CircuitOpen, cb.State())
+}
+
+func TestExecuteWithBreaker(t *testing.T) {
+	cb, _ := newTestCircuitBreaker(t, DefaultCircuitBreakerConfig())
+
+	value, err := ExecuteWithBreaker(context.Background(), cb, func(context.Context) (int, error) {
+		return 42, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, value)
+}
+
+func TestCircuitBreaker_TraceAnnotation(t *testing.T) {
+	tracer := observability.NewTracer(observability.TracingConfig{Enabled: true, ServiceName: "test-service"})
+	cb := NewCircuitBreaker("users-repository", CircuitBreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Second}, testutil.TestLogger(t), tracer)
+
+	ctx, seg := xray.BeginSegment(context.Background(), "test")
+	defer seg.Close(nil)
+	cb.Execute(ctx, failWith(NewExternalServiceError("users", "unavailable", 503, true, nil)))
+
+	// X-Ray drops annotation keys with characters other than letters, digits and underscores
+	assert.Equal(t, "open", seg.Annotations["circuit_state"])
+	for key := range seg.Annotations {
+		assert.Regexp(t, `^[A-Za-z0-9_]+$`, key)
+	}
+	assert.Equal(t, map[string]string{"name": "users-repository", "from": "closed", "to": "open"}, seg.Metadata["default"]["circuit_breaker"])
+}
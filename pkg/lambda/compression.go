@@ -0,0 +1,90 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"lambda-go-template/pkg/http"
+)
+
+// compressionEncodings lists the supported content codings in order of preference.
+var compressionEncodings = []string{http.EncodingBrotli, http.EncodingGzip}
+
+// compress encodes the response body with the best content coding the request accepts.
+// Only text-like bodies of at least CompressionMinSize bytes are compressed, and only when
+// that makes them smaller; the compressed body is base64-encoded for API Gateway and a
+// strong ETag becomes weak. Responses that are already encoded, or empty by status, are
+// left alone. REST API responses are only compressed with CompressRESTAPI set, since a
+// REST API without binaryMediaTypes would send clients the base64 text itself.
+func (h *Handler) compress(ctx context.Context, request *Request, response *http.Response) {
+	if !h.config.EnableCompression || (request.Source == EventSourceAPIGateway && !h.config.CompressRESTAPI) {
+		return
+	}
+	if response.IsBase64Encoded || response.StatusCode == 204 || response.StatusCode == 304 {
+		return
+	}
+	if len(response.Body) < h.config.CompressionMinSize || responseHeader(response.Headers, "Content-Encoding") != "" {
+		return
+	}
+	if !http.IsCompressible(responseHeader(response.Headers, "Content-Type")) {
+		return
+	}
+
+	// The body now depends on Accept-Encoding, whether or not this client accepts compression
+	headers := make(map[string]string, len(response.Headers)+2)
+	for key, value := range response.Headers {
+		headers[key] = value
+	}
+	headers["Vary"] = http.AppendVary(headers["Vary"], "Accept-Encoding")
+	response.Headers = headers
+
+	encoding := http.NegotiateEncoding(request.Header("Accept-Encoding"), compressionEncodings...)
+	if encoding == "" {
+		return
+	}
+
+	compressed, err := http.Compress([]byte(response.Body), encoding)
+	if err != nil {
+		h.logger.WithFields(map[string]interface{}{
+			"encoding": encoding,
+			"error":    err.Error(),
+		}).WithContext(ctx).Warn("Response compression failed, sending uncompressed body")
+		return
+	}
+	if len(compressed) >= len(response.Body) {
+		return
+	}
+
+	h.tracer.AddAnnotation(ctx, "content_encoding", encoding)
+	h.tracer.AddMetadata(ctx, "compression", map[string]interface{}{
+		"encoding":         encoding,
+		"original_bytes":   len(response.Body),
+		"compressed_bytes": len(compressed),
+	})
+
+	// A strong tag names the exact bytes, so it cannot be shared with the encoded body
+	for key, value := range headers {
+		if strings.EqualFold(key, "ETag") {
+			headers[key] = http.WeakenETag(value)
+		}
+	}
+
+	headers["Content-Encoding"] = encoding
+	response.Body = base64.StdEncoding.EncodeToString(compressed)
+	response.IsBase64Encoded = true
+}
+
+// responseHeader returns the named response header, matching the name case-insensitively.
+func responseHeader(headers map[string]string, name string) string {
+	if value, ok := headers[name]; ok {
+		return value
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
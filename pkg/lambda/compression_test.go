@@ -0,0 +1,210 @@
+package lambda
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{acceptEncoding: "gzip, deflate, br", expected: "br"},
+		{acceptEncoding: "gzip", expected: "gzip"},
+		{acceptEncoding: "br;q=0.5, gzip;q=0.8", expected: "gzip"},
+		{acceptEncoding: "br;q=0, gzip", expected: "gzip"},
+		{acceptEncoding: "*", expected: "br"},
+		{acceptEncoding: "*;q=0.1, br;q=0", expected: "gzip"},
+		{acceptEncoding: "identity", expected: ""},
+		{acceptEncoding: "", expected: ""},
+		{acceptEncoding: "GZIP", expected: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			assert.Equal(t, tt.expected, http.NegotiateEncoding(tt.acceptEncoding, http.EncodingBrotli, http.EncodingGzip))
+		})
+	}
+}
+
+// largeUsers returns a handler whose JSON response is well above the compression threshold.
+func largeUsers(ctx context.Context, request *Request) (interface{}, error) {
+	users := make([]map[string]string, 100)
+	for i := range users {
+		users[i] = map[string]string{"name": "User", "email": "user@example.com"}
+	}
+	return users, nil
+}
+
+// decompress decodes a base64-encoded compressed response body.
+func decompress(t *testing.T, body, encoding string) string {
+	t.Helper()
+
+	compressed, err := base64.StdEncoding.DecodeString(body)
+	require.NoError(t, err)
+
+	var reader io.Reader
+	switch encoding {
+	case http.EncodingGzip:
+		reader, err = gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+	case http.EncodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(compressed))
+	}
+
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+// responseData returns the data of a success response body, which unlike the request ID
+// and timestamp is the same for every request.
+func responseData(t *testing.T, body string) interface{} {
+	t.Helper()
+
+	var response http.SuccessResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	return response.Data
+}
+
+func compressionHandler(t *testing.T) *Handler {
+	cfg := testutil.TestConfig()
+	cfg.EnableCompression = true
+	cfg.CompressionMinSize = 1024
+	return NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+}
+
+func TestHandler_Compression(t *testing.T) {
+	uncompressed, err := compressionHandler(t).WrapHTTPAPI(largeUsers)(testutil.CreateTestContext("plain"), testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(uncompressed.Body), 1024)
+
+	t.Run("uncompressed without Accept-Encoding", func(t *testing.T) {
+		assert.False(t, uncompressed.IsBase64Encoded)
+		assert.Empty(t, uncompressed.Headers["Content-Encoding"])
+		assert.Equal(t, "Accept-Encoding", uncompressed.Headers["Vary"])
+	})
+
+	for _, encoding := range []string{http.EncodingGzip, http.EncodingBrotli} {
+		t.Run(encoding+" for HTTP API", func(t *testing.T) {
+			request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{"accept-encoding": encoding})
+			response, err := compressionHandler(t).WrapHTTPAPI(largeUsers)(testutil.CreateTestContext("compressed"), request)
+			require.NoError(t, err)
+
+			assert.Equal(t, 200, response.StatusCode)
+			assert.True(t, response.IsBase64Encoded)
+			assert.Equal(t, encoding, response.Headers["Content-Encoding"])
+			assert.Equal(t, "Accept-Encoding", response.Headers["Vary"])
+			assert.Less(t, len(response.Body), len(uncompressed.Body))
+			assert.Equal(t, responseData(t, uncompressed.Body), responseData(t, decompress(t, response.Body, encoding)))
+		})
+	}
+
+	t.Run("REST API responses are sent as is by default", func(t *testing.T) {
+		h := compressionHandler(t)
+		wrapped := h.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
+			return largeUsers(ctx, nil)
+		})
+
+		request := testutil.CreateTestAPIGatewayRequestWithHeaders("GET", "/users", map[string]string{"Accept-Encoding": "gzip, deflate"})
+		response, err := wrapped(testutil.CreateTestContext("uncompressed"), request)
+		require.NoError(t, err)
+
+		assert.False(t, response.IsBase64Encoded)
+		assert.Empty(t, response.Headers["Content-Encoding"])
+		assert.Equal(t, responseData(t, uncompressed.Body), responseData(t, response.Body))
+	})
+
+	t.Run("gzip for REST API when enabled", func(t *testing.T) {
+		h := compressionHandler(t)
+		h.config.CompressRESTAPI = true
+		wrapped := h.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
+			return largeUsers(ctx, nil)
+		})
+
+		request := testutil.CreateTestAPIGatewayRequestWithHeaders("GET", "/users", map[string]string{"Accept-Encoding": "gzip, deflate"})
+		response, err := wrapped(testutil.CreateTestContext("compressed"), request)
+		require.NoError(t, err)
+
+		assert.True(t, response.IsBase64Encoded)
+		assert.Equal(t, "gzip", response.Headers["Content-Encoding"])
+		assert.Equal(t, responseData(t, uncompressed.Body), responseData(t, decompress(t, response.Body, http.EncodingGzip)))
+	})
+
+	t.Run("strong ETags become weak when the body is compressed", func(t *testing.T) {
+		h := compressionHandler(t)
+		h.config.ETagMode = ETagModeStrong
+		wrapped := h.WrapHTTPAPI(largeUsers)
+
+		plain, err := wrapped(testutil.CreateTestContext("plain"), testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		strong := plain.Headers["ETag"]
+		require.Regexp(t, `^"[^"]+"$`, strong)
+
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{"accept-encoding": "gzip"})
+		compressed, err := wrapped(testutil.CreateTestContext("compressed"), request)
+		require.NoError(t, err)
+		require.Equal(t, "gzip", compressed.Headers["Content-Encoding"])
+		assert.Equal(t, "W/"+strong, compressed.Headers["ETag"])
+
+		// The weak tag still revalidates the compressed copy
+		request.Headers["if-none-match"] = compressed.Headers["ETag"]
+		revalidated, err := wrapped(testutil.CreateTestContext("revalidated"), request)
+		require.NoError(t, err)
+		assert.Equal(t, 304, revalidated.StatusCode)
+	})
+
+	t.Run("small bodies are sent as is", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{"accept-encoding": "gzip"})
+		response, err := compressionHandler(t).WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			return map[string]string{"status": "ok"}, nil
+		})(testutil.CreateTestContext("small"), request)
+		require.NoError(t, err)
+
+		assert.False(t, response.IsBase64Encoded)
+		assert.Empty(t, response.Headers["Content-Encoding"])
+	})
+
+	t.Run("binary content types are sent as is", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{"accept-encoding": "gzip"})
+		response, err := compressionHandler(t).WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			return http.Response{
+				StatusCode: 200,
+				Headers:    map[string]string{"Content-Type": "image/png"},
+				Body:       strings.Repeat("x", 2048),
+			}, nil
+		})(testutil.CreateTestContext("binary"), request)
+		require.NoError(t, err)
+
+		assert.False(t, response.IsBase64Encoded)
+		assert.Empty(t, response.Headers["Content-Encoding"])
+	})
+
+	t.Run("disabled by configuration", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.EnableCompression = false
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{"accept-encoding": "gzip"})
+		response, err := h.WrapHTTPAPI(largeUsers)(testutil.CreateTestContext("disabled"), request)
+		require.NoError(t, err)
+
+		assert.False(t, response.IsBase64Encoded)
+		assert.Empty(t, response.Headers["Content-Encoding"])
+	})
+}
@@ -0,0 +1,117 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"lambda-go-template/pkg/http"
+)
+
+// ETag modes for entity tags computed from response bodies.
+const (
+	ETagModeWeak   = "weak"
+	ETagModeStrong = "strong"
+	ETagModeOff    = "off"
+)
+
+// SetResponseETag sets the entity tag of the response to the current request, such as
+// http.StrongETag(record.Version), instead of the one computed from the body.
+func SetResponseETag(ctx context.Context, etag string) {
+	SetResponseHeader(ctx, "ETag", etag)
+}
+
+// SetResponseLastModified sets the time the resource in the response to the current
+// request was last modified, which If-Modified-Since is evaluated against.
+func SetResponseLastModified(ctx context.Context, t time.Time) {
+	SetResponseHeader(ctx, "Last-Modified", http.FormatHTTPDate(t))
+}
+
+// conditional adds validators to successful GET and HEAD responses and answers conditional
+// requests whose copy is still current with 304 Not Modified. Responses keep an ETag the
+// handler set; otherwise one is computed from the data the handler returned. The envelope's
+// request ID and timestamp differ on every response, so they are left out of the hash.
+func (h *Handler) conditional(ctx context.Context, request *Request, data interface{}, response *http.Response) {
+	if (request.Method != "GET" && request.Method != "HEAD") || response.StatusCode != 200 {
+		return
+	}
+
+	etag := responseHeader(response.Headers, "ETag")
+	if etag == "" && h.config.ETagMode != ETagModeOff {
+		weak := h.config.ETagMode != ETagModeStrong
+		if rendered, ok := data.(http.Response); ok {
+			if body, err := rendered.BodyBytes(); err == nil {
+				etag = http.ComputeETag(body, weak)
+			}
+		} else if computed, err := http.ComputeJSONETag(data, weak); err == nil {
+			etag = computed
+		}
+
+		if etag != "" {
+			headers := make(map[string]string, len(response.Headers)+1)
+			for key, value := range response.Headers {
+				headers[key] = value
+			}
+			headers["ETag"] = etag
+			response.Headers = headers
+		}
+	}
+
+	if !notModified(request, etag, responseHeader(response.Headers, "Last-Modified")) {
+		return
+	}
+
+	h.tracer.AddAnnotation(ctx, "not_modified", true)
+
+	// A 304 carries the validators and caching headers of the full response, but no content
+	headers := make(map[string]string, len(response.Headers))
+	for key, value := range response.Headers {
+		if !strings.EqualFold(key, "Content-Type") && !strings.EqualFold(key, "Content-Length") {
+			headers[key] = value
+		}
+	}
+	response.StatusCode = 304
+	response.Headers = headers
+	response.Body = ""
+	response.IsBase64Encoded = false
+}
+
+// notModified reports whether the client's cached copy, described by If-None-Match or
+// else If-Modified-Since, is still current.
+func notModified(request *Request, etag, lastModified string) bool {
+	if ifNoneMatch := request.Header("If-None-Match"); ifNoneMatch != "" {
+		return http.MatchETag(ifNoneMatch, etag, false)
+	}
+
+	ifModifiedSince, ok := http.ParseHTTPDate(request.Header("If-Modified-Since"))
+	if !ok {
+		return false
+	}
+	modified, ok := http.ParseHTTPDate(lastModified)
+	return ok && !modified.After(ifModifiedSince)
+}
+
+// CheckPreconditions evaluates the If-Match, If-Unmodified-Since and If-None-Match headers
+// of a write against the current etag and lastModified time of the resource, either of
+// which may be empty or zero when unknown, and returns a PreconditionFailedError when they
+// do not hold. Handlers call it after loading the resource and before changing it, so
+// clients cannot overwrite changes they have not seen.
+func CheckPreconditions(request *Request, etag string, lastModified time.Time) error {
+	if ifMatch := request.Header("If-Match"); ifMatch != "" {
+		if !http.MatchETag(ifMatch, etag, true) {
+			return NewPreconditionFailedError("The resource has changed since it was retrieved", "If-Match")
+		}
+	} else if ifUnmodifiedSince, ok := http.ParseHTTPDate(request.Header("If-Unmodified-Since")); ok && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(ifUnmodifiedSince) {
+			return NewPreconditionFailedError("The resource has changed since it was retrieved", "If-Unmodified-Since")
+		}
+	}
+
+	// "If-None-Match: *" on a write creates the resource only if it does not exist yet
+	if ifNoneMatch := request.Header("If-None-Match"); ifNoneMatch != "" && http.MatchETag(ifNoneMatch, etag, false) {
+		return NewPreconditionFailedError("The resource already exists", "If-None-Match")
+	}
+
+	return nil
+}
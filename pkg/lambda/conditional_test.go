@@ -0,0 +1,223 @@
+package lambda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		etag     string
+		strong   bool
+		expected bool
+	}{
+		{name: "same strong tag", header: `"v1"`, etag: `"v1"`, strong: true, expected: true},
+		{name: "tag in list", header: `"v0", "v1"`, etag: `"v1"`, strong: true, expected: true},
+		{name: "different tag", header: `"v0"`, etag: `"v1"`, strong: true, expected: false},
+		{name: "weak header tag with strong comparison", header: `W/"v1"`, etag: `"v1"`, strong: true, expected: false},
+		{name: "weak current tag with strong comparison", header: `"v1"`, etag: `W/"v1"`, strong: true, expected: false},
+		{name: "weak tags with weak comparison", header: `W/"v1"`, etag: `"v1"`, strong: false, expected: true},
+		{name: "comma inside tag", header: `"a,b", "c"`, etag: `"a,b"`, strong: false, expected: true},
+		{name: "wildcard with current representation", header: "*", etag: `"v1"`, strong: true, expected: true},
+		{name: "wildcard without current representation", header: "*", etag: "", strong: true, expected: false},
+		{name: "malformed header", header: "v1", etag: `"v1"`, strong: false, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, http.MatchETag(tt.header, tt.etag, tt.strong))
+		})
+	}
+}
+
+func TestHandler_ConditionalGet(t *testing.T) {
+	ctx := testutil.CreateTestContext("test-request")
+	users := func(ctx context.Context, request *Request) (interface{}, error) {
+		return []string{"ada", "grace"}, nil
+	}
+	etag, err := http.ComputeJSONETag([]string{"ada", "grace"}, true)
+	require.NoError(t, err)
+
+	t.Run("computes a weak ETag from the data", func(t *testing.T) {
+		response, err := newTestHandler(t).WrapHTTPAPI(users)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, etag, response.Headers["ETag"])
+	})
+
+	t.Run("computes a strong ETag in strong mode", func(t *testing.T) {
+		h := newTestHandler(t)
+		h.config.ETagMode = ETagModeStrong
+
+		response, err := h.WrapHTTPAPI(users)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		assert.Regexp(t, `^"[^"]+"$`, response.Headers["ETag"])
+	})
+
+	t.Run("omits the ETag when turned off", func(t *testing.T) {
+		h := newTestHandler(t)
+		h.config.ETagMode = ETagModeOff
+
+		response, err := h.WrapHTTPAPI(users)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		assert.NotContains(t, response.Headers, "ETag")
+	})
+
+	t.Run("returns 304 when If-None-Match lists the ETag", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{
+			"if-none-match": `"stale", ` + etag,
+		})
+
+		response, err := newTestHandler(t).WrapHTTPAPI(users)(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 304, response.StatusCode)
+		assert.Empty(t, response.Body)
+		assert.Equal(t, etag, response.Headers["ETag"])
+		assert.NotContains(t, response.Headers, "Content-Type")
+		assert.Contains(t, response.Headers, "Cache-Control")
+	})
+
+	t.Run("returns 200 when If-None-Match lists other ETags", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{
+			"if-none-match": `W/"stale"`,
+		})
+
+		response, err := newTestHandler(t).WrapHTTPAPI(users)(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.NotEmpty(t, response.Body)
+	})
+
+	t.Run("keeps the ETag supplied by the handler", func(t *testing.T) {
+		versioned := func(ctx context.Context, request *Request) (interface{}, error) {
+			SetResponseETag(ctx, http.StrongETag("v7"))
+			return map[string]string{"id": "1"}, nil
+		}
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users/1", map[string]string{
+			"if-none-match": `"v7"`,
+		})
+
+		response, err := newTestHandler(t).WrapHTTPAPI(versioned)(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 304, response.StatusCode)
+		assert.Equal(t, `"v7"`, response.Headers["ETag"])
+	})
+
+	t.Run("evaluates If-Modified-Since against Last-Modified", func(t *testing.T) {
+		modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		dated := func(ctx context.Context, request *Request) (interface{}, error) {
+			SetResponseLastModified(ctx, modified)
+			return map[string]string{"id": "1"}, nil
+		}
+
+		for ifModifiedSince, expectedStatus := range map[time.Time]int{
+			modified:                 304,
+			modified.Add(time.Hour):  304,
+			modified.Add(-time.Hour): 200,
+		} {
+			request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users/1", map[string]string{
+				"if-modified-since": http.FormatHTTPDate(ifModifiedSince),
+			})
+
+			response, err := newTestHandler(t).WrapHTTPAPI(dated)(ctx, request)
+			require.NoError(t, err)
+			assert.Equal(t, expectedStatus, response.StatusCode, "If-Modified-Since %s", ifModifiedSince)
+		}
+	})
+
+	t.Run("ignores conditions on writes and errors", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("POST", "/users", map[string]string{
+			"if-none-match": "*",
+		})
+
+		response, err := newTestHandler(t).WrapHTTPAPI(users)(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		assert.NotContains(t, response.Headers, "ETag")
+
+		request.RequestContext.HTTP.Method = "GET"
+		response, err = newTestHandler(t).WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			return nil, NewNotFoundError("user not found")
+		})(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, 404, response.StatusCode)
+	})
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		headers           map[string]string
+		etag              string
+		expectedCondition string
+	}{
+		{name: "no conditions", headers: map[string]string{}, etag: `"v2"`},
+		{name: "matching If-Match", headers: map[string]string{"If-Match": `"v2"`}, etag: `"v2"`},
+		{name: "stale If-Match", headers: map[string]string{"If-Match": `"v1"`}, etag: `"v2"`, expectedCondition: "If-Match"},
+		{name: "weak If-Match", headers: map[string]string{"If-Match": `W/"v2"`}, etag: `"v2"`, expectedCondition: "If-Match"},
+		{name: "If-Match wildcard on missing resource", headers: map[string]string{"If-Match": "*"}, etag: "", expectedCondition: "If-Match"},
+		{name: "If-None-Match wildcard on missing resource", headers: map[string]string{"If-None-Match": "*"}, etag: ""},
+		{name: "If-None-Match wildcard on existing resource", headers: map[string]string{"If-None-Match": "*"}, etag: `"v2"`, expectedCondition: "If-None-Match"},
+		{
+			name:    "If-Unmodified-Since after modification",
+			headers: map[string]string{"If-Unmodified-Since": http.FormatHTTPDate(modified)},
+			etag:    `"v2"`,
+		},
+		{
+			name:              "If-Unmodified-Since before modification",
+			headers:           map[string]string{"If-Unmodified-Since": http.FormatHTTPDate(modified.Add(-time.Minute))},
+			etag:              `"v2"`,
+			expectedCondition: "If-Unmodified-Since",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &Request{Method: "PUT", Path: "/users/1", Headers: tt.headers}
+
+			err := CheckPreconditions(request, tt.etag, modified)
+			if tt.expectedCondition == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var preconditionErr *PreconditionFailedError
+			require.ErrorAs(t, err, &preconditionErr)
+			assert.Equal(t, tt.expectedCondition, preconditionErr.Condition)
+		})
+	}
+
+	t.Run("stale writes get 412", func(t *testing.T) {
+		update := func(ctx context.Context, request *Request) (interface{}, error) {
+			if err := CheckPreconditions(request, `"v2"`, time.Time{}); err != nil {
+				return nil, err
+			}
+			return map[string]string{"id": "1"}, nil
+		}
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("PUT", "/users/1", map[string]string{"if-match": `"v1"`})
+
+		response, err := newTestHandler(t).WrapHTTPAPI(update)(testutil.CreateTestContext("test-request"), request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 412, response.StatusCode)
+		testutil.AssertErrorResponse(t, response.Body, "The resource has changed since it was retrieved")
+		assert.Contains(t, response.Body, ErrorCodePrecondition)
+	})
+}
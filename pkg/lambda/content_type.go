@@ -0,0 +1,192 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"strings"
+
+	"lambda-go-template/pkg/http"
+)
+
+// bindableContentTypes lists the media types Bind decodes. Validation accepts request
+// bodies in these types unless a route narrows them with AllowContentTypes.
+var bindableContentTypes = []string{http.ContentTypeJSON, http.ContentTypeFormURLEncoded, http.ContentTypeMultipartForm}
+
+// AllowContentTypes restricts the request bodies a route accepts to the given media types
+// or ranges, such as "application/json" (which includes +json types) or "image/*".
+// Requests with a body in any other type, or without a Content-Type, get an
+// UnsupportedMediaTypeError (415). Requests without a body pass through.
+func AllowContentTypes(mediaTypes ...string) Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			if request.Body != "" {
+				if _, err := checkContentType(request, mediaTypes); err != nil {
+					return nil, err
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// checkContentType parses the request's Content-Type and checks it against allowed.
+// JSON bodies must also be UTF-8, the only encoding JSON allows between systems.
+func checkContentType(request *Request, allowed []string) (http.MediaType, error) {
+	contentType := request.Header("Content-Type")
+	if contentType == "" {
+		return http.MediaType{}, NewUnsupportedMediaTypeError("Content-Type is required for requests with a body", "", allowed)
+	}
+
+	mediaType, err := http.ParseMediaType(contentType)
+	if err != nil {
+		return http.MediaType{}, NewUnsupportedMediaTypeError("Content-Type is malformed", contentType, allowed)
+	}
+
+	for _, pattern := range allowed {
+		if !mediaType.Matches(pattern) {
+			continue
+		}
+		if charset := mediaType.Charset(); mediaType.IsJSON() && charset != "" && charset != "utf-8" && charset != "utf8" {
+			return mediaType, NewUnsupportedMediaTypeError("JSON request bodies must be UTF-8 encoded", contentType, allowed)
+		}
+		return mediaType, nil
+	}
+
+	return mediaType, NewUnsupportedMediaTypeError(
+		fmt.Sprintf("Content-Type %s is not supported; use %s", mediaType.Type, strings.Join(allowed, ", ")), contentType, allowed)
+}
+
+// requestMediaType returns the parsed Content-Type of the request. Requests without one
+// are treated as JSON, which is what clients of this API send by default.
+func requestMediaType(request *Request) (http.MediaType, error) {
+	contentType := request.Header("Content-Type")
+	if contentType == "" {
+		return http.MediaType{Type: http.ContentTypeJSON}, nil
+	}
+
+	mediaType, err := http.ParseMediaType(contentType)
+	if err != nil {
+		return http.MediaType{}, NewUnsupportedMediaTypeError("Content-Type is malformed", contentType, bindableContentTypes)
+	}
+	return mediaType, nil
+}
+
+// requestBody returns the raw request body, decoding base64-encoded bodies.
+func requestBody(request *Request) ([]byte, error) {
+	if !request.IsBase64Encoded {
+		return []byte(request.Body), nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(request.Body)
+	if err != nil {
+		return nil, NewValidationErrorWithCause("request body is not valid base64", "body", nil, err)
+	}
+	return decoded, nil
+}
+
+// Form is a decoded application/x-www-form-urlencoded or multipart/form-data body.
+type Form struct {
+	// Values holds the text fields by name.
+	Values url.Values
+	// Files holds the uploaded files of multipart bodies by field name.
+	Files map[string][]*FormFile
+}
+
+// FormFile is a file uploaded in a multipart/form-data body.
+type FormFile struct {
+	Field       string
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Value returns the first value of the named field, or an empty string.
+func (f *Form) Value(name string) string {
+	return f.Values.Get(name)
+}
+
+// File returns the first file uploaded in the named field, or nil.
+func (f *Form) File(name string) *FormFile {
+	if files := f.Files[name]; len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// ParseForm decodes a form body, including the base64-encoded bodies API Gateway sends
+// for binary media types. Bodies of other media types get an UnsupportedMediaTypeError
+// and malformed bodies a ValidationError.
+func ParseForm(request *Request) (*Form, error) {
+	mediaType, err := requestMediaType(request)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType.Type != http.ContentTypeFormURLEncoded && mediaType.Type != http.ContentTypeMultipartForm {
+		formTypes := []string{http.ContentTypeFormURLEncoded, http.ContentTypeMultipartForm}
+		return nil, NewUnsupportedMediaTypeError("Request body is not a form", mediaType.Type, formTypes)
+	}
+
+	body, err := requestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	return parseFormBody(mediaType, body)
+}
+
+// parseFormBody decodes a form body of the given media type.
+func parseFormBody(mediaType http.MediaType, body []byte) (*Form, error) {
+	form := &Form{Values: url.Values{}, Files: map[string][]*FormFile{}}
+
+	if mediaType.Type == http.ContentTypeFormURLEncoded {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, NewValidationErrorWithCause("Invalid form body", "body", nil, err)
+		}
+		form.Values = values
+		return form, nil
+	}
+
+	boundary := mediaType.Params["boundary"]
+	if boundary == "" {
+		return nil, NewValidationError("multipart Content-Type has no boundary", "content-type", mediaType.Type)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return form, nil
+		}
+		if err != nil {
+			return nil, NewValidationErrorWithCause("Invalid multipart body", "body", nil, err)
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, NewValidationErrorWithCause("Invalid multipart body", name, nil, err)
+		}
+
+		if filename := part.FileName(); filename != "" {
+			form.Files[name] = append(form.Files[name], &FormFile{
+				Field:       name,
+				Filename:    filename,
+				ContentType: part.Header.Get("Content-Type"),
+				Content:     content,
+			})
+			continue
+		}
+		form.Values.Add(name, string(content))
+	}
+}
@@ -0,0 +1,240 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"testing"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMediaType(t *testing.T) {
+	tests := []struct {
+		value   string
+		pattern string
+		json    bool
+		charset string
+		matches bool
+	}{
+		{value: "application/json", pattern: "application/json", json: true, matches: true},
+		{value: "Application/JSON; charset=UTF-8", pattern: "application/json", json: true, charset: "utf-8", matches: true},
+		{value: "application/problem+json", pattern: "application/json", json: true, matches: true},
+		{value: "application/problem+json", pattern: "application/problem+json", json: true, matches: true},
+		{value: "application/json", pattern: "application/problem+json", json: true, matches: false},
+		{value: "image/png", pattern: "image/*", matches: true},
+		{value: "image/png", pattern: "*/*", matches: true},
+		{value: "text/plain", pattern: "application/json", matches: false},
+		{value: "multipart/form-data; boundary=xyz", pattern: "multipart/form-data", matches: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.pattern, func(t *testing.T) {
+			mediaType, err := http.ParseMediaType(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.json, mediaType.IsJSON())
+			assert.Equal(t, tt.charset, mediaType.Charset())
+			assert.Equal(t, tt.matches, mediaType.Matches(tt.pattern))
+		})
+	}
+
+	_, err := http.ParseMediaType("application/json; charset")
+	assert.Error(t, err)
+}
+
+type signupForm struct {
+	Name      string      `json:"name"`
+	Age       int         `form:"age"`
+	Tags      []string    `form:"tag"`
+	Avatar    *FormFile   `form:"avatar"`
+	Documents []*FormFile `form:"document"`
+	Source    string      `json:"-" query:"source"`
+}
+
+// multipartBody encodes fields and files, keyed by field name and then filename, as a
+// multipart/form-data body and returns it with its Content-Type.
+func multipartBody(t *testing.T, fields map[string]string, files map[string]map[string]string) (string, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	for name, contents := range files {
+		for filename, content := range contents {
+			part, err := writer.CreateFormFile(name, filename)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, writer.Close())
+	return body.String(), writer.FormDataContentType()
+}
+
+func TestBind_Form(t *testing.T) {
+	t.Run("URL-encoded body", func(t *testing.T) {
+		request := &Request{
+			Method:          "POST",
+			Body:            "name=Ada+Lovelace&age=36&tag=math&tag=poetry",
+			Headers:         map[string]string{"content-type": "application/x-www-form-urlencoded; charset=utf-8"},
+			QueryParameters: map[string]string{"source": "web"},
+		}
+
+		var input signupForm
+		require.NoError(t, Bind(request, &input))
+
+		assert.Equal(t, "Ada Lovelace", input.Name)
+		assert.Equal(t, 36, input.Age)
+		assert.Equal(t, []string{"math", "poetry"}, input.Tags)
+		assert.Equal(t, "web", input.Source)
+		assert.Nil(t, input.Avatar)
+	})
+
+	t.Run("values containing commas are not split", func(t *testing.T) {
+		request := &Request{
+			Method:  "POST",
+			Body:    "tag=Smith%2C+John",
+			Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		}
+
+		var input signupForm
+		require.NoError(t, Bind(request, &input))
+
+		assert.Equal(t, []string{"Smith, John"}, input.Tags)
+	})
+
+	t.Run("base64-encoded multipart body", func(t *testing.T) {
+		body, contentType := multipartBody(t,
+			map[string]string{"name": "Ada", "age": "36"},
+			map[string]map[string]string{"avatar": {"ada.png": "\x89PNG"}, "document": {"notes.txt": "engine"}})
+
+		request := &Request{
+			Method:          "POST",
+			Body:            base64.StdEncoding.EncodeToString([]byte(body)),
+			IsBase64Encoded: true,
+			Headers:         map[string]string{"Content-Type": contentType},
+		}
+
+		var input signupForm
+		require.NoError(t, Bind(request, &input))
+
+		assert.Equal(t, "Ada", input.Name)
+		assert.Equal(t, 36, input.Age)
+		require.NotNil(t, input.Avatar)
+		assert.Equal(t, "ada.png", input.Avatar.Filename)
+		assert.Equal(t, "avatar", input.Avatar.Field)
+		assert.Equal(t, []byte("\x89PNG"), input.Avatar.Content)
+		require.Len(t, input.Documents, 1)
+		assert.Equal(t, "notes.txt", input.Documents[0].Filename)
+	})
+
+	t.Run("invalid field value", func(t *testing.T) {
+		request := &Request{Body: "age=old", Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}}
+
+		var input signupForm
+		err := Bind(request, &input)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "age", validationErr.Field)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		request := &Request{Body: "name=Ada&admin=true", Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}}
+
+		var input signupForm
+		err := BindWithOptions(request, &input, BindOptions{DisallowUnknownFields: true})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "admin", validationErr.Field)
+	})
+
+	t.Run("multipart body without boundary", func(t *testing.T) {
+		request := &Request{Body: "--x--", Headers: map[string]string{"Content-Type": "multipart/form-data"}}
+
+		var input signupForm
+		assert.True(t, IsValidationError(Bind(request, &input)))
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		request := &Request{Body: "name: Ada", Headers: map[string]string{"Content-Type": "application/yaml"}}
+
+		var input signupForm
+		assert.True(t, IsUnsupportedMediaTypeError(Bind(request, &input)))
+	})
+}
+
+func TestParseForm(t *testing.T) {
+	body, contentType := multipartBody(t,
+		map[string]string{"title": "Report"},
+		map[string]map[string]string{"file": {"report.csv": "a,b\n1,2\n"}})
+
+	form, err := ParseForm(&Request{Body: body, Headers: map[string]string{"Content-Type": contentType}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Report", form.Value("title"))
+	require.NotNil(t, form.File("file"))
+	assert.Equal(t, "report.csv", form.File("file").Filename)
+	assert.Equal(t, "a,b\n1,2\n", string(form.File("file").Content))
+	assert.Nil(t, form.File("missing"))
+
+	_, err = ParseForm(&Request{Body: "{}", Headers: map[string]string{"Content-Type": "application/json"}})
+	assert.True(t, IsUnsupportedMediaTypeError(err))
+}
+
+func TestAllowContentTypes(t *testing.T) {
+	h := NewHandler(testutil.TestConfig(), testutil.TestLogger(t), testutil.TestTracer())
+	upload := func(ctx context.Context, request *Request) (interface{}, error) {
+		return map[string]string{"status": "stored"}, nil
+	}
+	wrapped := h.WrapHTTPAPI(upload, AllowContentTypes("image/*", "application/pdf"))
+
+	t.Run("accepts matching types", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("PUT", "/avatar", map[string]string{"content-type": "image/png"})
+		request.Body = base64.StdEncoding.EncodeToString([]byte("\x89PNG"))
+		request.IsBase64Encoded = true
+
+		response, err := wrapped(testutil.CreateTestContext("allowed"), request)
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+	})
+
+	t.Run("rejects other types with 415", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithBody("PUT", "/avatar", map[string]string{"name": "Ada"})
+
+		response, err := wrapped(testutil.CreateTestContext("rejected"), request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 415, response.StatusCode)
+		testutil.AssertErrorResponse(t, response.Body, "Content-Type application/json is not supported; use image/*, application/pdf")
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, ErrorCodeUnsupportedMedia, body["code"])
+		assert.Equal(t, map[string]interface{}{"supported": []interface{}{"image/*", "application/pdf"}}, body["details"])
+	})
+
+	t.Run("rejects bodies without Content-Type", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2Request("PUT", "/avatar")
+		request.Body = "raw"
+
+		response, err := wrapped(testutil.CreateTestContext("missing"), request)
+		require.NoError(t, err)
+		assert.Equal(t, 415, response.StatusCode)
+	})
+
+	t.Run("passes requests without a body", func(t *testing.T) {
+		response, err := wrapped(testutil.CreateTestContext("empty"), testutil.CreateTestAPIGatewayV2Request("GET", "/avatar"))
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+	})
+}
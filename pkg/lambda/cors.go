@@ -0,0 +1,87 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"time"
+
+	"lambda-go-template/pkg/config"
+	"lambda-go-template/pkg/http"
+)
+
+// corsPolicyFromConfig builds the CORS policy configured through the CORS_* variables.
+// Unset lists fall back to DefaultCORSPolicy.
+func corsPolicyFromConfig(cfg *config.Config) http.CORSPolicy {
+	policy := http.DefaultCORSPolicy()
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		policy.AllowedOrigins = cfg.CORSAllowedOrigins
+	}
+	if len(cfg.CORSAllowedMethods) > 0 {
+		policy.AllowedMethods = cfg.CORSAllowedMethods
+	}
+	if len(cfg.CORSAllowedHeaders) > 0 {
+		policy.AllowedHeaders = cfg.CORSAllowedHeaders
+	}
+	policy.ExposedHeaders = cfg.CORSExposedHeaders
+	policy.AllowCredentials = cfg.CORSAllowCredentials
+	policy.MaxAge = cfg.CORSMaxAge
+	return policy
+}
+
+// WithCORSPolicy sets the CORS policy applied to every response and preflight request.
+// Handlers use the policy from the configuration unless one is set.
+func (h *Handler) WithCORSPolicy(policy http.CORSPolicy) *Handler {
+	h.cors = policy
+	return h
+}
+
+// isPreflight reports whether request is a CORS preflight request.
+func isPreflight(request *Request) bool {
+	return http.IsPreflight(request.Method, request.Header("Origin"), request.Header("Access-Control-Request-Method"))
+}
+
+// preflight answers a CORS preflight request with 204 No Content. The handler does not run;
+// requests the policy rejects get no CORS headers, so the browser blocks the actual request.
+func (h *Handler) preflight(ctx context.Context, request *Request, start time.Time) http.Response {
+	origin := request.Header("Origin")
+	requestMethod := request.Header("Access-Control-Request-Method")
+
+	headers, allowed := h.cors.PreflightHeaders(origin, requestMethod, request.Header("Access-Control-Request-Headers"))
+
+	h.tracer.AddAnnotation(ctx, "cors_preflight", true)
+	h.tracer.AddAnnotation(ctx, "cors_allowed", allowed)
+	if !allowed {
+		h.logger.WithFields(map[string]interface{}{
+			"origin":         origin,
+			"request_method": requestMethod,
+			"path":           request.Path,
+		}).WithContext(ctx).Warn("CORS preflight rejected")
+	}
+
+	duration := time.Since(start).Milliseconds()
+	h.tracer.AddAnnotation(ctx, "http_status", 204)
+	h.logger.LogHTTPRequest(ctx, request.Method, request.clientPath(), 204, duration)
+
+	return http.Response{StatusCode: 204, Headers: headers}
+}
+
+// applyCORS adds the CORS headers for the request's origin to response. It runs on every
+// response, including ones returned as is, so replayed responses match the current origin.
+func (h *Handler) applyCORS(request *Request, response *http.Response) {
+	headers := h.cors.ResponseHeaders(request.Header("Origin"))
+	if len(headers) == 0 {
+		return
+	}
+
+	merged := make(map[string]string, len(response.Headers)+len(headers))
+	for key, value := range response.Headers {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		if key == "Vary" {
+			value = http.AppendVary(merged["Vary"], value)
+		}
+		merged[key] = value
+	}
+	response.Headers = merged
+}
@@ -0,0 +1,218 @@
+package lambda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func restrictedCORSPolicy() http.CORSPolicy {
+	return http.CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.preview.example.com"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID", "RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+}
+
+func corsRequest(method, origin string, headers map[string]string) events.APIGatewayV2HTTPRequest {
+	all := map[string]string{"origin": origin}
+	for key, value := range headers {
+		all[key] = value
+	}
+	return testutil.CreateTestAPIGatewayV2RequestWithHeaders(method, "/users", all)
+}
+
+func TestHandler_CORSPreflight(t *testing.T) {
+	tests := []struct {
+		name            string
+		origin          string
+		requestMethod   string
+		requestHeaders  string
+		expectAllowed   bool
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "allowed origin",
+			origin:         "https://app.example.com",
+			requestMethod:  "DELETE",
+			requestHeaders: "authorization, content-type",
+			expectAllowed:  true,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, POST, DELETE",
+				"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		{
+			name:          "origin matching pattern",
+			origin:        "https://pr-42.preview.example.com",
+			requestMethod: "GET",
+			expectAllowed: true,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://pr-42.preview.example.com",
+			},
+		},
+		{
+			name:          "pattern does not match bare domain",
+			origin:        "https://.preview.example.com",
+			requestMethod: "GET",
+		},
+		{
+			name:          "unknown origin",
+			origin:        "https://evil.example.net",
+			requestMethod: "GET",
+		},
+		{
+			name:          "method not allowed",
+			origin:        "https://app.example.com",
+			requestMethod: "PUT",
+		},
+		{
+			name:           "header not allowed",
+			origin:         "https://app.example.com",
+			requestMethod:  "POST",
+			requestHeaders: "x-debug",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t).WithCORSPolicy(restrictedCORSPolicy())
+			called := false
+			wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+
+			headers := map[string]string{"access-control-request-method": tt.requestMethod}
+			if tt.requestHeaders != "" {
+				headers["access-control-request-headers"] = tt.requestHeaders
+			}
+			response, err := wrapped(testutil.CreateTestContext("preflight"), corsRequest("OPTIONS", tt.origin, headers))
+			require.NoError(t, err)
+
+			assert.False(t, called, "preflight requests must not reach the handler")
+			assert.Equal(t, 204, response.StatusCode)
+			assert.Empty(t, response.Body)
+			assert.Contains(t, response.Headers["Vary"], "Origin")
+
+			if !tt.expectAllowed {
+				assert.NotContains(t, response.Headers, "Access-Control-Allow-Origin")
+				return
+			}
+			for key, value := range tt.expectedHeaders {
+				assert.Equal(t, value, response.Headers[key], key)
+			}
+		})
+	}
+}
+
+func TestHandler_CORSResponses(t *testing.T) {
+	ok := func(ctx context.Context, request *Request) (interface{}, error) {
+		return map[string]string{"status": "ok"}, nil
+	}
+
+	t.Run("echoes allowed origin with credentials", func(t *testing.T) {
+		h := newTestHandler(t).WithCORSPolicy(restrictedCORSPolicy())
+		response, err := h.WrapHTTPAPI(ok)(testutil.CreateTestContext("cors"), corsRequest("GET", "https://app.example.com", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, "https://app.example.com", response.Headers["Access-Control-Allow-Origin"])
+		assert.Equal(t, "true", response.Headers["Access-Control-Allow-Credentials"])
+		assert.Equal(t, "X-Request-ID, RateLimit-Remaining", response.Headers["Access-Control-Expose-Headers"])
+		assert.Equal(t, "Origin", response.Headers["Vary"])
+		assert.NotContains(t, response.Headers, "Access-Control-Allow-Methods")
+	})
+
+	t.Run("omits headers for disallowed origin", func(t *testing.T) {
+		h := newTestHandler(t).WithCORSPolicy(restrictedCORSPolicy())
+		response, err := h.WrapHTTPAPI(ok)(testutil.CreateTestContext("cors"), corsRequest("GET", "https://evil.example.net", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.NotContains(t, response.Headers, "Access-Control-Allow-Origin")
+		assert.NotContains(t, response.Headers, "Access-Control-Allow-Credentials")
+		assert.Equal(t, "Origin", response.Headers["Vary"])
+	})
+
+	t.Run("applies to error responses", func(t *testing.T) {
+		h := newTestHandler(t).WithCORSPolicy(restrictedCORSPolicy())
+		response, err := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			return nil, NewNotFoundError("user")
+		})(testutil.CreateTestContext("cors"), corsRequest("GET", "https://app.example.com", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, 404, response.StatusCode)
+		assert.Equal(t, "https://app.example.com", response.Headers["Access-Control-Allow-Origin"])
+	})
+
+	t.Run("merges Vary set by the handler", func(t *testing.T) {
+		h := newTestHandler(t).WithCORSPolicy(restrictedCORSPolicy())
+		response, err := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			SetResponseHeader(ctx, "Vary", "Accept")
+			return "ok", nil
+		})(testutil.CreateTestContext("cors"), corsRequest("GET", "https://app.example.com", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Accept, Origin", response.Headers["Vary"])
+	})
+
+	t.Run("replayed responses match the current origin", func(t *testing.T) {
+		policy := restrictedCORSPolicy()
+		policy.AllowedOrigins = append(policy.AllowedOrigins, "https://admin.example.com")
+		h := newTestHandler(t).WithCORSPolicy(policy)
+		wrapped := h.WrapHTTPAPI(ok, h.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+		first := corsRequest("POST", "https://app.example.com", map[string]string{"idempotency-key": "key-1"})
+		_, err := wrapped(testutil.CreateTestContext("request-1"), first)
+		require.NoError(t, err)
+
+		retry := corsRequest("POST", "https://admin.example.com", map[string]string{"idempotency-key": "key-1"})
+		response, err := wrapped(testutil.CreateTestContext("request-2"), retry)
+		require.NoError(t, err)
+
+		assert.Equal(t, "true", response.Headers["Idempotent-Replayed"])
+		assert.Equal(t, "https://admin.example.com", response.Headers["Access-Control-Allow-Origin"])
+	})
+
+	t.Run("default policy allows any origin", func(t *testing.T) {
+		h := newTestHandler(t)
+		response, err := h.WrapHTTPAPI(ok)(testutil.CreateTestContext("cors"), corsRequest("GET", "https://anywhere.example.org", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, "*", response.Headers["Access-Control-Allow-Origin"])
+		assert.NotContains(t, response.Headers, "Vary")
+	})
+
+	t.Run("omits headers for requests without an origin", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2Request("GET", "/users")
+		for _, h := range []*Handler{newTestHandler(t), newTestHandler(t).WithCORSPolicy(restrictedCORSPolicy())} {
+			response, err := h.WrapHTTPAPI(ok)(testutil.CreateTestContext("cors"), request)
+			require.NoError(t, err)
+
+			assert.Equal(t, 200, response.StatusCode)
+			assert.NotContains(t, response.Headers, "Access-Control-Allow-Origin")
+			assert.NotContains(t, response.Headers, "Access-Control-Allow-Credentials")
+			assert.NotContains(t, response.Headers, "Access-Control-Expose-Headers")
+		}
+	})
+}
+
+func TestAppendVary(t *testing.T) {
+	assert.Equal(t, "Origin", http.AppendVary("", "Origin"))
+	assert.Equal(t, "Accept-Encoding, Origin", http.AppendVary("Accept-Encoding", "Origin"))
+	assert.Equal(t, "Origin, Accept", http.AppendVary("Origin", "origin, Accept"))
+}
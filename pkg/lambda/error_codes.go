@@ -0,0 +1,206 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+// Stable, machine-readable error codes returned in the code field of error responses.
+// Clients should match on these rather than on messages; existing codes never change.
+const (
+	ErrorCodeValidationFailed = "VALIDATION_FAILED"
+	ErrorCodeUnauthorized     = "UNAUTHORIZED"
+	ErrorCodeForbidden        = "FORBIDDEN"
+	ErrorCodeNotFound         = "NOT_FOUND"
+	ErrorCodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	ErrorCodeConflict         = "CONFLICT"
+	ErrorCodePrecondition     = "PRECONDITION_FAILED"
+	ErrorCodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+	ErrorCodeUnsupportedMedia = "UNSUPPORTED_MEDIA_TYPE"
+	ErrorCodeBusinessRule     = "BUSINESS_RULE_VIOLATION"
+	ErrorCodeRateLimited      = "RATE_LIMITED"
+	ErrorCodeInternal         = "INTERNAL_ERROR"
+	ErrorCodeExternalService  = "EXTERNAL_SERVICE_ERROR"
+	ErrorCodeUnavailable      = "SERVICE_UNAVAILABLE"
+	ErrorCodeTimeout          = "TIMEOUT"
+)
+
+// ErrorCodeInfo documents an error code for publication in API documentation.
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	ErrorType   string `json:"errorType"`
+	Description string `json:"description"`
+}
+
+// ErrorCodes returns the documented error codes in the order of their HTTP status.
+func ErrorCodes() []ErrorCodeInfo {
+	return []ErrorCodeInfo{
+		{
+			Code:        ErrorCodeValidationFailed,
+			Status:      400,
+			ErrorType:   "ValidationError",
+			Description: "The request is malformed or failed validation; errors lists each invalid field.",
+		},
+		{
+			Code:        ErrorCodeUnauthorized,
+			Status:      401,
+			ErrorType:   "UnauthorizedError",
+			Description: "The request lacks valid authentication credentials.",
+		},
+		{
+			Code:        ErrorCodeForbidden,
+			Status:      403,
+			ErrorType:   "ForbiddenError",
+			Description: "The caller is authenticated but not allowed to perform the operation.",
+		},
+		{
+			Code:        ErrorCodeNotFound,
+			Status:      404,
+			ErrorType:   "NotFoundError",
+			Description: "The requested resource or route does not exist.",
+		},
+		{
+			Code:        ErrorCodeMethodNotAllowed,
+			Status:      405,
+			ErrorType:   "MethodNotAllowedError",
+			Description: "The resource does not support the request method; the Allow header lists supported methods.",
+		},
+		{
+			Code:        ErrorCodeConflict,
+			Status:      409,
+			ErrorType:   "ConflictError",
+			Description: "The request conflicts with the current state of the resource.",
+		},
+		{
+			Code:        ErrorCodePrecondition,
+			Status:      412,
+			ErrorType:   "PreconditionFailedError",
+			Description: "The If-Match, If-None-Match or If-Unmodified-Since precondition does not hold; fetch the resource again before retrying.",
+		},
+		{
+			Code:        ErrorCodePayloadTooLarge,
+			Status:      413,
+			ErrorType:   "PayloadTooLargeError",
+			Description: "The request body exceeds the size limit; details give the limit in bytes.",
+		},
+		{
+			Code:        ErrorCodeUnsupportedMedia,
+			Status:      415,
+			ErrorType:   "UnsupportedMediaTypeError",
+			Description: "The request body's Content-Type is missing or not accepted; details list the supported media types.",
+		},
+		{
+			Code:        ErrorCodeBusinessRule,
+			Status:      422,
+			ErrorType:   "BusinessLogicError",
+			Description: "The request violates a business rule. Errors declaring their own code return that code instead.",
+		},
+		{
+			Code:        ErrorCodeRateLimited,
+			Status:      429,
+			ErrorType:   "RateLimitError",
+			Description: "The caller exceeded its rate limit; retry after Retry-After. RateLimit-* headers describe the limit.",
+		},
+		{
+			Code:        ErrorCodeInternal,
+			Status:      500,
+			ErrorType:   "InternalError",
+			Description: "An unexpected error occurred while processing the request.",
+		},
+		{
+			Code:        ErrorCodeExternalService,
+			Status:      503,
+			ErrorType:   "ExternalServiceError",
+			Description: "A downstream service failed. Retryable failures return 503 with Retry-After, others 502.",
+		},
+		{
+			Code:        ErrorCodeUnavailable,
+			Status:      503,
+			ErrorType:   "ServiceUnavailableError",
+			Description: "The request was shed before processing, for example when too little invocation time remained; retry after Retry-After.",
+		},
+		{
+			Code:        ErrorCodeTimeout,
+			Status:      504,
+			ErrorType:   "TimeoutError",
+			Description: "The request did not complete within the configured timeout.",
+		},
+	}
+}
+
+// ErrorCode returns ErrorCodeValidationFailed.
+func (e *ValidationError) ErrorCode() string {
+	return ErrorCodeValidationFailed
+}
+
+// ErrorCode returns ErrorCodeUnauthorized.
+func (e *UnauthorizedError) ErrorCode() string {
+	return ErrorCodeUnauthorized
+}
+
+// ErrorCode returns ErrorCodeForbidden.
+func (e *ForbiddenError) ErrorCode() string {
+	return ErrorCodeForbidden
+}
+
+// ErrorCode returns ErrorCodeNotFound.
+func (e *NotFoundError) ErrorCode() string {
+	return ErrorCodeNotFound
+}
+
+// ErrorCode returns ErrorCodeMethodNotAllowed.
+func (e *MethodNotAllowedError) ErrorCode() string {
+	return ErrorCodeMethodNotAllowed
+}
+
+// ErrorCode returns ErrorCodeConflict.
+func (e *ConflictError) ErrorCode() string {
+	return ErrorCodeConflict
+}
+
+// ErrorCode returns ErrorCodePrecondition.
+func (e *PreconditionFailedError) ErrorCode() string {
+	return ErrorCodePrecondition
+}
+
+// ErrorCode returns ErrorCodePayloadTooLarge.
+func (e *PayloadTooLargeError) ErrorCode() string {
+	return ErrorCodePayloadTooLarge
+}
+
+// ErrorDetails returns the size limit, so clients know how much to send.
+func (e *PayloadTooLargeError) ErrorDetails() map[string]interface{} {
+	return map[string]interface{}{"limitBytes": e.Limit}
+}
+
+// ErrorCode returns ErrorCodeUnsupportedMedia.
+func (e *UnsupportedMediaTypeError) ErrorCode() string {
+	return ErrorCodeUnsupportedMedia
+}
+
+// ErrorDetails returns the supported media types, so clients know what to send.
+func (e *UnsupportedMediaTypeError) ErrorDetails() map[string]interface{} {
+	return map[string]interface{}{"supported": e.Supported}
+}
+
+// ErrorCode returns ErrorCodeRateLimited.
+func (e *RateLimitError) ErrorCode() string {
+	return ErrorCodeRateLimited
+}
+
+// ErrorCode returns ErrorCodeInternal.
+func (e *InternalError) ErrorCode() string {
+	return ErrorCodeInternal
+}
+
+// ErrorCode returns ErrorCodeExternalService.
+func (e *ExternalServiceError) ErrorCode() string {
+	return ErrorCodeExternalService
+}
+
+// ErrorCode returns ErrorCodeUnavailable.
+func (e *ServiceUnavailableError) ErrorCode() string {
+	return ErrorCodeUnavailable
+}
+
+// ErrorCode returns ErrorCodeTimeout.
+func (e *TimeoutError) ErrorCode() string {
+	return ErrorCodeTimeout
+}
@@ -0,0 +1,48 @@
+package lambda
+
+import (
+	"testing"
+	"time"
+
+	"lambda-go-template/pkg/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCodes_CoverErrorTypes(t *testing.T) {
+	errs := []http.ErrorCoder{
+		NewValidationError("invalid", "field", nil),
+		NewUnauthorizedError("unauthorized"),
+		NewForbiddenError("forbidden"),
+		NewNotFoundError("not found"),
+		NewMethodNotAllowedError("PUT", []string{"GET"}),
+		NewConflictError("conflict"),
+		NewPreconditionFailedError("stale", "If-Match"),
+		NewPayloadTooLargeError("too large", 2048, 1024),
+		NewUnsupportedMediaTypeError("not accepted", "text/plain", []string{"application/json"}),
+		&BusinessLogicError{Message: "rule violated"},
+		NewRateLimitError("slow down", 10, time.Second),
+		NewInternalError("internal", nil),
+		NewExternalServiceError("payments", "down", 500, true, nil),
+		NewServiceUnavailableError("shedding load", time.Second),
+		NewTimeoutError("timeout", time.Second),
+	}
+
+	documented := make(map[string]bool)
+	for _, info := range ErrorCodes() {
+		assert.False(t, documented[info.Code], "duplicate code %s", info.Code)
+		documented[info.Code] = true
+		assert.NotEmpty(t, info.Description)
+		assert.True(t, http.ValidateStatusCode(info.Status))
+	}
+
+	for _, err := range errs {
+		assert.True(t, documented[err.ErrorCode()], "%T returns undocumented code %s", err, err.ErrorCode())
+	}
+	assert.Len(t, documented, len(errs))
+}
+
+func TestBusinessLogicError_ErrorCode(t *testing.T) {
+	assert.Equal(t, "INSUFFICIENT_FUNDS", NewBusinessLogicError("insufficient balance", "INSUFFICIENT_FUNDS").ErrorCode())
+	assert.Equal(t, ErrorCodeBusinessRule, NewBusinessLogicError("rule violated", "").ErrorCode())
+}
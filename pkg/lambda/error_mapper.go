@@ -0,0 +1,202 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lambda-go-template/pkg/http"
+)
+
+// defaultRetryAfter is advertised to clients when a retryable error does not specify its own delay.
+const defaultRetryAfter = time.Second
+
+// ErrorMapping converts an error into an HTTP response. It reports false when it does not
+// handle the error so the next mapping can be tried.
+type ErrorMapping func(responseBuilder *http.ResponseBuilder, err error) (http.Response, bool)
+
+// MapError creates an ErrorMapping for errors of type E, which is usually a pointer to an
+// error struct. For example:
+//
+//	lambda.RegisterErrorMapping(lambda.MapError(func(rb *http.ResponseBuilder, err *QuotaError) http.Response {
+//		return rb.TooManyRequests(err.Message)
+//	}))
+func MapError[E error](mapping func(responseBuilder *http.ResponseBuilder, err E) http.Response) ErrorMapping {
+	return func(responseBuilder *http.ResponseBuilder, err error) (http.Response, bool) {
+		target, ok := err.(E)
+		if !ok {
+			return http.Response{}, false
+		}
+		return mapping(responseBuilder, target), true
+	}
+}
+
+// ErrorMapper converts handler errors into HTTP responses using registered mappings.
+//
+// The error chain is walked from the outermost error inwards, following both Unwrap() error
+// and Unwrap() []error, and the first error with a mapping decides the response. At each
+// step mappings are tried newest first, so services can override the defaults. Errors with
+// no mapping become a 500 Internal Server Error. An InternalError is mapped to a 500 itself,
+// so wrapping a client error in one hides its status and message.
+type ErrorMapper struct {
+	mu       sync.RWMutex
+	mappings []ErrorMapping
+}
+
+// NewErrorMapper creates an error mapper with mappings for the errors defined in this package.
+func NewErrorMapper() *ErrorMapper {
+	return &ErrorMapper{
+		mappings: []ErrorMapping{
+			MapError(func(rb *http.ResponseBuilder, err *ValidationError) http.Response {
+				return rb.BadRequest(err.Message, err.Err)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *NotFoundError) http.Response {
+				return rb.NotFound(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *ConflictError) http.Response {
+				return rb.Conflict(err.Message, err.Err)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *PreconditionFailedError) http.Response {
+				return rb.PreconditionFailed(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *PayloadTooLargeError) http.Response {
+				return rb.PayloadTooLarge(err.Message, err)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *UnsupportedMediaTypeError) http.Response {
+				return rb.UnsupportedMediaType(err.Message, err)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *UnauthorizedError) http.Response {
+				return rb.Unauthorized(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *ForbiddenError) http.Response {
+				return rb.Forbidden(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *MethodNotAllowedError) http.Response {
+				return rb.WithHeader("Allow", strings.Join(err.Allowed, ", ")).MethodNotAllowed(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *RateLimitError) http.Response {
+				retryAfter := err.RetryAfter
+				if retryAfter <= 0 {
+					retryAfter = defaultRetryAfter
+				}
+				return rb.WithHeader("Retry-After", retryAfterSeconds(retryAfter)).TooManyRequests(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *TimeoutError) http.Response {
+				return rb.GatewayTimeout(err.Message)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *InternalError) http.Response {
+				return rb.InternalServerError("Internal server error", err)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *BusinessLogicError) http.Response {
+				return rb.UnprocessableEntity(err.Message, err)
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *ExternalServiceError) http.Response {
+				if !err.IsRetryable() {
+					return rb.BadGateway("Upstream service error")
+				}
+
+				retryAfter := err.RetryAfter
+				if retryAfter <= 0 {
+					retryAfter = defaultRetryAfter
+				}
+				return rb.WithHeader("Retry-After", retryAfterSeconds(retryAfter)).
+					ServiceUnavailable("Service temporarily unavailable")
+			}),
+			MapError(func(rb *http.ResponseBuilder, err *ServiceUnavailableError) http.Response {
+				retryAfter := err.RetryAfter
+				if retryAfter <= 0 {
+					retryAfter = defaultRetryAfter
+				}
+				return rb.WithHeader("Retry-After", retryAfterSeconds(retryAfter)).ServiceUnavailable(err.Message)
+			}),
+		},
+	}
+}
+
+// defaultErrorMapper is used by handlers unless another mapper is configured.
+var defaultErrorMapper = NewErrorMapper()
+
+// RegisterErrorMapping registers a mapping on the default error mapper.
+func RegisterErrorMapping(mapping ErrorMapping) {
+	defaultErrorMapper.Register(mapping)
+}
+
+// Register adds a mapping that takes precedence over previously registered mappings.
+func (m *ErrorMapper) Register(mapping ErrorMapping) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mappings = append(m.mappings, mapping)
+}
+
+// Map converts err into an HTTP response. The response carries the code of the mapped error
+// when it implements http.ErrorCoder. Unmapped errors carry the code of the outermost error
+// implementing it, or ErrorCodeInternal.
+func (m *ErrorMapper) Map(responseBuilder *http.ResponseBuilder, err error) http.Response {
+	m.mu.RLock()
+	mappings := make([]ErrorMapping, len(m.mappings))
+	copy(mappings, m.mappings)
+	m.mu.RUnlock()
+
+	var response http.Response
+	matched := walkErrorChain(err, func(current error) bool {
+		code := ""
+		if coder, ok := current.(http.ErrorCoder); ok {
+			code = coder.ErrorCode()
+		}
+		responseBuilder.WithErrorCode(code)
+
+		for i := len(mappings) - 1; i >= 0; i-- {
+			if mapped, ok := mappings[i](responseBuilder, current); ok {
+				response = mapped
+				return true
+			}
+		}
+		return false
+	})
+
+	if !matched {
+		code := ErrorCodeInternal
+		var coder http.ErrorCoder
+		if errors.As(err, &coder) {
+			code = coder.ErrorCode()
+		}
+		responseBuilder.WithErrorCode(code)
+		return responseBuilder.InternalServerError("Internal server error", err)
+	}
+
+	return response
+}
+
+// walkErrorChain visits err and the errors it wraps depth first until visit returns true.
+func walkErrorChain(err error, visit func(error) bool) bool {
+	for err != nil {
+		if visit(err) {
+			return true
+		}
+
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range wrapped.Unwrap() {
+				if walkErrorChain(inner, visit) {
+					return true
+				}
+			}
+			return false
+		case interface{ Unwrap() error }:
+			err = wrapped.Unwrap()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// retryAfterSeconds formats a duration as a Retry-After header value in whole seconds.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
@@ -0,0 +1,189 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type quotaError struct {
+	Limit int
+}
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("quota of %d exceeded", e.Limit)
+}
+
+func TestErrorMapper_DefaultMappings(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedMessage string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:            "validation",
+			err:             NewValidationError("name is required", "name", nil),
+			expectedStatus:  400,
+			expectedMessage: "name is required",
+		},
+		{
+			name:            "wrapped not found",
+			err:             fmt.Errorf("loading user: %w", NewNotFoundError("user not found")),
+			expectedStatus:  404,
+			expectedMessage: "user not found",
+		},
+		{
+			name:            "joined conflict",
+			err:             errors.Join(errors.New("audit failed"), NewConflictError("email taken")),
+			expectedStatus:  409,
+			expectedMessage: "email taken",
+		},
+		{
+			name:            "unauthorized",
+			err:             NewUnauthorizedError("missing token"),
+			expectedStatus:  401,
+			expectedMessage: "missing token",
+		},
+		{
+			name:            "forbidden",
+			err:             NewForbiddenError("admins only"),
+			expectedStatus:  403,
+			expectedMessage: "admins only",
+		},
+		{
+			name:            "method not allowed",
+			err:             NewMethodNotAllowedError("PUT", []string{"GET", "POST"}),
+			expectedStatus:  405,
+			expectedMessage: "HTTP method PUT is not allowed for this resource",
+			expectedHeaders: map[string]string{"Allow": "GET, POST"},
+		},
+		{
+			name:            "rate limited",
+			err:             NewRateLimitError("Too many requests", 10, 2500*time.Millisecond),
+			expectedStatus:  429,
+			expectedMessage: "Too many requests",
+			expectedHeaders: map[string]string{"Retry-After": "3"},
+		},
+		{
+			name:            "timeout",
+			err:             NewTimeoutError("Request timeout after 5s", 5*time.Second),
+			expectedStatus:  504,
+			expectedMessage: "Request timeout after 5s",
+		},
+		{
+			name:            "retryable external service",
+			err:             NewExternalServiceError("payments", "throttled", 429, true, nil).WithRetryAfter(1500 * time.Millisecond),
+			expectedStatus:  503,
+			expectedMessage: "Service temporarily unavailable",
+			expectedHeaders: map[string]string{"Retry-After": "2"},
+		},
+		{
+			name:            "retryable external service without delay",
+			err:             NewExternalServiceError("payments", "throttled", 429, true, nil),
+			expectedStatus:  503,
+			expectedMessage: "Service temporarily unavailable",
+			expectedHeaders: map[string]string{"Retry-After": "1"},
+		},
+		{
+			name:            "non-retryable external service",
+			err:             NewExternalServiceError("payments", "rejected", 400, false, nil),
+			expectedStatus:  502,
+			expectedMessage: "Upstream service error",
+		},
+		{
+			name:            "outermost mapped error wins",
+			err:             NewValidationErrorWithCause("invalid reference", "ownerId", "7", NewNotFoundError("owner not found")),
+			expectedStatus:  400,
+			expectedMessage: "invalid reference",
+		},
+		{
+			name:            "internal error hides wrapped client error",
+			err:             NewInternalErrorWithOperation("user retrieval", "failed to get user", NewNotFoundError("user not found")),
+			expectedStatus:  500,
+			expectedMessage: "Internal server error",
+		},
+		{
+			name:            "unmapped",
+			err:             errors.New("boom"),
+			expectedStatus:  500,
+			expectedMessage: "Internal server error",
+		},
+	}
+
+	mapper := NewErrorMapper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := mapper.Map(http.NewResponseBuilder(), tt.err)
+
+			assert.Equal(t, tt.expectedStatus, response.StatusCode)
+			var body http.ErrorResponse
+			require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+			assert.Equal(t, tt.expectedMessage, body.Message)
+			for key, value := range tt.expectedHeaders {
+				assert.Equal(t, value, response.Headers[key])
+			}
+		})
+	}
+}
+
+func TestErrorMapper_BusinessLogicError(t *testing.T) {
+	err := NewBusinessLogicError("insufficient balance", "INSUFFICIENT_FUNDS").WithDetail("balance", 10)
+
+	response := NewErrorMapper().Map(http.NewResponseBuilder(), fmt.Errorf("transfer: %w", err))
+
+	assert.Equal(t, 422, response.StatusCode)
+	var body http.ErrorResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "insufficient balance", body.Message)
+	assert.Equal(t, "INSUFFICIENT_FUNDS", body.Code)
+	assert.Equal(t, map[string]interface{}{"balance": float64(10)}, body.Details)
+}
+
+func TestErrorMapper_Register(t *testing.T) {
+	mapper := NewErrorMapper()
+	mapper.Register(MapError(func(rb *http.ResponseBuilder, err *quotaError) http.Response {
+		return rb.TooManyRequests(err.Error())
+	}))
+
+	response := mapper.Map(http.NewResponseBuilder(), fmt.Errorf("creating order: %w", &quotaError{Limit: 5}))
+	assert.Equal(t, 429, response.StatusCode)
+
+	// Later registrations override the defaults
+	mapper.Register(MapError(func(rb *http.ResponseBuilder, err *NotFoundError) http.Response {
+		return rb.CustomError(410, err.Message, nil)
+	}))
+	response = mapper.Map(http.NewResponseBuilder(), NewNotFoundError("user deleted"))
+	assert.Equal(t, 410, response.StatusCode)
+
+	// Registrations do not leak into other mappers
+	response = NewErrorMapper().Map(http.NewResponseBuilder(), &quotaError{Limit: 5})
+	assert.Equal(t, 500, response.StatusCode)
+}
+
+func TestHandler_WithErrorMapper(t *testing.T) {
+	mapper := NewErrorMapper()
+	mapper.Register(MapError(func(rb *http.ResponseBuilder, err *quotaError) http.Response {
+		return rb.TooManyRequests(err.Error())
+	}))
+
+	h := newTestHandler(t).WithErrorMapper(mapper)
+	wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+		return nil, &quotaError{Limit: 5}
+	})
+
+	response, err := wrapped(testutil.CreateTestContext("test-request"), testutil.CreateTestAPIGatewayV2Request("POST", "/orders"))
+	require.NoError(t, err)
+	assert.Equal(t, 429, response.StatusCode)
+	testutil.AssertErrorResponse(t, response.Body, "quota of 5 exceeded")
+}
@@ -0,0 +1,63 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"errors"
+	"sync"
+)
+
+// RedactionRule decides whether an error's internal details are redacted from response
+// bodies. It reports false as its second result when it does not apply to the error.
+type RedactionRule func(err error) (redact bool, ok bool)
+
+// RedactErrorType creates a RedactionRule for errors of type E anywhere in the error chain.
+// Use redact=false to expose a safe error type in production, or redact=true to hide the
+// details of a client error type that may leak internals.
+func RedactErrorType[E error](redact bool) RedactionRule {
+	return func(err error) (bool, bool) {
+		var target E
+		if errors.As(err, &target) {
+			return redact, true
+		}
+		return false, false
+	}
+}
+
+// RedactionPolicy decides which error responses have their internal details removed.
+//
+// When enabled, the error text and details of 5xx responses are redacted while 4xx
+// responses are left intact. Registered rules take precedence over this default, newest
+// first. Redaction only affects response bodies; logs and traces keep the full error.
+type RedactionPolicy struct {
+	mu      sync.RWMutex
+	enabled bool
+	rules   []RedactionRule
+}
+
+// NewRedactionPolicy creates a redaction policy. Handlers enable it in production.
+func NewRedactionPolicy(enabled bool) *RedactionPolicy {
+	return &RedactionPolicy{
+		enabled: enabled,
+	}
+}
+
+// Register adds a rule that takes precedence over the default and earlier rules.
+func (p *RedactionPolicy) Register(rule RedactionRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, rule)
+}
+
+// Redact reports whether the details of err must be left out of a response with the given status code.
+func (p *RedactionPolicy) Redact(statusCode int, err error) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for i := len(p.rules) - 1; i >= 0; i-- {
+		if redact, ok := p.rules[i](err); ok {
+			return redact
+		}
+	}
+
+	return p.enabled && statusCode >= 500
+}
@@ -0,0 +1,139 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactionPolicy_Redact(t *testing.T) {
+	dbErr := NewInternalErrorWithOperation("query_users", "connection refused", errors.New("dial tcp 10.0.0.5:5432"))
+
+	tests := []struct {
+		name       string
+		policy     *RedactionPolicy
+		statusCode int
+		err        error
+		expected   bool
+	}{
+		{name: "disabled", policy: NewRedactionPolicy(false), statusCode: 500, err: dbErr, expected: false},
+		{name: "enabled server error", policy: NewRedactionPolicy(true), statusCode: 500, err: dbErr, expected: true},
+		{name: "enabled client error", policy: NewRedactionPolicy(true), statusCode: 400, err: NewValidationError("bad", "name", nil), expected: false},
+		{
+			name: "type rule exposes server error",
+			policy: func() *RedactionPolicy {
+				policy := NewRedactionPolicy(true)
+				policy.Register(RedactErrorType[*ExternalServiceError](false))
+				return policy
+			}(),
+			statusCode: 503,
+			err:        fmt.Errorf("charging card: %w", NewExternalServiceError("payments", "throttled", 429, true, nil)),
+			expected:   false,
+		},
+		{
+			name: "type rule redacts client error",
+			policy: func() *RedactionPolicy {
+				policy := NewRedactionPolicy(false)
+				policy.Register(RedactErrorType[*ConflictError](true))
+				return policy
+			}(),
+			statusCode: 409,
+			err:        NewConflictError("duplicate key"),
+			expected:   true,
+		},
+		{
+			name: "newest rule wins",
+			policy: func() *RedactionPolicy {
+				policy := NewRedactionPolicy(true)
+				policy.Register(RedactErrorType[*InternalError](false))
+				policy.Register(RedactErrorType[*InternalError](true))
+				return policy
+			}(),
+			statusCode: 500,
+			err:        dbErr,
+			expected:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Redact(tt.statusCode, tt.err))
+		})
+	}
+}
+
+func TestHandler_RedactsServerErrorsInProduction(t *testing.T) {
+	business := func(ctx context.Context, request *Request) (interface{}, error) {
+		if request.Path == "/orders" {
+			return nil, NewBusinessLogicError("order limit reached", "ORDER_LIMIT").WithDetail("limit", 5)
+		}
+		return nil, NewInternalErrorWithOperation("query_users", "connection refused", errors.New("dial tcp 10.0.0.5:5432"))
+	}
+	ctx := testutil.CreateTestContext("test-request")
+
+	decode := func(t *testing.T, body string) http.ErrorResponse {
+		var response http.ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &response))
+		return response
+	}
+
+	t.Run("development exposes details", func(t *testing.T) {
+		h := newTestHandler(t)
+		response, err := h.WrapHTTPAPI(business)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		body := decode(t, response.Body)
+		assert.Equal(t, 500, response.StatusCode)
+		assert.Contains(t, body.Error, "connection refused")
+	})
+
+	t.Run("production redacts server errors", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Environment = "production"
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		response, err := h.WrapHTTPAPI(business)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		body := decode(t, response.Body)
+		assert.Equal(t, 500, response.StatusCode)
+		assert.Equal(t, "Internal server error", body.Message)
+		assert.Equal(t, ErrorCodeInternal, body.Code)
+		assert.Equal(t, "test-request", body.RequestID)
+		assert.Empty(t, body.Error)
+		assert.NotContains(t, response.Body, "query_users")
+		assert.NotContains(t, response.Body, "10.0.0.5")
+	})
+
+	t.Run("production keeps client error details", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Environment = "production"
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		response, err := h.WrapHTTPAPI(business)(ctx, testutil.CreateTestAPIGatewayV2Request("POST", "/orders"))
+		require.NoError(t, err)
+
+		body := decode(t, response.Body)
+		assert.Equal(t, 422, response.StatusCode)
+		assert.Equal(t, "ORDER_LIMIT", body.Code)
+		assert.Equal(t, map[string]interface{}{"limit": float64(5)}, body.Details)
+	})
+
+	t.Run("policy override", func(t *testing.T) {
+		policy := NewRedactionPolicy(true)
+		policy.Register(RedactErrorType[*InternalError](false))
+		h := newTestHandler(t).WithRedactionPolicy(policy)
+
+		response, err := h.WrapHTTPAPI(business)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assert.Contains(t, decode(t, response.Body).Error, "connection refused")
+	})
+}
@@ -2,7 +2,9 @@
 package lambda
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -111,6 +113,74 @@ func NewResourceConflictError(resource, message string, err error) *ConflictErro
 	}
 }
 
+// PreconditionFailedError represents a conditional request, such as one with If-Match,
+// whose precondition does not hold for the current state of the resource.
+type PreconditionFailedError struct {
+	Message   string
+	Condition string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	if e.Condition != "" {
+		return fmt.Sprintf("precondition failed: %s (%s)", e.Message, e.Condition)
+	}
+	return fmt.Sprintf("precondition failed: %s", e.Message)
+}
+
+// NewPreconditionFailedError creates a new precondition failed error for the header named
+// by condition.
+func NewPreconditionFailedError(message, condition string) *PreconditionFailedError {
+	return &PreconditionFailedError{
+		Message:   message,
+		Condition: condition,
+	}
+}
+
+// PayloadTooLargeError represents a request body larger than the accepted limit.
+type PayloadTooLargeError struct {
+	Message string
+	Limit   int
+	Size    int
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("payload too large: %s (%d bytes, limit %d)", e.Message, e.Size, e.Limit)
+}
+
+// NewPayloadTooLargeError creates a new payload too large error for a body of size bytes.
+func NewPayloadTooLargeError(message string, size, limit int) *PayloadTooLargeError {
+	return &PayloadTooLargeError{
+		Message: message,
+		Limit:   limit,
+		Size:    size,
+	}
+}
+
+// UnsupportedMediaTypeError represents a request body in a media type the endpoint does
+// not accept.
+type UnsupportedMediaTypeError struct {
+	Message     string
+	ContentType string
+	Supported   []string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	if e.ContentType != "" {
+		return fmt.Sprintf("unsupported media type %s: %s", e.ContentType, e.Message)
+	}
+	return fmt.Sprintf("unsupported media type: %s", e.Message)
+}
+
+// NewUnsupportedMediaTypeError creates a new unsupported media type error for a body sent
+// as contentType, listing the supported media types.
+func NewUnsupportedMediaTypeError(message, contentType string, supported []string) *UnsupportedMediaTypeError {
+	return &UnsupportedMediaTypeError{
+		Message:     message,
+		ContentType: contentType,
+		Supported:   supported,
+	}
+}
+
 // UnauthorizedError represents an authentication error.
 type UnauthorizedError struct {
 	Message string
@@ -172,6 +242,49 @@ func NewResourceForbiddenError(resource, operation, message string) *ForbiddenEr
 	}
 }
 
+// MethodNotAllowedError represents a request method that the matched resource does not support.
+type MethodNotAllowedError struct {
+	Message string
+	Method  string
+	Allowed []string
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	if len(e.Allowed) > 0 {
+		return fmt.Sprintf("method not allowed: %s (allowed: %s)", e.Message, strings.Join(e.Allowed, ", "))
+	}
+	return fmt.Sprintf("method not allowed: %s", e.Message)
+}
+
+// NewMethodNotAllowedError creates a new method not allowed error.
+func NewMethodNotAllowedError(method string, allowed []string) *MethodNotAllowedError {
+	return &MethodNotAllowedError{
+		Message: fmt.Sprintf("HTTP method %s is not allowed for this resource", method),
+		Method:  method,
+		Allowed: allowed,
+	}
+}
+
+// RateLimitError represents a request rejected because the caller exceeded its rate limit.
+type RateLimitError struct {
+	Message    string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded: %s", e.Message)
+}
+
+// NewRateLimitError creates a new rate limit error.
+func NewRateLimitError(message string, limit int, retryAfter time.Duration) *RateLimitError {
+	return &RateLimitError{
+		Message:    message,
+		Limit:      limit,
+		RetryAfter: retryAfter,
+	}
+}
+
 // TimeoutError represents a request timeout error.
 type TimeoutError struct {
 	Message string
@@ -193,6 +306,25 @@ func NewTimeoutError(message string, timeout time.Duration) *TimeoutError {
 	}
 }
 
+// ServiceUnavailableError represents a request rejected because the function cannot serve
+// it right now, for example when too little invocation time remains.
+type ServiceUnavailableError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *ServiceUnavailableError) Error() string {
+	return fmt.Sprintf("service unavailable: %s", e.Message)
+}
+
+// NewServiceUnavailableError creates a new service unavailable error.
+func NewServiceUnavailableError(message string, retryAfter time.Duration) *ServiceUnavailableError {
+	return &ServiceUnavailableError{
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+}
+
 // InternalError represents an internal system error.
 type InternalError struct {
 	Message   string
@@ -228,6 +360,25 @@ func NewInternalErrorWithOperation(operation, message string, err error) *Intern
 	}
 }
 
+// PanicError represents a panic recovered from a handler. It is returned as the cause of an
+// InternalError so the panic never decides the response status.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// NewPanicError creates a new panic error from a recovered value and its stack trace.
+func NewPanicError(value interface{}, stack []byte) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: stack,
+	}
+}
+
 // BusinessLogicError represents a business logic validation error.
 type BusinessLogicError struct {
 	Message string
@@ -260,12 +411,26 @@ func (e *BusinessLogicError) WithDetail(key string, value interface{}) *Business
 	return e
 }
 
+// ErrorCode returns the machine-readable business rule code, or ErrorCodeBusinessRule if none was set.
+func (e *BusinessLogicError) ErrorCode() string {
+	if e.Code == "" {
+		return ErrorCodeBusinessRule
+	}
+	return e.Code
+}
+
+// ErrorDetails returns the structured details describing the violated rule.
+func (e *BusinessLogicError) ErrorDetails() map[string]interface{} {
+	return e.Details
+}
+
 // ExternalServiceError represents an error from an external service.
 type ExternalServiceError struct {
 	Message     string
 	Service     string
 	StatusCode  int
 	Retryable   bool
+	RetryAfter  time.Duration
 	Err         error
 }
 
@@ -288,6 +453,12 @@ func (e *ExternalServiceError) IsRetryable() bool {
 	return e.Retryable
 }
 
+// WithRetryAfter sets how long clients should wait before retrying a retryable error.
+func (e *ExternalServiceError) WithRetryAfter(retryAfter time.Duration) *ExternalServiceError {
+	e.RetryAfter = retryAfter
+	return e
+}
+
 // NewExternalServiceError creates a new external service error.
 func NewExternalServiceError(service, message string, statusCode int, retryable bool, err error) *ExternalServiceError {
 	return &ExternalServiceError{
@@ -299,63 +470,106 @@ func NewExternalServiceError(service, message string, statusCode int, retryable
 	}
 }
 
-// IsValidationError checks if an error is a validation error.
+// IsValidationError checks if an error, or any error it wraps, is a validation error.
 func IsValidationError(err error) bool {
-	_, ok := err.(*ValidationError)
-	return ok
+	var target *ValidationError
+	return errors.As(err, &target)
 }
 
-// IsNotFoundError checks if an error is a not found error.
+// IsNotFoundError checks if an error, or any error it wraps, is a not found error.
 func IsNotFoundError(err error) bool {
-	_, ok := err.(*NotFoundError)
-	return ok
+	var target *NotFoundError
+	return errors.As(err, &target)
 }
 
-// IsConflictError checks if an error is a conflict error.
+// IsConflictError checks if an error, or any error it wraps, is a conflict error.
 func IsConflictError(err error) bool {
-	_, ok := err.(*ConflictError)
-	return ok
+	var target *ConflictError
+	return errors.As(err, &target)
+}
+
+// IsPreconditionFailedError checks if an error, or any error it wraps, is a precondition failed error.
+func IsPreconditionFailedError(err error) bool {
+	var target *PreconditionFailedError
+	return errors.As(err, &target)
+}
+
+// IsPayloadTooLargeError checks if an error, or any error it wraps, is a payload too large error.
+func IsPayloadTooLargeError(err error) bool {
+	var target *PayloadTooLargeError
+	return errors.As(err, &target)
 }
 
-// IsUnauthorizedError checks if an error is an unauthorized error.
+// IsUnsupportedMediaTypeError checks if an error, or any error it wraps, is an unsupported media type error.
+func IsUnsupportedMediaTypeError(err error) bool {
+	var target *UnsupportedMediaTypeError
+	return errors.As(err, &target)
+}
+
+// IsUnauthorizedError checks if an error, or any error it wraps, is an unauthorized error.
 func IsUnauthorizedError(err error) bool {
-	_, ok := err.(*UnauthorizedError)
-	return ok
+	var target *UnauthorizedError
+	return errors.As(err, &target)
 }
 
-// IsForbiddenError checks if an error is a forbidden error.
+// IsForbiddenError checks if an error, or any error it wraps, is a forbidden error.
 func IsForbiddenError(err error) bool {
-	_, ok := err.(*ForbiddenError)
-	return ok
+	var target *ForbiddenError
+	return errors.As(err, &target)
+}
+
+// IsMethodNotAllowedError checks if an error, or any error it wraps, is a method not allowed error.
+func IsMethodNotAllowedError(err error) bool {
+	var target *MethodNotAllowedError
+	return errors.As(err, &target)
+}
+
+// IsRateLimitError checks if an error, or any error it wraps, is a rate limit error.
+func IsRateLimitError(err error) bool {
+	var target *RateLimitError
+	return errors.As(err, &target)
 }
 
-// IsTimeoutError checks if an error is a timeout error.
+// IsTimeoutError checks if an error, or any error it wraps, is a timeout error.
 func IsTimeoutError(err error) bool {
-	_, ok := err.(*TimeoutError)
-	return ok
+	var target *TimeoutError
+	return errors.As(err, &target)
+}
+
+// IsServiceUnavailableError checks if an error, or any error it wraps, is a service unavailable error.
+func IsServiceUnavailableError(err error) bool {
+	var target *ServiceUnavailableError
+	return errors.As(err, &target)
 }
 
-// IsInternalError checks if an error is an internal error.
+// IsInternalError checks if an error, or any error it wraps, is an internal error.
 func IsInternalError(err error) bool {
-	_, ok := err.(*InternalError)
-	return ok
+	var target *InternalError
+	return errors.As(err, &target)
+}
+
+// IsPanicError checks if an error, or any error it wraps, is a recovered panic.
+func IsPanicError(err error) bool {
+	var target *PanicError
+	return errors.As(err, &target)
 }
 
-// IsBusinessLogicError checks if an error is a business logic error.
+// IsBusinessLogicError checks if an error, or any error it wraps, is a business logic error.
 func IsBusinessLogicError(err error) bool {
-	_, ok := err.(*BusinessLogicError)
-	return ok
+	var target *BusinessLogicError
+	return errors.As(err, &target)
 }
 
-// IsExternalServiceError checks if an error is an external service error.
+// IsExternalServiceError checks if an error, or any error it wraps, is an external service error.
 func IsExternalServiceError(err error) bool {
-	_, ok := err.(*ExternalServiceError)
-	return ok
+	var target *ExternalServiceError
+	return errors.As(err, &target)
 }
 
-// IsRetryableError checks if an error is retryable.
+// IsRetryableError checks if an error, or any error it wraps, is retryable.
 func IsRetryableError(err error) bool {
-	if extErr, ok := err.(*ExternalServiceError); ok {
+	var extErr *ExternalServiceError
+	if errors.As(err, &extErr) {
 		return extErr.IsRetryable()
 	}
 
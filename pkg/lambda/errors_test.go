@@ -1,6 +1,7 @@
 package lambda
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -118,6 +119,11 @@ func TestIsValidationError(t *testing.T) {
 			err:      NewNotFoundError("resource not found"),
 			expected: false,
 		},
+		{
+			name:     "wrapped error",
+			err:      fmt.Errorf("context: %w", NewValidationError("test", "field", "value")),
+			expected: true,
+		},
 		{
 			name:     "nil error",
 			err:      nil,
@@ -218,6 +224,11 @@ func TestIsNotFoundError(t *testing.T) {
 			err:      NewValidationError("test", "field", "value"),
 			expected: false,
 		},
+		{
+			name:     "wrapped error",
+			err:      fmt.Errorf("context: %w", NewNotFoundError("resource not found")),
+			expected: true,
+		},
 		{
 			name:     "nil error",
 			err:      nil,
@@ -409,4 +420,6 @@ func TestNewConflictError(t *testing.T) {
 	err := NewConflictError(message)
 
 	assert.Equal(t, message, err.Message)
-	assert.Empty(t, err
+	assert.Empty(t, err.Resource)
+	assert.Nil(t, err.Err)
+}
@@ -5,6 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"lambda-go-template/pkg/config"
@@ -13,22 +16,31 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambdacontext"
+	"go.uber.org/zap"
 )
 
 // Context key types to avoid collisions
 type contextKey string
 
 const (
-	contextKeyParsedBody contextKey = "parsed_body"
-	contextKeyRequestID  contextKey = "request_id"
-	contextKeyTimestamp  contextKey = "timestamp"
+	contextKeyParsedBody      contextKey = "parsed_body"
+	contextKeyRequestID       contextKey = "request_id"
+	contextKeyTimestamp       contextKey = "timestamp"
+	contextKeyResponseHeaders contextKey = "response_headers"
+	contextKeyClaims          contextKey = "claims"
 )
 
 // Handler represents a Lambda function handler with observability and error handling.
 type Handler struct {
-	config *config.Config
-	logger *observability.Logger
-	tracer *observability.Tracer
+	config      *config.Config
+	logger      *observability.Logger
+	tracer      *observability.Tracer
+	metrics     *observability.Metrics
+	errorMapper *ErrorMapper
+	redaction   *RedactionPolicy
+	redactor    *observability.Redactor
+	cors        http.CORSPolicy
+	abandoned   atomic.Int64
 }
 
 // HandlerFunc represents a Lambda function that processes API Gateway requests.
@@ -46,12 +58,112 @@ type MiddlewareFuncV2 func(HandlerFuncV2) HandlerFuncV2
 // NewHandler creates a new Lambda handler with observability.
 func NewHandler(cfg *config.Config, logger *observability.Logger, tracer *observability.Tracer) *Handler {
 	return &Handler{
-		config: cfg,
-		logger: logger,
-		tracer: tracer,
+		config:      cfg,
+		logger:      logger,
+		tracer:      tracer,
+		metrics:     metricsFromConfig(cfg),
+		errorMapper: defaultErrorMapper,
+		redaction:   NewRedactionPolicy(cfg.IsProduction()),
+		redactor:    redactorFromConfig(cfg),
+		cors:        corsPolicyFromConfig(cfg),
 	}
 }
 
+// WithErrorMapper sets the mapper used to convert handler errors into responses.
+// Handlers use the default mapper, extended through RegisterErrorMapping, unless one is set.
+func (h *Handler) WithErrorMapper(mapper *ErrorMapper) *Handler {
+	h.errorMapper = mapper
+	return h
+}
+
+// WithMetrics sets where the handler publishes metrics. By default they are written to
+// standard output when metrics are enabled.
+func (h *Handler) WithMetrics(metrics *observability.Metrics) *Handler {
+	h.metrics = metrics
+	return h
+}
+
+// metricsFromConfig creates the metrics of a handler, namespaced by service unless
+// configured otherwise.
+func metricsFromConfig(cfg *config.Config) *observability.Metrics {
+	namespace := cfg.MetricsNamespace
+	if namespace == "" {
+		namespace = cfg.ServiceName
+	}
+	return observability.NewMetrics(observability.MetricsConfig{
+		Enabled:     cfg.EnableMetrics,
+		Namespace:   namespace,
+		ServiceName: cfg.ServiceName,
+	})
+}
+
+// WithRedactionPolicy sets the policy deciding which error details are kept out of response
+// bodies. By default 5xx details are redacted in production.
+func (h *Handler) WithRedactionPolicy(policy *RedactionPolicy) *Handler {
+	h.redaction = policy
+	return h
+}
+
+// WithRedactor sets the redactor applied to request data before Logging and Tracing record
+// it, for rules such as regular expressions that cannot be configured through the
+// environment.
+func (h *Handler) WithRedactor(redactor *observability.Redactor) *Handler {
+	h.redactor = redactor
+	return h
+}
+
+// redactorFromConfig creates the redactor of a handler from the REDACT_* settings.
+func redactorFromConfig(cfg *config.Config) *observability.Redactor {
+	return observability.NewRedactor(observability.RedactionConfig{
+		Fields:    cfg.RedactFields,
+		BodyPaths: cfg.RedactBodyPaths,
+		Mode:      cfg.RedactMode,
+		HashKey:   cfg.RedactHashKey,
+	})
+}
+
+// RequestHandlerFunc represents a Lambda function that processes a normalized Request
+// regardless of the trigger that delivered it.
+type RequestHandlerFunc func(ctx context.Context, request *Request) (interface{}, error)
+
+// Middleware represents middleware that wraps request handlers. Middleware written against
+// Request runs unchanged behind every supported trigger.
+type Middleware func(RequestHandlerFunc) RequestHandlerFunc
+
+// V1 adapts the middleware to API Gateway REST handlers.
+func (m Middleware) V1() MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayProxyRequest) (interface{}, error) {
+			handler := m(func(ctx context.Context, _ *Request) (interface{}, error) {
+				return next(ctx, event)
+			})
+			return handler(ctx, NewRequestFromAPIGateway(event))
+		}
+	}
+}
+
+// V2 adapts the middleware to API Gateway HTTP API handlers.
+func (m Middleware) V2() MiddlewareFuncV2 {
+	return func(next HandlerFuncV2) HandlerFuncV2 {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (interface{}, error) {
+			handler := m(func(ctx context.Context, _ *Request) (interface{}, error) {
+				return next(ctx, event)
+			})
+			return handler(ctx, NewRequestFromHTTPAPI(event))
+		}
+	}
+}
+
+// Chain applies middlewares to a request handler. The first middleware is the outermost.
+func Chain(handlerFunc RequestHandlerFunc, middlewares ...Middleware) RequestHandlerFunc {
+	// Apply middlewares in reverse order
+	wrapped := handlerFunc
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		wrapped = middlewares[i](wrapped)
+	}
+	return wrapped
+}
+
 // Wrap wraps a handler function with common Lambda functionality including:
 // - Request/response logging
 // - Distributed tracing
@@ -64,271 +176,227 @@ func (h *Handler) Wrap(handlerFunc HandlerFunc, middlewares ...MiddlewareFunc) f
 		wrapped = middlewares[i](wrapped)
 	}
 
-	return func(ctx context.Context, request events.APIGatewayProxyRequest) (http.Response, error) {
-		start := time.Now()
-
-		// Get Lambda context
-		lc, _ := lambdacontext.FromContext(ctx)
-		requestID := ""
-		if lc != nil {
-			requestID = lc.AwsRequestID
-		}
-
-		// Create tracing segment
-		ctx, seg := h.tracer.StartSegment(ctx, h.config.ServiceName)
-		defer h.tracer.Close(seg, nil)
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (http.Response, error) {
+		response := h.serve(ctx, NewRequestFromAPIGateway(event), func(ctx context.Context, _ *Request) (interface{}, error) {
+			return wrapped(ctx, event)
+		})
+		return ToAPIGatewayResponse(response), nil
+	}
+}
 
-		// Add request metadata to tracing
-		h.tracer.AddAnnotation(ctx, "http_method", request.HTTPMethod)
-		h.tracer.AddAnnotation(ctx, "http_path", request.Path)
-		h.tracer.AddAnnotation(ctx, "request_id", requestID)
+// WrapV2 wraps a handler function for API Gateway v2 HTTP API with common Lambda functionality.
+func (h *Handler) WrapV2(handlerFunc HandlerFuncV2, middlewares ...MiddlewareFuncV2) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	// Apply middlewares in reverse order
+	wrapped := handlerFunc
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		wrapped = middlewares[i](wrapped)
+	}
 
-		// Log Lambda invocation start
-		if lc != nil {
-			h.logger.LogLambdaStart(ctx, "", "", 0)
-		}
+	return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		response := h.serve(ctx, NewRequestFromHTTPAPI(event), func(ctx context.Context, _ *Request) (interface{}, error) {
+			return wrapped(ctx, event)
+		})
+		return ToHTTPAPIResponse(response), nil
+	}
+}
 
-		// Create response builder
-		responseBuilder := http.NewResponseBuilder().
-			WithRequestID(requestID).
-			WithPath(request.Path).
-			WithCORS().
-			WithCacheControl(h.config.CacheMaxAge)
-
-		// Process the request
-		data, err := wrapped(ctx, request)
-		duration := time.Since(start).Milliseconds()
-
-		if err != nil {
-			// Log error
-			h.logger.LogLambdaError(ctx, err, "Handler execution failed")
-
-			// Add error to tracing
-			h.tracer.AddError(ctx, err)
-			h.tracer.AddAnnotation(ctx, "error", true)
-
-			// Determine error type and create appropriate response
-			var response http.Response
-			switch e := err.(type) {
-			case *ValidationError:
-				response = responseBuilder.BadRequest(e.Message, e.Err)
-			case *NotFoundError:
-				response = responseBuilder.NotFound(e.Message)
-			case *ConflictError:
-				response = responseBuilder.Conflict(e.Message, e.Err)
-			case *UnauthorizedError:
-				response = responseBuilder.Unauthorized(e.Message)
-			case *ForbiddenError:
-				response = responseBuilder.Forbidden(e.Message)
-			default:
-				response = responseBuilder.InternalServerError("Internal server error", err)
-			}
+// WrapAPIGateway wraps a request handler for API Gateway REST APIs.
+func (h *Handler) WrapAPIGateway(handlerFunc RequestHandlerFunc, middlewares ...Middleware) func(context.Context, events.APIGatewayProxyRequest) (http.Response, error) {
+	wrapped := Chain(handlerFunc, middlewares...)
 
-			// Log HTTP response
-			h.logger.LogHTTPRequest(ctx, request.HTTPMethod, request.Path, response.StatusCode, duration)
-			h.tracer.AddAnnotation(ctx, "http_status", response.StatusCode)
-			h.tracer.AddAnnotation(ctx, "duration_ms", duration)
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (http.Response, error) {
+		return ToAPIGatewayResponse(h.serve(ctx, NewRequestFromAPIGateway(event), wrapped)), nil
+	}
+}
 
-			return response, nil
-		}
+// WrapHTTPAPI wraps a request handler for API Gateway HTTP APIs.
+func (h *Handler) WrapHTTPAPI(handlerFunc RequestHandlerFunc, middlewares ...Middleware) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	wrapped := Chain(handlerFunc, middlewares...)
 
-		// Create success response
-		response := responseBuilder.OK(data)
+	return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		return ToHTTPAPIResponse(h.serve(ctx, NewRequestFromHTTPAPI(event), wrapped)), nil
+	}
+}
 
-		// Add response metadata to tracing
-		h.tracer.AddAnnotation(ctx, "http_status", response.StatusCode)
-		h.tracer.AddAnnotation(ctx, "duration_ms", duration)
-		h.tracer.AddAnnotation(ctx, "success", true)
+// WrapALB wraps a request handler for Application Load Balancer target groups.
+// Responses use multi-value headers when the target group has them enabled.
+func (h *Handler) WrapALB(handlerFunc RequestHandlerFunc, middlewares ...Middleware) func(context.Context, events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	wrapped := Chain(handlerFunc, middlewares...)
 
-		// Add response size to metadata
-		responseSize := len(response.Body)
-		h.tracer.AddMetadata(ctx, "response", map[string]interface{}{
-			"size_bytes": responseSize,
-		})
+	return func(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+		response := h.serve(ctx, NewRequestFromALB(event), wrapped)
+		return ToALBResponse(response, isMultiValueALB(event)), nil
+	}
+}
 
-		// Log successful completion
-		h.logger.LogHTTPRequest(ctx, request.HTTPMethod, request.Path, response.StatusCode, duration)
-		h.logger.LogLambdaEnd(ctx, duration)
+// WrapFunctionURL wraps a request handler for Lambda Function URLs.
+func (h *Handler) WrapFunctionURL(handlerFunc RequestHandlerFunc, middlewares ...Middleware) func(context.Context, events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	wrapped := Chain(handlerFunc, middlewares...)
 
-		return response, nil
+	return func(ctx context.Context, event events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		return ToFunctionURLResponse(h.serve(ctx, NewRequestFromFunctionURL(event), wrapped)), nil
 	}
 }
 
-// WrapV2 wraps a handler function for API Gateway v2 HTTP API with common Lambda functionality.
-func (h *Handler) WrapV2(handlerFunc HandlerFuncV2, middlewares ...MiddlewareFuncV2) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-	// Apply middlewares in reverse order
-	wrapped := handlerFunc
-	for i := len(middlewares) - 1; i >= 0; i-- {
-		wrapped = middlewares[i](wrapped)
-	}
+// serve runs a normalized request through the handler and formats the response.
+// Every trigger-specific entrypoint delegates here.
+func (h *Handler) serve(ctx context.Context, request *Request, handlerFunc RequestHandlerFunc) http.Response {
+	start := time.Now()
 
-	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-		start := time.Now()
+	// Get Lambda context
+	lc, _ := lambdacontext.FromContext(ctx)
+	requestID := ""
+	if lc != nil {
+		requestID = lc.AwsRequestID
+	}
 
-		// Get Lambda context
-		lc, _ := lambdacontext.FromContext(ctx)
-		requestID := ""
-		if lc != nil {
-			requestID = lc.AwsRequestID
-		}
+	// Create tracing segment
+	ctx, seg := h.tracer.StartSegment(ctx, h.config.ServiceName)
+	defer h.tracer.Close(seg, nil)
 
-		// Create tracing segment
-		ctx, seg := h.tracer.StartSegment(ctx, h.config.ServiceName)
-		defer h.tracer.Close(seg, nil)
+	// Add request metadata to tracing
+	h.tracer.AddAnnotation(ctx, "http_method", request.Method)
+	h.tracer.AddAnnotation(ctx, "http_path", request.clientPath())
+	h.tracer.AddAnnotation(ctx, "request_id", requestID)
+	h.tracer.AddAnnotation(ctx, "event_source", string(request.Source))
 
-		// Add request metadata to tracing
-		h.tracer.AddAnnotation(ctx, "http_method", request.RequestContext.HTTP.Method)
-		h.tracer.AddAnnotation(ctx, "http_path", request.RawPath)
-		h.tracer.AddAnnotation(ctx, "request_id", requestID)
+	// Log Lambda invocation start
+	if lc != nil {
+		h.logger.LogLambdaStart(ctx, "", "", 0)
+	}
 
-		// Log Lambda invocation start
-		if lc != nil {
-			h.logger.LogLambdaStart(ctx, "", "", 0)
-		}
+	// Answer CORS preflight requests without running the handler
+	if isPreflight(request) {
+		return h.preflight(ctx, request, start)
+	}
 
-		// Create response builder
-		responseBuilder := http.NewResponseBuilder().
-			WithRequestID(requestID).
-			WithPath(request.RawPath).
-			WithCORS().
-			WithCacheControl(h.config.CacheMaxAge)
-
-		// Process the request
-		data, err := wrapped(ctx, request)
-		duration := time.Since(start).Milliseconds()
-
-		if err != nil {
-			// Log error
-			h.logger.LogLambdaError(ctx, err, "Handler execution failed")
-
-			// Add error to tracing
-			h.tracer.AddError(ctx, err)
-			h.tracer.AddAnnotation(ctx, "error", true)
-
-			// Determine error type and create appropriate response
-			var response http.Response
-			switch e := err.(type) {
-			case *ValidationError:
-				response = responseBuilder.BadRequest(e.Message, e.Err)
-			case *NotFoundError:
-				response = responseBuilder.NotFound(e.Message)
-			case *ConflictError:
-				response = responseBuilder.Conflict(e.Message, e.Err)
-			case *UnauthorizedError:
-				response = responseBuilder.Unauthorized(e.Message)
-			case *ForbiddenError:
-				response = responseBuilder.Forbidden(e.Message)
-			default:
-				response = responseBuilder.InternalServerError("Internal server error", err)
-			}
+	// Collect headers that middleware and handlers set on the response
+	headers := &responseHeaders{values: make(map[string]string)}
+	ctx = context.WithValue(ctx, contextKeyResponseHeaders, headers)
 
-			// Log HTTP response
-			h.logger.LogHTTPRequest(ctx, request.RequestContext.HTTP.Method, request.RawPath, response.StatusCode, duration)
-			h.tracer.AddAnnotation(ctx, "http_status", response.StatusCode)
-			h.tracer.AddAnnotation(ctx, "duration_ms", duration)
-
-			// Convert to v2 response
-			return events.APIGatewayV2HTTPResponse{
-				StatusCode: response.StatusCode,
-				Headers:    response.Headers,
-				Body:       response.Body,
-			}, nil
+	// Process the request, converting panics into errors
+	data, err := h.Recovery()(handlerFunc)(ctx, request)
+	duration := time.Since(start).Milliseconds()
+	response := h.render(ctx, request, data, err)
+	if err == nil {
+		h.conditional(ctx, request, data, &response)
+	}
+	h.applyCORS(request, &response)
+	h.compress(ctx, request, &response)
+
+	if err != nil {
+		// Log error
+		h.logger.LogLambdaError(ctx, err, "Handler execution failed")
+
+		// Add error to tracing
+		h.tracer.AddError(ctx, err)
+		h.tracer.AddAnnotation(ctx, "error", true)
+		if h.redaction.Redact(response.StatusCode, err) {
+			h.tracer.AddAnnotation(ctx, "error_redacted", true)
 		}
 
-		// Create success response
-		response := responseBuilder.OK(data)
-
-		// Add response metadata to tracing
+		// Log HTTP response
+		h.logger.LogHTTPRequest(ctx, request.Method, request.clientPath(), response.StatusCode, duration)
 		h.tracer.AddAnnotation(ctx, "http_status", response.StatusCode)
 		h.tracer.AddAnnotation(ctx, "duration_ms", duration)
-		h.tracer.AddAnnotation(ctx, "success", true)
 
-		// Add response size to metadata
-		responseSize := len(response.Body)
-		h.tracer.AddMetadata(ctx, "response", map[string]interface{}{
-			"size_bytes": responseSize,
-		})
+		return response
+	}
 
-		// Log successful completion
-		h.logger.LogHTTPRequest(ctx, request.RequestContext.HTTP.Method, request.RawPath, response.StatusCode, duration)
-		h.logger.LogLambdaEnd(ctx, duration)
+	// Add response metadata to tracing
+	h.tracer.AddAnnotation(ctx, "http_status", response.StatusCode)
+	h.tracer.AddAnnotation(ctx, "duration_ms", duration)
+	// Handlers may answer without an error but with a client error status, such as a
+	// replayed 400
+	h.tracer.AddAnnotation(ctx, "success", response.StatusCode < 400)
 
-		// Convert to v2 response
-		return events.APIGatewayV2HTTPResponse{
-			StatusCode: response.StatusCode,
-			Headers:    response.Headers,
-			Body:       response.Body,
-		}, nil
-	}
+	// Add response size to metadata
+	responseSize := len(response.Body)
+	h.tracer.AddMetadata(ctx, "response", map[string]interface{}{
+		"size_bytes": responseSize,
+	})
+
+	// Log successful completion
+	h.logger.LogHTTPRequest(ctx, request.Method, request.clientPath(), response.StatusCode, duration)
+	h.logger.LogLambdaEnd(ctx, duration)
+
+	return response
 }
 
-// LoggingMiddleware adds request/response logging.
-func (h *Handler) LoggingMiddleware() MiddlewareFunc {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
-			// Log request details
-			h.logger.WithFields(map[string]interface{}{
-				"method":      request.HTTPMethod,
-				"path":        request.Path,
-				"query":       request.QueryStringParameters,
-				"headers":     request.Headers,
-				"user_agent":  request.Headers["User-Agent"],
-				"source_ip":   request.RequestContext.Identity.SourceIP,
-			}).Info("Processing request")
+// render converts the outcome of a handler into the HTTP response sent to the client.
+// Handlers returning an http.Response have it sent as is; replayed idempotent responses
+// get the current request's ID and response headers.
+func (h *Handler) render(ctx context.Context, request *Request, data interface{}, err error) http.Response {
+	if response, ok := data.(http.Response); ok && err == nil {
+		return response
+	}
 
-			return next(ctx, request)
-		}
+	responseBuilder := http.NewResponseBuilder().
+		WithRequestID(GetRequestID(ctx)).
+		WithPath(request.clientPath()).
+		WithCacheControl(h.config.CacheMaxAge).
+		WithErrorFormat(http.NegotiateErrorFormat(request.Header("Accept"), http.ErrorFormat(h.config.ErrorFormat))).
+		WithProblemTypeBaseURL(h.config.ProblemTypeBaseURL)
+
+	if headers, ok := ctx.Value(contextKeyResponseHeaders).(*responseHeaders); ok {
+		headers.apply(responseBuilder)
+	}
+
+	if replayed, ok := data.(replayedResponse); ok && err == nil {
+		return responseBuilder.Replay(replayed.response)
 	}
+
+	if err != nil {
+		// Redaction is decided on the original error so type rules see the full chain
+		responseBuilder.WithRedaction(func(statusCode int, _ error) bool {
+			return h.redaction.Redact(statusCode, err)
+		})
+		return h.errorMapper.Map(responseBuilder, err)
+	}
+
+	return responseBuilder.OK(data)
 }
 
-// LoggingMiddlewareV2 adds request/response logging for v2 HTTP API.
-func (h *Handler) LoggingMiddlewareV2() MiddlewareFuncV2 {
-	return func(next HandlerFuncV2) HandlerFuncV2 {
-		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
+// Logging adds request/response logging. Headers and query parameters are redacted
+// first, and at debug level JSON bodies are logged with their sensitive fields redacted.
+func (h *Handler) Logging() Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
 			// Log request details
 			h.logger.WithFields(map[string]interface{}{
-				"method":      request.RequestContext.HTTP.Method,
-				"path":        request.RawPath,
-				"query":       request.QueryStringParameters,
-				"headers":     request.Headers,
-				"user_agent":  request.RequestContext.HTTP.UserAgent,
-				"source_ip":   request.RequestContext.HTTP.SourceIP,
+				"method":     request.Method,
+				"path":       request.Path,
+				"query":      h.redactor.RedactValues(request.Query()),
+				"headers":    h.redactor.RedactValues(request.CanonicalHeaders()),
+				"user_agent": request.UserAgent,
+				"source_ip":  request.SourceIP,
+				"source":     request.Source,
 			}).Info("Processing request")
 
-			return next(ctx, request)
-		}
-	}
-}
-
-// TracingMiddleware adds detailed tracing information.
-func (h *Handler) TracingMiddleware() MiddlewareFunc {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
-			// Add request details to tracing
-			requestData := map[string]interface{}{
-				"headers":         request.Headers,
-				"query_parameters": request.QueryStringParameters,
-				"path_parameters":  request.PathParameters,
-			}
-			if request.Body != "" {
-				requestData["body_size"] = len(request.Body)
+			if request.Body != "" && h.logger.Core().Enabled(zap.DebugLevel) {
+				if mediaType, err := requestMediaType(request); err == nil && mediaType.IsJSON() {
+					if body, err := requestBody(request); err == nil {
+						h.logger.WithFields(map[string]interface{}{
+							"body": string(h.redactor.RedactJSON(body)),
+						}).Debug("Request body")
+					}
+				}
 			}
-			h.tracer.AddMetadata(ctx, "request", requestData)
 
 			return next(ctx, request)
 		}
 	}
 }
 
-// TracingMiddlewareV2 adds detailed tracing information for v2 HTTP API.
-func (h *Handler) TracingMiddlewareV2() MiddlewareFuncV2 {
-	return func(next HandlerFuncV2) HandlerFuncV2 {
-		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
+// Tracing adds detailed tracing information, with headers and parameters redacted.
+func (h *Handler) Tracing() Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
 			// Add request details to tracing
 			requestData := map[string]interface{}{
-				"headers":         request.Headers,
-				"query_parameters": request.QueryStringParameters,
-				"path_parameters":  request.PathParameters,
+				"headers":          h.redactor.RedactValues(request.CanonicalHeaders()),
+				"query_parameters": h.redactor.RedactValues(request.Query()),
+				"path_parameters":  h.redactor.RedactMap(request.PathParameters),
 			}
 			if request.Body != "" {
 				requestData["body_size"] = len(request.Body)
@@ -340,65 +408,63 @@ func (h *Handler) TracingMiddlewareV2() MiddlewareFuncV2 {
 	}
 }
 
-// TimeoutMiddleware adds timeout handling to requests.
-func (h *Handler) TimeoutMiddleware() MiddlewareFunc {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
-			// Create context with timeout
-			timeoutCtx, cancel := context.WithTimeout(ctx, h.config.ResponseTimeout)
-			defer cancel()
-
-			// Channel to receive result
-			resultChan := make(chan struct {
-				data interface{}
-				err  error
-			}, 1)
+// handlerResult carries the outcome of a handler run on a separate goroutine.
+type handlerResult struct {
+	data interface{}
+	err  error
+}
 
-			// Execute handler in goroutine
-			go func() {
-				data, err := next(timeoutCtx, request)
-				resultChan <- struct {
-					data interface{}
-					err  error
-				}{data, err}
-			}()
+// Handler goroutine states used by Timeout to decide who owns the result.
+const (
+	handlerRunning int32 = iota
+	handlerFinished
+	handlerAbandoned
+)
 
-			// Wait for result or timeout
-			select {
-			case result := <-resultChan:
-				return result.data, result.err
-			case <-timeoutCtx.Done():
-				h.tracer.AddAnnotation(ctx, "timeout", true)
-				return nil, &TimeoutError{
-					Message: fmt.Sprintf("Request timeout after %v", h.config.ResponseTimeout),
-					Timeout: h.config.ResponseTimeout,
-				}
+// Timeout bounds how long the handler may run. The limit is the configured response timeout,
+// capped by the invocation deadline minus TimeoutSafetyMargin so logs and traces can still be
+// flushed. Requests left with less than MinRequestBudget are shed with a ServiceUnavailableError
+// before the handler starts; handlers exceeding the limit produce a TimeoutError. A handler that
+// times out keeps running in the background; it is counted by AbandonedHandlers and logged when
+// it finally returns.
+func (h *Handler) Timeout() Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			timeout := h.requestTimeout(ctx)
+			if timeout < h.config.MinRequestBudget {
+				h.tracer.AddAnnotation(ctx, "load_shed", true)
+				h.logger.WithFields(map[string]interface{}{
+					"budget_ms":     timeout.Milliseconds(),
+					"min_budget_ms": h.config.MinRequestBudget.Milliseconds(),
+				}).WithContext(ctx).Warn("Shedding request: not enough invocation time remaining")
+				return nil, NewServiceUnavailableError("Not enough time remaining to process the request", 0)
 			}
-		}
-	}
-}
 
-// TimeoutMiddlewareV2 adds timeout handling to v2 HTTP API requests.
-func (h *Handler) TimeoutMiddlewareV2() MiddlewareFuncV2 {
-	return func(next HandlerFuncV2) HandlerFuncV2 {
-		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
 			// Create context with timeout
-			timeoutCtx, cancel := context.WithTimeout(ctx, h.config.ResponseTimeout)
+			timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
 
 			// Channel to receive result
-			resultChan := make(chan struct {
-				data interface{}
-				err  error
-			}, 1)
+			resultChan := make(chan handlerResult, 1)
+			var state atomic.Int32
+			start := time.Now()
 
-			// Execute handler in goroutine
+			// Execute handler in goroutine. Panics must be recovered here because a
+			// recover in the caller cannot see them.
 			go func() {
+				var result handlerResult
+				defer func() {
+					if value := recover(); value != nil {
+						result = handlerResult{err: h.recoverPanic(timeoutCtx, value)}
+					}
+					if !state.CompareAndSwap(handlerRunning, handlerFinished) {
+						h.finishAbandoned(ctx, request, time.Since(start), result.err)
+					}
+					resultChan <- result
+				}()
+
 				data, err := next(timeoutCtx, request)
-				resultChan <- struct {
-					data interface{}
-					err  error
-				}{data, err}
+				result = handlerResult{data: data, err: err}
 			}()
 
 			// Wait for result or timeout
@@ -406,63 +472,94 @@ func (h *Handler) TimeoutMiddlewareV2() MiddlewareFuncV2 {
 			case result := <-resultChan:
 				return result.data, result.err
 			case <-timeoutCtx.Done():
-				h.tracer.AddAnnotation(ctx, "timeout", true)
-				return nil, &TimeoutError{
-					Message: fmt.Sprintf("Request timeout after %v", h.config.ResponseTimeout),
-					Timeout: h.config.ResponseTimeout,
+				// The handler may have finished at the same moment; its result wins
+				if !state.CompareAndSwap(handlerRunning, handlerAbandoned) {
+					result := <-resultChan
+					return result.data, result.err
 				}
+
+				abandoned := h.abandoned.Add(1)
+				h.tracer.AddAnnotation(ctx, "timeout", true)
+				h.logger.WithFields(map[string]interface{}{
+					"method":             request.Method,
+					"path":               request.Path,
+					"timeout_ms":         timeout.Milliseconds(),
+					"abandoned_handlers": abandoned,
+				}).WithContext(ctx).Warn("Handler timed out and was abandoned")
+
+				return nil, NewTimeoutError(fmt.Sprintf("Request timeout after %v", timeout), timeout)
 			}
 		}
 	}
 }
 
-// ValidationMiddleware validates common request parameters.
-func (h *Handler) ValidationMiddleware() MiddlewareFunc {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
-			// Validate HTTP method
-			allowedMethods := map[string]bool{
-				"GET":     true,
-				"POST":    true,
-				"PUT":     true,
-				"DELETE":  true,
-				"PATCH":   true,
-				"OPTIONS": true,
-			}
+// requestTimeout returns how long a handler may run: the configured response timeout, capped
+// by the context deadline (the Lambda invocation deadline) minus the safety margin.
+func (h *Handler) requestTimeout(ctx context.Context) time.Duration {
+	timeout := h.config.ResponseTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) - h.config.TimeoutSafetyMargin; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	return timeout
+}
 
-			if !allowedMethods[request.HTTPMethod] {
-				return nil, &ValidationError{
-					Message: fmt.Sprintf("HTTP method %s is not allowed", request.HTTPMethod),
-					Field:   "httpMethod",
-					Value:   request.HTTPMethod,
-				}
-			}
+// finishAbandoned records an abandoned handler returning after its request timed out.
+func (h *Handler) finishAbandoned(ctx context.Context, request *Request, elapsed time.Duration, err error) {
+	remaining := h.abandoned.Add(-1)
 
-			// Validate content type for POST/PUT/PATCH requests with body
-			if (request.HTTPMethod == "POST" || request.HTTPMethod == "PUT" || request.HTTPMethod == "PATCH") && request.Body != "" {
-				contentType := request.Headers["Content-Type"]
-				if contentType == "" {
-					contentType = request.Headers["content-type"] // Try lowercase
-				}
+	fields := map[string]interface{}{
+		"method":             request.Method,
+		"path":               request.Path,
+		"elapsed_ms":         elapsed.Milliseconds(),
+		"abandoned_handlers": remaining,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	h.logger.WithFields(fields).WithContext(ctx).Warn("Abandoned handler finished after timeout")
+}
 
-				if contentType != "application/json" {
-					return nil, &ValidationError{
-						Message: "Content-Type must be application/json for requests with body",
-						Field:   "content-type",
-						Value:   contentType,
-					}
+// AbandonedHandlers returns the number of handlers that timed out and are still running.
+// Lambda freezes the execution environment between invocations, so these may resume
+// during later requests.
+func (h *Handler) AbandonedHandlers() int64 {
+	return h.abandoned.Load()
+}
+
+// Recovery converts panics in later middleware and handlers into an InternalError, logging
+// the stack trace and marking the trace segment as a fault. Every entrypoint already applies
+// it around the whole chain; add it explicitly only to recover closer to the handler.
+func (h *Handler) Recovery() Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (data interface{}, err error) {
+			defer func() {
+				if value := recover(); value != nil {
+					data, err = nil, h.recoverPanic(ctx, value)
 				}
-			}
+			}()
 
 			return next(ctx, request)
 		}
 	}
 }
 
-// ValidationMiddlewareV2 validates common request parameters for v2 HTTP API.
-func (h *Handler) ValidationMiddlewareV2() MiddlewareFuncV2 {
-	return func(next HandlerFuncV2) HandlerFuncV2 {
-		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
+// recoverPanic records a recovered panic and converts it into an InternalError.
+// It must be called from the deferred function that recovered the panic.
+func (h *Handler) recoverPanic(ctx context.Context, value interface{}) error {
+	stack := debug.Stack()
+	h.logger.LogPanic(ctx, value, stack)
+	h.tracer.AddPanic(ctx, value)
+	h.tracer.AddAnnotation(ctx, "panic", true)
+
+	return NewInternalErrorWithOperation("handler", "recovered from panic", NewPanicError(value, stack))
+}
+
+// Validation validates common request parameters.
+func (h *Handler) Validation() Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
 			// Validate HTTP method
 			allowedMethods := map[string]bool{
 				"GET":     true,
@@ -473,28 +570,18 @@ func (h *Handler) ValidationMiddlewareV2() MiddlewareFuncV2 {
 				"OPTIONS": true,
 			}
 
-			httpMethod := request.RequestContext.HTTP.Method
-			if !allowedMethods[httpMethod] {
+			if !allowedMethods[request.Method] {
 				return nil, &ValidationError{
-					Message: fmt.Sprintf("HTTP method %s is not allowed", httpMethod),
+					Message: fmt.Sprintf("HTTP method %s is not allowed", request.Method),
 					Field:   "httpMethod",
-					Value:   httpMethod,
+					Value:   request.Method,
 				}
 			}
 
-			// Validate content type for POST/PUT/PATCH requests with body
-			if (httpMethod == "POST" || httpMethod == "PUT" || httpMethod == "PATCH") && request.Body != "" {
-				contentType := request.Headers["Content-Type"]
-				if contentType == "" {
-					contentType = request.Headers["content-type"] // Try lowercase
-				}
-
-				if contentType != "application/json" {
-					return nil, &ValidationError{
-						Message: "Content-Type must be application/json for requests with body",
-						Field:   "content-type",
-						Value:   contentType,
-					}
+			// Bodies of POST/PUT/PATCH requests must be in a media type the binding layer decodes
+			if (request.Method == "POST" || request.Method == "PUT" || request.Method == "PATCH") && request.Body != "" {
+				if _, err := checkContentType(request, bindableContentTypes); err != nil {
+					return nil, err
 				}
 			}
 
@@ -503,12 +590,13 @@ func (h *Handler) ValidationMiddlewareV2() MiddlewareFuncV2 {
 	}
 }
 
-// JSONParsingMiddleware parses JSON request bodies.
-func (h *Handler) JSONParsingMiddleware() MiddlewareFunc {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
+// JSONParsing parses JSON request bodies. Bodies declared as another media type, such as
+// forms, are left to the handler.
+func (h *Handler) JSONParsing() Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
 			// Add parsed body to context if it's JSON
-			if request.Body != "" {
+			if mediaType, err := requestMediaType(request); request.Body != "" && err == nil && mediaType.IsJSON() {
 				var parsedBody interface{}
 				if err := json.Unmarshal([]byte(request.Body), &parsedBody); err != nil {
 					return nil, &ValidationError{
@@ -527,12 +615,142 @@ func (h *Handler) JSONParsingMiddleware() MiddlewareFunc {
 	}
 }
 
+// LoggingMiddleware adds request/response logging.
+func (h *Handler) LoggingMiddleware() MiddlewareFunc {
+	return h.Logging().V1()
+}
+
+// LoggingMiddlewareV2 adds request/response logging for v2 HTTP API.
+func (h *Handler) LoggingMiddlewareV2() MiddlewareFuncV2 {
+	return h.Logging().V2()
+}
+
+// TracingMiddleware adds detailed tracing information.
+func (h *Handler) TracingMiddleware() MiddlewareFunc {
+	return h.Tracing().V1()
+}
+
+// TracingMiddlewareV2 adds detailed tracing information for v2 HTTP API.
+func (h *Handler) TracingMiddlewareV2() MiddlewareFuncV2 {
+	return h.Tracing().V2()
+}
+
+// TimeoutMiddleware adds timeout handling to requests.
+func (h *Handler) TimeoutMiddleware() MiddlewareFunc {
+	return h.Timeout().V1()
+}
+
+// TimeoutMiddlewareV2 adds timeout handling to v2 HTTP API requests.
+func (h *Handler) TimeoutMiddlewareV2() MiddlewareFuncV2 {
+	return h.Timeout().V2()
+}
+
+// RecoveryMiddleware converts panics into internal errors.
+func (h *Handler) RecoveryMiddleware() MiddlewareFunc {
+	return h.Recovery().V1()
+}
+
+// RecoveryMiddlewareV2 converts panics into internal errors for v2 HTTP API.
+func (h *Handler) RecoveryMiddlewareV2() MiddlewareFuncV2 {
+	return h.Recovery().V2()
+}
+
+// ValidationMiddleware validates common request parameters.
+func (h *Handler) ValidationMiddleware() MiddlewareFunc {
+	return h.Validation().V1()
+}
+
+// ValidationMiddlewareV2 validates common request parameters for v2 HTTP API.
+func (h *Handler) ValidationMiddlewareV2() MiddlewareFuncV2 {
+	return h.Validation().V2()
+}
+
+// JSONParsingMiddleware parses JSON request bodies.
+func (h *Handler) JSONParsingMiddleware() MiddlewareFunc {
+	return h.JSONParsing().V1()
+}
+
+// JSONParsingMiddlewareV2 parses JSON request bodies for v2 HTTP API.
+func (h *Handler) JSONParsingMiddlewareV2() MiddlewareFuncV2 {
+	return h.JSONParsing().V2()
+}
+
 // GetParsedBody retrieves the parsed JSON body from context.
 func GetParsedBody(ctx context.Context) (interface{}, bool) {
 	body := ctx.Value(contextKeyParsedBody)
 	return body, body != nil
 }
 
+// responseHeaders holds the headers and cookies set through SetResponseHeader,
+// AddResponseHeader and SetResponseCookie for one request. It is locked because handlers
+// abandoned by Timeout may still set headers.
+type responseHeaders struct {
+	mu          sync.Mutex
+	values      map[string]string
+	multiValues map[string][]string
+	cookies     []*http.Cookie
+}
+
+// apply adds the collected headers and cookies to responseBuilder.
+func (rh *responseHeaders) apply(responseBuilder *http.ResponseBuilder) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+
+	responseBuilder.WithHeaders(rh.values)
+	for key, values := range rh.multiValues {
+		for _, value := range values {
+			responseBuilder.AddHeader(key, value)
+		}
+	}
+	for _, cookie := range rh.cookies {
+		responseBuilder.WithCookie(cookie)
+	}
+}
+
+// SetResponseHeader sets a header on the response to the current request, whether the
+// handler succeeds or fails. Headers set by error mappings, such as Retry-After, take
+// precedence. It does nothing for contexts not created by a Handler entrypoint.
+func SetResponseHeader(ctx context.Context, key, value string) {
+	headers, ok := ctx.Value(contextKeyResponseHeaders).(*responseHeaders)
+	if !ok {
+		return
+	}
+
+	headers.mu.Lock()
+	defer headers.mu.Unlock()
+	headers.values[key] = value
+}
+
+// AddResponseHeader adds a value to a header of the response to the current request,
+// keeping the values added before, like SetResponseHeader does for single values.
+func AddResponseHeader(ctx context.Context, key, value string) {
+	headers, ok := ctx.Value(contextKeyResponseHeaders).(*responseHeaders)
+	if !ok {
+		return
+	}
+
+	headers.mu.Lock()
+	defer headers.mu.Unlock()
+	if headers.multiValues == nil {
+		headers.multiValues = make(map[string][]string)
+	}
+	headers.multiValues[key] = append(headers.multiValues[key], value)
+}
+
+// SetResponseCookie sets a cookie on the client through the response to the current
+// request, whether the handler succeeds or fails. Each trigger sends cookies its own way:
+// multiValueHeaders for REST APIs and the cookies field for HTTP APIs and Function URLs.
+func SetResponseCookie(ctx context.Context, cookie *http.Cookie) {
+	headers, ok := ctx.Value(contextKeyResponseHeaders).(*responseHeaders)
+	if !ok {
+		return
+	}
+
+	headers.mu.Lock()
+	defer headers.mu.Unlock()
+	headers.cookies = append(headers.cookies, cookie)
+}
+
 // GetRequestID retrieves the request ID from Lambda context.
 func GetRequestID(ctx context.Context) string {
 	if lc, ok := lambdacontext.FromContext(ctx); ok {
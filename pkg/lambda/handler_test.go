@@ -0,0 +1,506 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	return NewHandler(testutil.TestConfig(), testutil.TestLogger(t), testutil.TestTracer())
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return func(next RequestHandlerFunc) RequestHandlerFunc {
+			return func(ctx context.Context, request *Request) (interface{}, error) {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+
+	handler := Chain(func(ctx context.Context, request *Request) (interface{}, error) {
+		order = append(order, "handler")
+		return nil, nil
+	}, record("first"), record("second"))
+
+	_, err := handler(context.Background(), &Request{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "handler"}, order)
+}
+
+func TestHandler_WrapAcrossTriggers(t *testing.T) {
+	h := newTestHandler(t)
+	ctx := testutil.CreateTestContext("test-request")
+
+	var seen []EventSource
+	business := func(ctx context.Context, request *Request) (interface{}, error) {
+		seen = append(seen, request.Source)
+		if request.PathParameters["id"] == "missing" {
+			return nil, NewNotFoundError("user not found")
+		}
+		return map[string]string{"path": request.Path}, nil
+	}
+
+	t.Run("API Gateway REST", func(t *testing.T) {
+		wrapped := h.WrapAPIGateway(business, h.Validation(), h.Logging())
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayRequest("GET", "/users"))
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		testutil.AssertSuccessResponse(t, response.Body, nil)
+	})
+
+	t.Run("API Gateway HTTP API", func(t *testing.T) {
+		wrapped := h.WrapHTTPAPI(business, h.Validation(), h.Logging())
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/missing", map[string]string{
+			"id": "missing",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, 404, response.StatusCode)
+		testutil.AssertErrorResponse(t, response.Body, "user not found")
+	})
+
+	t.Run("ALB", func(t *testing.T) {
+		wrapped := h.WrapALB(business, h.Validation(), h.Logging())
+		response, err := wrapped(ctx, events.ALBTargetGroupRequest{
+			HTTPMethod:        "GET",
+			Path:              "/users",
+			MultiValueHeaders: map[string][]string{"user-agent": {"test-agent/1.0"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, "200 OK", response.StatusDescription)
+		assert.Equal(t, []string{"application/json"}, response.MultiValueHeaders["Content-Type"])
+		testutil.AssertSuccessResponse(t, response.Body, map[string]string{"path": "/users"})
+	})
+
+	t.Run("Function URL", func(t *testing.T) {
+		wrapped := h.WrapFunctionURL(business, h.Validation(), h.Logging())
+		response, err := wrapped(ctx, events.LambdaFunctionURLRequest{
+			RawPath: "/hello",
+			RequestContext: events.LambdaFunctionURLRequestContext{
+				HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, "test-request", response.Headers["X-Request-ID"])
+		testutil.AssertSuccessResponse(t, response.Body, map[string]string{"path": "/hello"})
+	})
+
+	assert.Equal(t, []EventSource{EventSourceAPIGateway, EventSourceHTTPAPI, EventSourceALB, EventSourceFunctionURL}, seen)
+}
+
+func TestMiddleware_LegacyAdapters(t *testing.T) {
+	h := newTestHandler(t)
+	ctx := testutil.CreateTestContext("test-request")
+
+	t.Run("V1", func(t *testing.T) {
+		wrapped := h.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
+			return "ok", nil
+		}, h.ValidationMiddleware())
+
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayRequest("TRACE", "/hello"))
+		require.NoError(t, err)
+		assert.Equal(t, 400, response.StatusCode)
+		testutil.AssertErrorResponse(t, response.Body, "HTTP method TRACE is not allowed")
+	})
+
+	t.Run("V2", func(t *testing.T) {
+		wrapped := h.WrapV2(func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
+			body, ok := GetParsedBody(ctx)
+			require.True(t, ok)
+			return body, nil
+		}, h.JSONParsingMiddlewareV2())
+
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayV2RequestWithBody("POST", "/users", map[string]string{
+			"name": "test",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, "test", body.Data["name"])
+	})
+}
+
+func TestHandler_Validation(t *testing.T) {
+	h := newTestHandler(t)
+	handler := Chain(func(ctx context.Context, request *Request) (interface{}, error) {
+		return "ok", nil
+	}, h.Validation())
+
+	tests := []struct {
+		name        string
+		request     *Request
+		expectError func(error) bool
+	}{
+		{
+			name:    "GET without body",
+			request: &Request{Method: "GET"},
+		},
+		{
+			name:    "POST with lowercase content type header",
+			request: &Request{Method: "POST", Body: "{}", Headers: map[string]string{"content-type": "application/json"}},
+		},
+		{
+			name:    "POST with charset parameter",
+			request: &Request{Method: "POST", Body: "{}", Headers: map[string]string{"Content-Type": "application/json; charset=UTF-8"}},
+		},
+		{
+			name:    "PATCH with +json media type",
+			request: &Request{Method: "PATCH", Body: "{}", Headers: map[string]string{"Content-Type": "application/merge-patch+json"}},
+		},
+		{
+			name:    "POST with form body",
+			request: &Request{Method: "POST", Body: "name=Ada", Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}},
+		},
+		{
+			name:        "POST with wrong content type",
+			request:     &Request{Method: "POST", Body: "{}", Headers: map[string]string{"Content-Type": "text/plain"}},
+			expectError: IsUnsupportedMediaTypeError,
+		},
+		{
+			name:        "POST without content type",
+			request:     &Request{Method: "POST", Body: "{}"},
+			expectError: IsUnsupportedMediaTypeError,
+		},
+		{
+			name:        "POST with JSON in another charset",
+			request:     &Request{Method: "POST", Body: "{}", Headers: map[string]string{"Content-Type": "application/json; charset=iso-8859-1"}},
+			expectError: IsUnsupportedMediaTypeError,
+		},
+		{
+			name:        "unsupported method",
+			request:     &Request{Method: "TRACE"},
+			expectError: IsValidationError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler(context.Background(), tt.request)
+			if tt.expectError != nil {
+				assert.True(t, tt.expectError(err), "unexpected error %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHandler_ErrorFormats(t *testing.T) {
+	business := func(ctx context.Context, request *Request) (interface{}, error) {
+		return nil, fmt.Errorf("loading user: %w", NewNotFoundError("user not found"))
+	}
+	ctx := testutil.CreateTestContext("test-request")
+
+	tests := []struct {
+		name                string
+		errorFormat         string
+		accept              string
+		expectedContentType string
+	}{
+		{name: "default", expectedContentType: "application/json"},
+		{name: "accept problem", accept: "application/problem+json", expectedContentType: "application/problem+json"},
+		{name: "configured problem", errorFormat: "problem", expectedContentType: "application/problem+json"},
+		{name: "accept overrides config", errorFormat: "problem", accept: "application/json", expectedContentType: "application/json"},
+		{name: "refused problem", accept: "application/problem+json;q=0", expectedContentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.ErrorFormat = tt.errorFormat
+			cfg.ProblemTypeBaseURL = "https://errors.example.com/"
+			h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+			request := testutil.CreateTestAPIGatewayV2Request("GET", "/users/7")
+			if tt.accept != "" {
+				request.Headers["accept"] = tt.accept
+			}
+
+			response, err := h.WrapHTTPAPI(business)(ctx, request)
+			require.NoError(t, err)
+			assert.Equal(t, 404, response.StatusCode)
+			assert.Equal(t, tt.expectedContentType, response.Headers["Content-Type"])
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+			assert.Equal(t, ErrorCodeNotFound, body["code"])
+
+			if tt.expectedContentType == "application/problem+json" {
+				assert.Equal(t, "https://errors.example.com/not-found", body["type"])
+				assert.Equal(t, "Not Found", body["title"])
+				assert.Equal(t, float64(404), body["status"])
+				assert.Equal(t, "user not found", body["detail"])
+				assert.Equal(t, "/users/7", body["instance"])
+			} else {
+				assert.Equal(t, "user not found", body["message"])
+			}
+		})
+	}
+}
+
+func TestHandler_ErrorPathKeepsStage(t *testing.T) {
+	request := testutil.CreateTestAPIGatewayV2Request("GET", "/prod/users/7")
+	request.RequestContext.Stage = "prod"
+
+	response, err := newTestHandler(t).WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+		assert.Equal(t, "/users/7", request.Path)
+		return nil, NewNotFoundError("user not found")
+	})(testutil.CreateTestContext("test-request"), request)
+	require.NoError(t, err)
+
+	var body http.ErrorResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "/prod/users/7", body.Path)
+}
+
+func TestHandler_RecoversPanics(t *testing.T) {
+	ctx := testutil.CreateTestContext("test-request")
+	panicking := func(ctx context.Context, request *Request) (interface{}, error) {
+		var user *struct{ Name string }
+		return user.Name, nil
+	}
+
+	assertInternalError := func(t *testing.T, statusCode int, body string) {
+		assert.Equal(t, 500, statusCode)
+		var response http.ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &response))
+		assert.Equal(t, "Internal server error", response.Message)
+		assert.Equal(t, ErrorCodeInternal, response.Code)
+		assert.Equal(t, "test-request", response.RequestID)
+	}
+
+	t.Run("handler", func(t *testing.T) {
+		h := newTestHandler(t)
+		response, err := h.WrapHTTPAPI(panicking, h.Logging())(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assertInternalError(t, response.StatusCode, response.Body)
+	})
+
+	t.Run("inside timeout goroutine", func(t *testing.T) {
+		h := newTestHandler(t)
+		response, err := h.WrapHTTPAPI(panicking, h.Timeout())(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assertInternalError(t, response.StatusCode, response.Body)
+	})
+
+	t.Run("legacy entrypoint", func(t *testing.T) {
+		h := newTestHandler(t)
+		wrapped := h.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (interface{}, error) {
+			panic("boom")
+		}, h.TimeoutMiddleware())
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayRequest("GET", "/users"))
+		require.NoError(t, err)
+		assertInternalError(t, response.StatusCode, response.Body)
+	})
+
+	t.Run("panic value does not pick the status", func(t *testing.T) {
+		h := newTestHandler(t)
+		response, err := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			panic(NewNotFoundError("user not found"))
+		})(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assertInternalError(t, response.StatusCode, response.Body)
+	})
+}
+
+func TestHandler_Recovery(t *testing.T) {
+	h := newTestHandler(t)
+	handler := h.Recovery()(func(ctx context.Context, request *Request) (interface{}, error) {
+		panic("boom")
+	})
+
+	data, err := handler(context.Background(), &Request{})
+	assert.Nil(t, data)
+	require.Error(t, err)
+	assert.True(t, IsInternalError(err))
+	assert.True(t, IsPanicError(err))
+
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "TestHandler_Recovery")
+}
+
+func TestHandler_Timeout(t *testing.T) {
+	t.Run("completes within budget", func(t *testing.T) {
+		h := newTestHandler(t)
+		handler := h.Timeout()(func(ctx context.Context, request *Request) (interface{}, error) {
+			return "ok", nil
+		})
+
+		data, err := handler(context.Background(), &Request{})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", data)
+	})
+
+	t.Run("configured timeout without deadline", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.ResponseTimeout = 20 * time.Millisecond
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		release := make(chan struct{})
+		defer close(release)
+		handler := h.Timeout()(func(ctx context.Context, request *Request) (interface{}, error) {
+			<-release
+			return nil, nil
+		})
+
+		_, err := handler(context.Background(), &Request{})
+		var timeoutErr *TimeoutError
+		require.True(t, errors.As(err, &timeoutErr))
+		assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+	})
+
+	t.Run("deadline minus safety margin", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.TimeoutSafetyMargin = 50 * time.Millisecond
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		release := make(chan struct{})
+		handler := h.Timeout()(func(ctx context.Context, request *Request) (interface{}, error) {
+			<-release
+			return nil, errors.New("finished late")
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+		defer cancel()
+
+		_, err := handler(ctx, &Request{Method: "GET", Path: "/slow"})
+		var timeoutErr *TimeoutError
+		require.True(t, errors.As(err, &timeoutErr))
+		assert.LessOrEqual(t, timeoutErr.Timeout, 100*time.Millisecond)
+		require.NoError(t, ctx.Err(), "handler must give up before the invocation deadline")
+
+		// The abandoned handler is tracked until it returns
+		assert.Equal(t, int64(1), h.AbandonedHandlers())
+		close(release)
+		assert.Eventually(t, func() bool { return h.AbandonedHandlers() == 0 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("sheds requests without enough budget", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.TimeoutSafetyMargin = 500 * time.Millisecond
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		called := false
+		wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			called = true
+			return nil, nil
+		}, h.Timeout())
+
+		ctx, cancel := context.WithTimeout(testutil.CreateTestContext("test-request"), 200*time.Millisecond)
+		defer cancel()
+
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assert.False(t, called)
+		assert.Equal(t, 503, response.StatusCode)
+		assert.Equal(t, "1", response.Headers["Retry-After"])
+
+		var body http.ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, ErrorCodeUnavailable, body.Code)
+	})
+
+	t.Run("timeout maps to 504", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.ResponseTimeout = 10 * time.Millisecond
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer())
+
+		release := make(chan struct{})
+		defer close(release)
+		wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			<-release
+			return nil, nil
+		}, h.Timeout())
+
+		response, err := wrapped(testutil.CreateTestContext("test-request"), testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assert.Equal(t, 504, response.StatusCode)
+	})
+}
+
+func TestHandler_CookiesAndBinaryBodies(t *testing.T) {
+	h := newTestHandler(t)
+	ctx := testutil.CreateTestContext("test-request")
+
+	setCookies := func(ctx context.Context, request *Request) (interface{}, error) {
+		SetResponseCookie(ctx, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true, Secure: true, SameSite: http.SameSiteStrictMode})
+		SetResponseCookie(ctx, &http.Cookie{Name: "theme", Value: "dark", MaxAge: 3600})
+		AddResponseHeader(ctx, "Link", `</users?page=2>; rel="next"`)
+		AddResponseHeader(ctx, "Link", `</users?page=9>; rel="last"`)
+		return map[string]string{"status": "ok"}, nil
+	}
+	expectedCookies := []string{
+		"session=abc; Path=/; HttpOnly; Secure; SameSite=Strict",
+		"theme=dark; Max-Age=3600",
+	}
+
+	t.Run("HTTP API returns cookies separately", func(t *testing.T) {
+		response, err := h.WrapHTTPAPI(setCookies)(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, expectedCookies, response.Cookies)
+		assert.Equal(t, `</users?page=2>; rel="next", </users?page=9>; rel="last"`, response.Headers["Link"])
+	})
+
+	t.Run("REST API returns cookies as multi-value headers", func(t *testing.T) {
+		response, err := h.WrapAPIGateway(setCookies)(ctx, testutil.CreateTestAPIGatewayRequest("GET", "/users"))
+		require.NoError(t, err)
+
+		assert.Equal(t, expectedCookies, response.MultiValueHeaders["Set-Cookie"])
+		assert.Equal(t, []string{`</users?page=2>; rel="next"`, `</users?page=9>; rel="last"`}, response.MultiValueHeaders["Link"])
+		assert.Nil(t, response.Cookies)
+	})
+
+	t.Run("cookies are set on error responses", func(t *testing.T) {
+		response, err := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			SetResponseCookie(ctx, &http.Cookie{Name: "session", Value: "", MaxAge: -1})
+			return nil, NewUnauthorizedError("Session expired")
+		})(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+
+		assert.Equal(t, 401, response.StatusCode)
+		assert.Equal(t, []string{"session=; Max-Age=0"}, response.Cookies)
+	})
+
+	t.Run("WrapV2 sends binary bodies base64-encoded", func(t *testing.T) {
+		csv := []byte("id,name\n1,Ada\n")
+		response, err := h.WrapV2(func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (interface{}, error) {
+			return http.NewResponseBuilder().
+				WithHeader("Content-Disposition", `attachment; filename="users.csv"`).
+				WithCookie(&http.Cookie{Name: "export", Value: "done"}).
+				Binary(200, "text/csv", csv), nil
+		})(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users/export"))
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.True(t, response.IsBase64Encoded)
+		assert.Equal(t, "text/csv", response.Headers["Content-Type"])
+		assert.Equal(t, []string{"export=done"}, response.Cookies)
+
+		body, err := (http.Response{Body: response.Body, IsBase64Encoded: response.IsBase64Encoded}).BodyBytes()
+		require.NoError(t, err)
+		assert.Equal(t, csv, body)
+	})
+}
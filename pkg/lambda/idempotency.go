@@ -0,0 +1,253 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sync"
+	"time"
+
+	"lambda-go-template/pkg/http"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyStatus is the state of an idempotency record.
+type IdempotencyStatus string
+
+// Idempotency record states.
+const (
+	IdempotencyInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyRecord is what an IdempotencyStore keeps for one idempotency key.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Status      IdempotencyStatus
+	Response    *http.Response
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore persists idempotency records.
+//
+// A store backed by DynamoDB implements Start as a PutItem conditioned on
+// attribute_not_exists of the key, reading the existing item when the condition fails,
+// and maps ExpiresAt onto the table's TTL attribute. Expired records must be treated as
+// absent, since DynamoDB deletes expired items lazily.
+type IdempotencyStore interface {
+	// Start saves record unless an unexpired record exists for its key, in which case it
+	// returns the existing record and saves nothing.
+	Start(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete replaces the record for its key.
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	// Delete removes the record for key so the request can be retried.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory. Records are per container,
+// so it suits tests and functions with a single concurrent execution environment.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+	now     func() time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]IdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+// Start implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Start(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.Key]; ok && s.now().Before(existing.ExpiresAt) {
+		return &existing, nil
+	}
+
+	s.records[record.Key] = record
+	return nil, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.Key] = record
+	return nil
+}
+
+// Delete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// isMutatingMethod reports whether requests with method change server state.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
+
+// requestFingerprint identifies the payload of a request so a reused idempotency key can
+// be told apart from a retry.
+func requestFingerprint(request *Request) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		request.Method,
+		request.Path,
+		url.Values(request.Query()).Encode(),
+		request.Header("Content-Type"),
+		request.Body,
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyStoreKey scopes a client's idempotency key to the caller and the operation,
+// so callers choosing the same key neither conflict with nor replay each other's requests.
+func idempotencyStoreKey(ctx context.Context, request *Request, key string) string {
+	hash := sha256.New()
+	for _, part := range []string{idempotencyPrincipal(ctx, request), request.Method, request.Path} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32] + ":" + key
+}
+
+// idempotencyPrincipal identifies the caller: the principal named by a Lambda authorizer,
+// else the verified token subject, else the API key. Anonymous callers share one scope.
+func idempotencyPrincipal(ctx context.Context, request *Request) string {
+	if authorizerContext, ok := request.AuthorizerContext(); ok && authorizerContext.Principal() != "" {
+		return "principal:" + authorizerContext.Principal()
+	}
+	if subject, ok := KeyByJWTSubject(ctx, request); ok {
+		return subject
+	}
+	if apiKey, ok := KeyByAPIKey(ctx, request); ok {
+		return apiKey
+	}
+	return ""
+}
+
+// Idempotency makes mutating requests carrying an Idempotency-Key header safe to retry.
+//
+// The first request with a key runs normally and its response is saved for ttl; retries
+// with the same key and payload get the saved response replayed with an Idempotent-Replayed
+// header, and with the request ID and response headers of the retry itself. Reusing a key
+// with a different payload, or while the first request is still running, fails with a
+// ConflictError (409). Keys are scoped to the caller, method and path, and the payload
+// covers the query string, Content-Type and body. Server errors are not saved, so the
+// client can retry them. If the store fails, requests run without idempotency and the
+// failure is logged.
+func (h *Handler) Idempotency(store IdempotencyStore, ttl time.Duration) Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			key := request.Header(IdempotencyKeyHeader)
+			if key == "" || !isMutatingMethod(request.Method) {
+				return next(ctx, request)
+			}
+
+			fingerprint := requestFingerprint(request)
+			storeKey := idempotencyStoreKey(ctx, request, key)
+			h.tracer.AddAnnotation(ctx, "idempotency_key", key)
+
+			// The in-progress record expires with the invocation, so a crashed attempt
+			// does not block retries for the whole ttl
+			lockExpiry := time.Now().Add(ttl)
+			if deadline, ok := ctx.Deadline(); ok && deadline.Before(lockExpiry) {
+				lockExpiry = deadline
+			}
+
+			existing, err := store.Start(ctx, IdempotencyRecord{
+				Key:         storeKey,
+				Fingerprint: fingerprint,
+				Status:      IdempotencyInProgress,
+				ExpiresAt:   lockExpiry,
+			})
+			if err != nil {
+				h.logIdempotencyStoreError(ctx, key, err)
+				return next(ctx, request)
+			}
+
+			if existing != nil {
+				return h.replay(ctx, existing, fingerprint)
+			}
+
+			data, err := next(ctx, request)
+			response := h.render(ctx, request, data, err)
+
+			if response.StatusCode >= 500 {
+				if deleteErr := store.Delete(ctx, storeKey); deleteErr != nil {
+					h.logIdempotencyStoreError(ctx, key, deleteErr)
+				}
+				return data, err
+			}
+
+			completeErr := store.Complete(ctx, IdempotencyRecord{
+				Key:         storeKey,
+				Fingerprint: fingerprint,
+				Status:      IdempotencyCompleted,
+				Response:    &response,
+				ExpiresAt:   time.Now().Add(ttl),
+			})
+			if completeErr != nil {
+				h.logIdempotencyStoreError(ctx, key, completeErr)
+			}
+
+			return data, err
+		}
+	}
+}
+
+// replay answers a request whose idempotency key already has a record.
+func (h *Handler) replay(ctx context.Context, record *IdempotencyRecord, fingerprint string) (interface{}, error) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		return nil, NewConflictError("Idempotency-Key has already been used with a different request")
+	case record.Status != IdempotencyCompleted || record.Response == nil:
+		return nil, NewConflictError("A request with this Idempotency-Key is still being processed")
+	}
+
+	h.tracer.AddAnnotation(ctx, "idempotent_replay", true)
+	h.tracer.AddAnnotation(ctx, "replayed_status", record.Response.StatusCode)
+	h.logger.WithFields(map[string]interface{}{
+		"idempotency_key": record.Key,
+		"status_code":     record.Response.StatusCode,
+	}).WithContext(ctx).Info("Replaying idempotent response")
+
+	SetResponseHeader(ctx, "Idempotent-Replayed", "true")
+	return replayedResponse{response: *record.Response}, nil
+}
+
+// replayedResponse is a saved response returned by Idempotency. render rebuilds it with
+// the current request's ID and response headers.
+type replayedResponse struct {
+	response http.Response
+}
+
+// logIdempotencyStoreError logs a failed idempotency store operation.
+func (h *Handler) logIdempotencyStoreError(ctx context.Context, key string, err error) {
+	h.logger.WithFields(map[string]interface{}{
+		"idempotency_key": key,
+		"error":           err.Error(),
+	}).WithContext(ctx).Error("Idempotency store failed")
+}
@@ -0,0 +1,242 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func idempotentRequest(key, body string) events.APIGatewayV2HTTPRequest {
+	request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("POST", "/users", map[string]string{"Idempotency-Key": key})
+	request.Body = body
+	return request
+}
+
+func TestHandler_Idempotency(t *testing.T) {
+	t.Run("replays completed response", func(t *testing.T) {
+		h := newTestHandler(t)
+		calls := 0
+		wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			calls++
+			return map[string]int{"id": calls}, nil
+		}, h.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+		first, err := wrapped(testutil.CreateTestContext("request-1"), idempotentRequest("key-1", `{"name":"Ann"}`))
+		require.NoError(t, err)
+		second, err := wrapped(testutil.CreateTestContext("request-2"), idempotentRequest("key-1", `{"name":"Ann"}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 200, second.StatusCode)
+		assert.Equal(t, first.Body, second.Body)
+		assert.Equal(t, "true", second.Headers["Idempotent-Replayed"])
+		assert.Empty(t, first.Headers["Idempotent-Replayed"])
+	})
+
+	t.Run("replays with the headers of the current request", func(t *testing.T) {
+		h := newTestHandler(t)
+		attempt := 0
+		countAttempts := func(next RequestHandlerFunc) RequestHandlerFunc {
+			return func(ctx context.Context, request *Request) (interface{}, error) {
+				attempt++
+				SetResponseHeader(ctx, "RateLimit-Remaining", strconv.Itoa(10-attempt))
+				SetResponseCookie(ctx, &http.Cookie{Name: "attempt", Value: strconv.Itoa(attempt)})
+				return next(ctx, request)
+			}
+		}
+		wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			SetResponseHeader(ctx, "Location", "/users/1")
+			return nil, NewValidationError("name is required", "name", nil)
+		}, countAttempts, h.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+		first, err := wrapped(testutil.CreateTestContext("request-1"), idempotentRequest("key-1", `{}`))
+		require.NoError(t, err)
+		second, err := wrapped(testutil.CreateTestContext("request-2"), idempotentRequest("key-1", `{}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, second.StatusCode)
+		assert.Equal(t, first.Body, second.Body)
+		assert.Equal(t, "request-1", first.Headers["X-Request-ID"])
+		assert.Equal(t, "request-2", second.Headers["X-Request-ID"])
+		assert.Equal(t, "8", second.Headers["RateLimit-Remaining"])
+		assert.Equal(t, "/users/1", second.Headers["Location"])
+		assert.Equal(t, "true", second.Headers["Idempotent-Replayed"])
+		assert.Equal(t, first.Headers["Content-Type"], second.Headers["Content-Type"])
+		assert.Equal(t, []string{"attempt=1", "attempt=2"}, second.Cookies)
+	})
+
+	t.Run("replays client errors", func(t *testing.T) {
+		h := newTestHandler(t)
+		calls := 0
+		wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			calls++
+			return nil, NewValidationError("name is required", "name", nil)
+		}, h.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+		for i := 0; i < 2; i++ {
+			response, err := wrapped(testutil.CreateTestContext("request"), idempotentRequest("key-1", `{}`))
+			require.NoError(t, err)
+			assert.Equal(t, 400, response.StatusCode)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not save server errors", func(t *testing.T) {
+		h := newTestHandler(t)
+		calls := 0
+		wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("database unavailable")
+			}
+			return "created", nil
+		}, h.Idempotency(NewMemoryIdempotencyStore(), time.Hour))
+
+		first, err := wrapped(testutil.CreateTestContext("request-1"), idempotentRequest("key-1", `{}`))
+		require.NoError(t, err)
+		assert.Equal(t, 500, first.StatusCode)
+
+		second, err := wrapped(testutil.CreateTestContext("request-2"), idempotentRequest("key-1", `{}`))
+		require.NoError(t, err)
+		assert.Equal(t, 200, second.StatusCode)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("rejects reused key with different payload", func(t *testing.T) {
+		h := newTestHandler(t)
+		handler := h.Idempotency(NewMemoryIdempotencyStore(), time.Hour)(func(ctx context.Context, request *Request) (interface{}, error) {
+			return "created", nil
+		})
+
+		_, err := handler(context.Background(), NewRequestFromHTTPAPI(idempotentRequest("key-1", `{"name":"Ann"}`)))
+		require.NoError(t, err)
+
+		_, err = handler(context.Background(), NewRequestFromHTTPAPI(idempotentRequest("key-1", `{"name":"Bob"}`)))
+		assert.True(t, IsConflictError(err))
+	})
+
+	t.Run("scopes keys by caller", func(t *testing.T) {
+		h := newTestHandler(t)
+		calls := 0
+		handler := h.Idempotency(NewMemoryIdempotencyStore(), time.Hour)(func(ctx context.Context, request *Request) (interface{}, error) {
+			calls++
+			return "created", nil
+		})
+
+		for _, subject := range []string{"alice", "bob"} {
+			ctx := ContextWithClaims(context.Background(), &Claims{Subject: subject})
+			_, err := handler(ctx, NewRequestFromHTTPAPI(idempotentRequest("key-1", `{"name":"Ann"}`)))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("scopes keys by method and path", func(t *testing.T) {
+		h := newTestHandler(t)
+		calls := 0
+		handler := h.Idempotency(NewMemoryIdempotencyStore(), time.Hour)(func(ctx context.Context, request *Request) (interface{}, error) {
+			calls++
+			return "created", nil
+		})
+
+		other := idempotentRequest("key-1", `{}`)
+		other.RawPath = "/orders"
+		for _, event := range []events.APIGatewayV2HTTPRequest{idempotentRequest("key-1", `{}`), other} {
+			_, err := handler(context.Background(), NewRequestFromHTTPAPI(event))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("rejects reused key with different query string or content type", func(t *testing.T) {
+		h := newTestHandler(t)
+		handler := h.Idempotency(NewMemoryIdempotencyStore(), time.Hour)(func(ctx context.Context, request *Request) (interface{}, error) {
+			return "created", nil
+		})
+
+		_, err := handler(context.Background(), NewRequestFromHTTPAPI(idempotentRequest("key-1", `{}`)))
+		require.NoError(t, err)
+
+		dryRun := idempotentRequest("key-1", `{}`)
+		dryRun.QueryStringParameters = map[string]string{"dry_run": "true"}
+		_, err = handler(context.Background(), NewRequestFromHTTPAPI(dryRun))
+		assert.True(t, IsConflictError(err))
+
+		form := idempotentRequest("key-1", `{}`)
+		form.Headers["content-type"] = "text/plain"
+		_, err = handler(context.Background(), NewRequestFromHTTPAPI(form))
+		assert.True(t, IsConflictError(err))
+	})
+
+	t.Run("rejects concurrent duplicate", func(t *testing.T) {
+		h := newTestHandler(t)
+		store := NewMemoryIdempotencyStore()
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handler := h.Idempotency(store, time.Hour)(func(ctx context.Context, request *Request) (interface{}, error) {
+			close(started)
+			<-release
+			return "created", nil
+		})
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := handler(context.Background(), NewRequestFromHTTPAPI(idempotentRequest("key-1", `{}`)))
+			done <- err
+		}()
+		<-started
+
+		_, err := handler(context.Background(), NewRequestFromHTTPAPI(idempotentRequest("key-1", `{}`)))
+		assert.True(t, IsConflictError(err))
+
+		close(release)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("ignores safe methods and requests without key", func(t *testing.T) {
+		h := newTestHandler(t)
+		calls := 0
+		handler := h.Idempotency(NewMemoryIdempotencyStore(), time.Hour)(func(ctx context.Context, request *Request) (interface{}, error) {
+			calls++
+			return nil, nil
+		})
+
+		get := &Request{Method: "GET", Path: "/users", Headers: map[string]string{"Idempotency-Key": "key-1"}}
+		post := &Request{Method: "POST", Path: "/users"}
+		for _, request := range []*Request{get, get, post, post} {
+			_, err := handler(context.Background(), request)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 4, calls)
+	})
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	record := IdempotencyRecord{Key: "key-1", Fingerprint: "a", Status: IdempotencyInProgress, ExpiresAt: now.Add(time.Minute)}
+	existing, err := store.Start(context.Background(), record)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = store.Start(context.Background(), record)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, IdempotencyInProgress, existing.Status)
+
+	now = now.Add(time.Minute)
+	existing, err = store.Start(context.Background(), record)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "expired records are treated as absent")
+}
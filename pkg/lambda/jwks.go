@@ -0,0 +1,239 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	nethttp "net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwksMinRefreshInterval limits how often an unknown key ID triggers a refresh, so tokens
+// with made-up key IDs cannot flood the JWKS endpoint.
+const jwksMinRefreshInterval = time.Minute
+
+// jwksFetchTimeout bounds JWKS fetches made with the default client, so a hanging issuer
+// cannot stall token verification until the invocation times out.
+const jwksFetchTimeout = 5 * time.Second
+
+// jsonWebKey is a single key of a JWKS document (RFC 7517).
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Curve   string `json:"crv"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+	K       string `json:"k"`
+}
+
+// JWKSKeySet loads verification keys from a JWKS document and caches them. Keys are
+// reloaded once the cache expires, and early when a token names an unknown key ID, so
+// signing key rotations are picked up without a redeploy. The cache lives as long as the
+// container, so warm invocations do not refetch the document.
+type JWKSKeySet struct {
+	load      func(ctx context.Context) ([]byte, error)
+	cacheTTL  time.Duration
+	symmetric bool
+	now       func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+}
+
+// NewJWKSFromURL creates a key set fetching the JWKS document at url with client, or with
+// a client timing out after jwksFetchTimeout when client is nil. A fetched document is
+// public, so its symmetric ("oct") keys are skipped: anyone able to read them could sign
+// tokens.
+func NewJWKSFromURL(url string, cacheTTL time.Duration, client *nethttp.Client) *JWKSKeySet {
+	if client == nil {
+		client = &nethttp.Client{Timeout: jwksFetchTimeout}
+	}
+
+	return newJWKSKeySet(cacheTTL, false, func(ctx context.Context) ([]byte, error) {
+		request, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return nil, NewExternalServiceError("jwks", "failed to fetch JWKS", 0, true, err)
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != nethttp.StatusOK {
+			return nil, NewExternalServiceError("jwks", "unexpected JWKS response", response.StatusCode, response.StatusCode >= 500, nil)
+		}
+
+		return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	})
+}
+
+// NewJWKSFromFile creates a key set reading the JWKS document at path, for example one
+// bundled with the function. The document stays private, so it may hold symmetric keys.
+func NewJWKSFromFile(path string, cacheTTL time.Duration) *JWKSKeySet {
+	return newJWKSKeySet(cacheTTL, true, func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+func newJWKSKeySet(cacheTTL time.Duration, symmetric bool, load func(ctx context.Context) ([]byte, error)) *JWKSKeySet {
+	return &JWKSKeySet{
+		load:      load,
+		cacheTTL:  cacheTTL,
+		symmetric: symmetric,
+		now:       time.Now,
+	}
+}
+
+// Key implements JWTKeySet.
+func (s *JWKSKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	expired := s.keys == nil || (s.cacheTTL > 0 && now.Sub(s.fetchedAt) >= s.cacheTTL)
+	if key, ok := s.keys[kid]; ok && !expired {
+		return key, nil
+	}
+
+	// Refresh when the cache expired or the key is unknown, at most once per interval, so
+	// an unreachable endpoint is not fetched on every token; stale keys are served meanwhile
+	if s.lastAttempt.IsZero() || now.Sub(s.lastAttempt) >= jwksMinRefreshInterval {
+		s.lastErr = s.refresh(ctx, now)
+	}
+	if s.keys == nil {
+		return nil, s.lastErr
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// refresh reloads the keys. On failure the previous keys are kept.
+func (s *JWKSKeySet) refresh(ctx context.Context, now time.Time) error {
+	s.lastAttempt = now
+
+	data, err := s.load(ctx)
+	if err != nil {
+		return fmt.Errorf("loading JWKS: %w", err)
+	}
+
+	keys, err := parseJWKS(data, s.symmetric)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+	s.fetchedAt = now
+	return nil
+}
+
+// ParseJWKS parses a JWKS document into keys indexed by key ID. Keys of unsupported types
+// and encryption keys are skipped. Symmetric keys are included, so only parse documents
+// that are kept secret.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	return parseJWKS(data, true)
+}
+
+// parseJWKS parses a JWKS document, skipping symmetric keys unless symmetric is set.
+func parseJWKS(data []byte, symmetric bool) (map[string]crypto.PublicKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if jwk.KeyType == "oct" && !symmetric {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	return keys, nil
+}
+
+// publicKey converts the JWK into a key usable by verifyJWTSignature.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, err
+		}
+		return key, nil
+
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// decodeJWKInt decodes a base64url-encoded big-endian integer.
+func decodeJWKInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,363 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Reasons reported by UnauthorizedError when a bearer token or API key is rejected.
+const (
+	AuthReasonMissingToken     = "missing_token"
+	AuthReasonMalformedToken   = "malformed_token"
+	AuthReasonUnsupportedAlg   = "unsupported_algorithm"
+	AuthReasonUnknownKey       = "unknown_key"
+	AuthReasonInvalidSignature = "invalid_signature"
+	AuthReasonTokenExpired     = "token_expired"
+	AuthReasonMissingExpiry    = "missing_expiry"
+	AuthReasonTokenNotYetValid = "token_not_yet_valid"
+	AuthReasonInvalidIssuer    = "invalid_issuer"
+	AuthReasonInvalidAudience  = "invalid_audience"
+	AuthReasonInvalidAPIKey    = "invalid_api_key"
+)
+
+// Supported JWT signing algorithms.
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgES256 = "ES256"
+)
+
+// NumericDate is a JWT time claim, encoded as seconds since the Unix epoch.
+type NumericDate struct {
+	time.Time
+}
+
+// UnmarshalJSON accepts integer and fractional seconds.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("numeric date: %w", err)
+	}
+	whole, fraction := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+	return nil
+}
+
+// Audience is the aud claim, which may be a single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("audience must be a string or an array of strings: %w", err)
+	}
+	*a = multiple
+	return nil
+}
+
+// Contains reports whether audience is one of the token's audiences.
+func (a Audience) Contains(audience string) bool {
+	for _, value := range a {
+		if value == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims holds the verified claims of a JWT. Registered claims are parsed into fields;
+// every claim, including custom ones, is available in Raw.
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+	Scope     string       `json:"scope,omitempty"`
+
+	Raw map[string]interface{} `json:"-"`
+}
+
+// JWTKeySet resolves the key used to verify a token. Keys are []byte for HS256,
+// *rsa.PublicKey for RS256 and *ecdsa.PublicKey for ES256.
+type JWTKeySet interface {
+	// Key returns the key with the given key ID; kid is empty for tokens without one.
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// ErrKeyNotFound is returned by key sets that have no key with the requested ID.
+var ErrKeyNotFound = errors.New("key not found")
+
+// StaticKeySet is a fixed set of keys indexed by key ID. The key under the empty ID
+// verifies tokens without a kid header.
+type StaticKeySet map[string]crypto.PublicKey
+
+// Key implements JWTKeySet.
+func (s StaticKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// JWTConfig configures a JWTVerifier.
+type JWTConfig struct {
+	Keys JWTKeySet
+	// Algorithms lists the accepted signing algorithms. Defaults to HS256, RS256 and ES256,
+	// or only RS256 and ES256 when Keys is a JWKSKeySet; a key is only ever used with the
+	// algorithm matching its type.
+	Algorithms []string
+	// Issuer and Audience, when set, must match the iss claim and be one of the aud claim.
+	Issuer   string
+	Audience string
+	// Leeway allows for clock skew when checking exp and nbf.
+	Leeway time.Duration
+	// RequireExpiry rejects tokens without an exp claim, which would otherwise stay valid
+	// forever. Nil requires it; set it to a false value to accept such tokens.
+	RequireExpiry *bool
+}
+
+// JWTVerifier verifies signed JWTs and their registered claims.
+type JWTVerifier struct {
+	config JWTConfig
+	now    func() time.Time
+}
+
+// NewJWTVerifier creates a verifier for the given configuration.
+func NewJWTVerifier(config JWTConfig) *JWTVerifier {
+	if len(config.Algorithms) == 0 {
+		config.Algorithms = []string{JWTAlgHS256, JWTAlgRS256, JWTAlgES256}
+		// Key sets from JWKS documents hold the issuer's public keys, not shared secrets
+		if _, ok := config.Keys.(*JWKSKeySet); ok {
+			config.Algorithms = []string{JWTAlgRS256, JWTAlgES256}
+		}
+	}
+	if config.RequireExpiry == nil {
+		requireExpiry := true
+		config.RequireExpiry = &requireExpiry
+	}
+
+	return &JWTVerifier{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// jwtHeader is the JOSE header of a JWT.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify checks the token's signature and claims and returns the claims. Every failure
+// is an UnauthorizedError whose Reason is one of the AuthReason constants.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, NewUnauthorizedErrorWithReason("Malformed bearer token", AuthReasonMalformedToken)
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, NewUnauthorizedErrorWithReason("Malformed bearer token", AuthReasonMalformedToken)
+	}
+	if !v.allows(header.Algorithm) {
+		return nil, NewUnauthorizedErrorWithReason("Unsupported token algorithm", AuthReasonUnsupportedAlg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, NewUnauthorizedErrorWithReason("Malformed bearer token", AuthReasonMalformedToken)
+	}
+
+	key, err := v.config.Keys.Key(ctx, header.KeyID)
+	if err != nil {
+		return nil, NewUnauthorizedErrorWithReason("Unknown token signing key", AuthReasonUnknownKey)
+	}
+
+	if err := verifyJWTSignature(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, NewUnauthorizedErrorWithReason("Invalid token signature", AuthReasonInvalidSignature)
+	}
+
+	claims := &Claims{}
+	if err := decodeJWTSegment(parts[1], claims); err != nil {
+		return nil, NewUnauthorizedErrorWithReason("Malformed token claims", AuthReasonMalformedToken)
+	}
+	if err := decodeJWTSegment(parts[1], &claims.Raw); err != nil {
+		return nil, NewUnauthorizedErrorWithReason("Malformed token claims", AuthReasonMalformedToken)
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// allows reports whether alg is an accepted algorithm.
+func (v *JWTVerifier) allows(alg string) bool {
+	for _, allowed := range v.config.Algorithms {
+		if alg == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// validateClaims checks the time, issuer and audience claims.
+func (v *JWTVerifier) validateClaims(claims *Claims) error {
+	now := v.now()
+
+	if claims.ExpiresAt == nil && *v.config.RequireExpiry {
+		return NewUnauthorizedErrorWithReason("Token has no expiry", AuthReasonMissingExpiry)
+	}
+	if claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Add(v.config.Leeway)) {
+		return NewUnauthorizedErrorWithReason("Token has expired", AuthReasonTokenExpired)
+	}
+	if claims.NotBefore != nil && now.Add(v.config.Leeway).Before(claims.NotBefore.Time) {
+		return NewUnauthorizedErrorWithReason("Token is not valid yet", AuthReasonTokenNotYetValid)
+	}
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return NewUnauthorizedErrorWithReason("Token issuer is not trusted", AuthReasonInvalidIssuer)
+	}
+	if v.config.Audience != "" && !claims.Audience.Contains(v.config.Audience) {
+		return NewUnauthorizedErrorWithReason("Token is not intended for this audience", AuthReasonInvalidAudience)
+	}
+
+	return nil
+}
+
+// decodeJWTSegment decodes a base64url-encoded JSON segment of a JWT.
+func decodeJWTSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+// verifyJWTSignature checks signature over signingInput. The key type must match alg so
+// that, for example, an RSA public key can never be used as an HMAC secret.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case JWTAlgHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%s requires an HMAC secret, got %T", alg, key)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+
+	case JWTAlgRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s requires an RSA public key, got %T", alg, key)
+		}
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature)
+
+	case JWTAlgES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve != elliptic.P256() {
+			return fmt.Errorf("%s requires a P-256 public key, got %T", alg, key)
+		}
+		if len(signature) != 64 {
+			return errors.New("signature must be 64 bytes")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+// ContextWithClaims returns a context carrying the verified claims of the caller.
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKeyClaims, claims)
+}
+
+// ClaimsFromContext returns the claims stored by JWTAuth.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKeyClaims).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// BearerToken extracts the token from the value of an "Authorization: Bearer <token>"
+// header. It reports false for other schemes and empty tokens.
+func BearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// JWTAuth requires a valid "Authorization: Bearer" JWT and stores its claims in the context
+// for ClaimsFromContext. Requests with a missing or invalid token fail with an
+// UnauthorizedError whose Reason says why, and a WWW-Authenticate challenge.
+func (h *Handler) JWTAuth(verifier *JWTVerifier) Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			token, ok := BearerToken(request.Header("Authorization"))
+			if !ok {
+				SetResponseHeader(ctx, "WWW-Authenticate", `Bearer`)
+				return nil, NewUnauthorizedErrorWithReason("Missing bearer token", AuthReasonMissingToken)
+			}
+
+			claims, err := verifier.Verify(ctx, token)
+			if err != nil {
+				var unauthorized *UnauthorizedError
+				if errors.As(err, &unauthorized) {
+					SetResponseHeader(ctx, "WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, unauthorized.Reason))
+					h.tracer.AddAnnotation(ctx, "auth_failure", unauthorized.Reason)
+				}
+				h.logger.WithFields(map[string]interface{}{
+					"path":  request.Path,
+					"error": err.Error(),
+				}).WithContext(ctx).Warn("Rejected bearer token")
+				return nil, err
+			}
+
+			h.tracer.AddUserID(ctx, claims.Subject)
+			return next(ContextWithClaims(ctx, claims), request)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package lambda
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signTestJWT creates a token signed with key using alg.
+func signTestJWT(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case JWTAlgHS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case JWTAlgRS256:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case JWTAlgES256:
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	secret := []byte("test-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		values := map[string]interface{}{
+			"iss":   "https://issuer.example.com",
+			"sub":   "user-1",
+			"aud":   []string{"users-api", "other-api"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"nbf":   now.Add(-time.Minute).Unix(),
+			"scope": "users:read users:write",
+			"tier":  "gold",
+		}
+		for key, value := range overrides {
+			values[key] = value
+		}
+		return values
+	}
+
+	verifier := NewJWTVerifier(JWTConfig{
+		Keys: StaticKeySet{
+			"hmac": secret,
+			"rsa":  &rsaKey.PublicKey,
+			"ec":   &ecKey.PublicKey,
+		},
+		Issuer:   "https://issuer.example.com",
+		Audience: "users-api",
+		Leeway:   30 * time.Second,
+	})
+	verifier.now = func() time.Time { return now }
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedReason string
+	}{
+		{name: "HS256", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(nil))},
+		{name: "RS256", token: signTestJWT(t, JWTAlgRS256, "rsa", rsaKey, claims(nil))},
+		{name: "ES256", token: signTestJWT(t, JWTAlgES256, "ec", ecKey, claims(nil))},
+		{name: "single audience", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"aud": "users-api"}))},
+		{name: "expired within leeway", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}))},
+		{name: "malformed", token: "not-a-jwt", expectedReason: AuthReasonMalformedToken},
+		{name: "unsupported algorithm", token: signTestJWT(t, "none", "hmac", secret, claims(nil)), expectedReason: AuthReasonUnsupportedAlg},
+		{name: "unknown key", token: signTestJWT(t, JWTAlgHS256, "missing", secret, claims(nil)), expectedReason: AuthReasonUnknownKey},
+		{name: "wrong secret", token: signTestJWT(t, JWTAlgHS256, "hmac", []byte("other"), claims(nil)), expectedReason: AuthReasonInvalidSignature},
+		{name: "key type mismatch", token: signTestJWT(t, JWTAlgHS256, "rsa", secret, claims(nil)), expectedReason: AuthReasonInvalidSignature},
+		{name: "expired", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})), expectedReason: AuthReasonTokenExpired},
+		{name: "no expiry", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"exp": nil})), expectedReason: AuthReasonMissingExpiry},
+		{name: "not yet valid", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()})), expectedReason: AuthReasonTokenNotYetValid},
+		{name: "wrong issuer", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"iss": "https://evil.example.com"})), expectedReason: AuthReasonInvalidIssuer},
+		{name: "wrong audience", token: signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"aud": "billing-api"})), expectedReason: AuthReasonInvalidAudience},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verified, err := verifier.Verify(context.Background(), tt.token)
+
+			if tt.expectedReason != "" {
+				var unauthorized *UnauthorizedError
+				require.True(t, errors.As(err, &unauthorized), "expected UnauthorizedError, got %v", err)
+				assert.Equal(t, tt.expectedReason, unauthorized.Reason)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", verified.Subject)
+			assert.Equal(t, "https://issuer.example.com", verified.Issuer)
+			assert.True(t, verified.Audience.Contains("users-api"))
+			assert.Equal(t, "users:read users:write", verified.Scope)
+			assert.Equal(t, "gold", verified.Raw["tier"])
+			require.NotNil(t, verified.ExpiresAt)
+		})
+	}
+
+	t.Run("no expiry when not required", func(t *testing.T) {
+		requireExpiry := false
+		lenient := NewJWTVerifier(JWTConfig{Keys: StaticKeySet{"hmac": secret}, RequireExpiry: &requireExpiry})
+		lenient.now = func() time.Time { return now }
+
+		verified, err := lenient.Verify(context.Background(), signTestJWT(t, JWTAlgHS256, "hmac", secret, claims(map[string]interface{}{"exp": nil})))
+		require.NoError(t, err)
+		assert.Nil(t, verified.ExpiresAt)
+	})
+}
+
+func TestJWKSKeySet(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	secret := []byte("published-secret")
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"EC","kid":"ec-1","use":"sig","crv":"P-256","x":%q,"y":%q},
+		{"kty":"RSA","kid":"rsa-1","n":%q,"e":%q},
+		{"kty":"RSA","kid":"enc-1","use":"enc","n":%q,"e":%q},
+		{"kty":"oct","kid":"hmac-1","k":%q}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		base64.RawURLEncoding.EncodeToString(secret),
+	)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	claims := map[string]interface{}{"sub": "user-1", "exp": now.Add(time.Hour).Unix()}
+
+	t.Run("from URL with caching", func(t *testing.T) {
+		fetches := 0
+		server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			fetches++
+			fmt.Fprint(w, jwks)
+		}))
+		defer server.Close()
+
+		keySet := NewJWKSFromURL(server.URL, time.Hour, server.Client())
+		keySet.now = func() time.Time { return now }
+
+		verifier := NewJWTVerifier(JWTConfig{Keys: keySet})
+		verifier.now = func() time.Time { return now }
+
+		for _, token := range []string{
+			signTestJWT(t, JWTAlgES256, "ec-1", ecKey, claims),
+			signTestJWT(t, JWTAlgRS256, "rsa-1", rsaKey, claims),
+		} {
+			claims, err := verifier.Verify(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+		}
+		assert.Equal(t, 1, fetches)
+
+		// Symmetric keys in a published document would let anyone sign tokens
+		_, err := verifier.Verify(context.Background(), signTestJWT(t, JWTAlgHS256, "hmac-1", secret, claims))
+		var unauthorized *UnauthorizedError
+		require.ErrorAs(t, err, &unauthorized)
+		assert.Equal(t, AuthReasonUnsupportedAlg, unauthorized.Reason)
+		_, err = keySet.Key(context.Background(), "hmac-1")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		// Unknown key IDs refresh at most once per interval
+		_, err = keySet.Key(context.Background(), "enc-1")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Equal(t, 1, fetches)
+
+		now = now.Add(jwksMinRefreshInterval)
+		_, err = keySet.Key(context.Background(), "rotated")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Equal(t, 2, fetches)
+
+		now = now.Add(time.Hour)
+		_, err = keySet.Key(context.Background(), "ec-1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, fetches)
+	})
+
+	t.Run("from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, []byte(jwks), 0o600))
+
+		keySet := NewJWKSFromFile(path, 0)
+		key, err := keySet.Key(context.Background(), "ec-1")
+		require.NoError(t, err)
+		assert.Equal(t, &ecKey.PublicKey, key)
+
+		key, err = keySet.Key(context.Background(), "hmac-1")
+		require.NoError(t, err)
+		assert.Equal(t, secret, key)
+	})
+
+	t.Run("fetch failure", func(t *testing.T) {
+		server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		_, err := NewJWKSFromURL(server.URL, time.Hour, server.Client()).Key(context.Background(), "ec-1")
+		assert.True(t, IsExternalServiceError(err))
+	})
+
+	t.Run("outage after the cache expired", func(t *testing.T) {
+		fetches := 0
+		down := false
+		server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			fetches++
+			if down {
+				w.WriteHeader(nethttp.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, jwks)
+		}))
+		defer server.Close()
+
+		keySet := NewJWKSFromURL(server.URL, time.Hour, server.Client())
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		keySet.now = func() time.Time { return now }
+		_, err := keySet.Key(context.Background(), "ec-1")
+		require.NoError(t, err)
+
+		// Stale keys are served, and the endpoint retried at most once per interval
+		down = true
+		now = now.Add(2 * time.Hour)
+		for i := 0; i < 3; i++ {
+			key, err := keySet.Key(context.Background(), "ec-1")
+			require.NoError(t, err)
+			assert.Equal(t, &ecKey.PublicKey, key)
+		}
+		assert.Equal(t, 2, fetches)
+
+		now = now.Add(jwksMinRefreshInterval)
+		_, err = keySet.Key(context.Background(), "ec-1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, fetches)
+
+		// Without keys to fall back on, the last error is returned until the next attempt
+		cold := NewJWKSFromURL(server.URL, time.Hour, server.Client())
+		cold.now = keySet.now
+		for i := 0; i < 2; i++ {
+			_, err = cold.Key(context.Background(), "ec-1")
+			assert.True(t, IsExternalServiceError(err))
+		}
+		assert.Equal(t, 4, fetches)
+	})
+}
+
+func TestHandler_JWTAuth(t *testing.T) {
+	secret := []byte("test-secret")
+	verifier := NewJWTVerifier(JWTConfig{Keys: StaticKeySet{"": secret}})
+
+	h := newTestHandler(t)
+	wrapped := h.WrapHTTPAPI(func(ctx context.Context, request *Request) (interface{}, error) {
+		claims, ok := ClaimsFromContext(ctx)
+		require.True(t, ok)
+		return claims.Subject, nil
+	}, h.JWTAuth(verifier))
+
+	token := signTestJWT(t, JWTAlgHS256, "", secret, map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	response, err := wrapped(testutil.CreateTestContext("test-request"), testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{
+		"authorization": "Bearer " + token,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Contains(t, response.Body, "user-1")
+
+	response, err = wrapped(testutil.CreateTestContext("test-request"), testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+	require.NoError(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	assert.Equal(t, "Bearer", response.Headers["WWW-Authenticate"])
+
+	expired := signTestJWT(t, JWTAlgHS256, "", secret, map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(-time.Hour).Unix()})
+	response, err = wrapped(testutil.CreateTestContext("test-request"), testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{
+		"authorization": "Bearer " + expired,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	assert.Contains(t, response.Headers["WWW-Authenticate"], AuthReasonTokenExpired)
+}
@@ -0,0 +1,214 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"lambda-go-template/pkg/config"
+	"lambda-go-template/pkg/http"
+)
+
+// Reasons recorded when LimitBody rejects a request.
+const (
+	RejectReasonBodyTooLarge   = "body_too_large"
+	RejectReasonJSONDepth      = "json_depth"
+	RejectReasonJSONArray      = "json_array_length"
+	RejectReasonJSONObjectKeys = "json_object_keys"
+	RejectReasonInvalidBody    = "invalid_body"
+)
+
+// BodyLimits bounds the size and shape of request bodies. Zero disables a limit.
+type BodyLimits struct {
+	// MaxBytes is the largest accepted body, after base64 decoding.
+	MaxBytes int
+	// MaxDepth is the deepest accepted nesting of JSON objects and arrays.
+	MaxDepth int
+	// MaxArrayLength is the most elements accepted in one JSON array.
+	MaxArrayLength int
+	// MaxObjectKeys is the most members accepted in one JSON object.
+	MaxObjectKeys int
+}
+
+// BodyLimitsFromConfig returns the body limits configured through MAX_BODY_BYTES,
+// MAX_JSON_DEPTH, MAX_JSON_ARRAY_LENGTH and MAX_JSON_OBJECT_KEYS.
+func BodyLimitsFromConfig(cfg *config.Config) BodyLimits {
+	return BodyLimits{
+		MaxBytes:       cfg.MaxBodyBytes,
+		MaxDepth:       cfg.MaxJSONDepth,
+		MaxArrayLength: cfg.MaxJSONArrayLength,
+		MaxObjectKeys:  cfg.MaxJSONObjectKeys,
+	}
+}
+
+// LimitBody rejects request bodies exceeding limits before any handler decodes them.
+// Oversized bodies get a PayloadTooLargeError (413). JSON bodies are then scanned token by
+// token, without building the decoded value, and a body nested too deeply or with too
+// large an array or object gets a ValidationError (400) naming the offending location,
+// such as "body.items[1000]". Each rejection is logged, annotated on the trace and counted
+// in the RequestRejected metric by reason.
+//
+// Place it ahead of JSONParsing and body binding, which decode whatever they are given.
+func (h *Handler) LimitBody(limits BodyLimits) Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			if request.Body == "" {
+				return next(ctx, request)
+			}
+
+			if size := bodySize(request); limits.MaxBytes > 0 && size > limits.MaxBytes {
+				err := NewPayloadTooLargeError(fmt.Sprintf("Request body exceeds %d bytes", limits.MaxBytes), size, limits.MaxBytes)
+				h.rejectRequest(ctx, request, RejectReasonBodyTooLarge, err)
+				return nil, err
+			}
+
+			if mediaType, err := http.ParseMediaType(request.Header("Content-Type")); err != nil || !mediaType.IsJSON() {
+				return next(ctx, request)
+			}
+
+			body, err := requestBody(request)
+			if err != nil {
+				h.rejectRequest(ctx, request, RejectReasonInvalidBody, err)
+				return nil, err
+			}
+
+			if reason, err := checkJSONShape(body, limits); err != nil {
+				h.rejectRequest(ctx, request, reason, err)
+				return nil, err
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// rejectRequest records a request rejected by LimitBody.
+func (h *Handler) rejectRequest(ctx context.Context, request *Request, reason string, err error) {
+	h.tracer.AddAnnotation(ctx, "request_rejected", reason)
+	h.metrics.Count(ctx, "RequestRejected", map[string]string{"reason": reason})
+	h.logger.WithFields(map[string]interface{}{
+		"reason":    reason,
+		"path":      request.Path,
+		"body_size": bodySize(request),
+		"error":     err.Error(),
+	}).WithContext(ctx).Warn("Request body rejected")
+}
+
+// bodySize returns the size of the request body in bytes, after base64 decoding.
+func bodySize(request *Request) int {
+	if !request.IsBase64Encoded {
+		return len(request.Body)
+	}
+	padding := len(request.Body) - len(strings.TrimRight(request.Body, "="))
+	return len(request.Body)/4*3 - padding
+}
+
+// jsonFrame is an open JSON object or array while scanning a body.
+type jsonFrame struct {
+	object    bool
+	count     int
+	key       string
+	expectKey bool
+}
+
+// checkJSONShape scans a JSON document and returns the reason and a ValidationError for
+// the first place it exceeds limits, or for malformed JSON.
+func checkJSONShape(body []byte, limits BodyLimits) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var stack []*jsonFrame
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		if err != nil {
+			return RejectReasonInvalidBody, NewValidationErrorWithCause("Invalid JSON in request body", "body", nil, err)
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		delim, isDelim := token.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && stack[len(stack)-1].object {
+				stack[len(stack)-1].expectKey = true
+			}
+			continue
+		}
+
+		if top != nil && top.object && top.expectKey {
+			top.count++
+			if limits.MaxObjectKeys > 0 && top.count > limits.MaxObjectKeys {
+				return RejectReasonJSONObjectKeys, bodyShapeError(
+					fmt.Sprintf("object has more than %d fields", limits.MaxObjectKeys), jsonPath(stack), "max_object_keys")
+			}
+			top.key, _ = token.(string)
+			top.expectKey = false
+			continue
+		}
+
+		if top != nil && !top.object {
+			top.count++
+			if limits.MaxArrayLength > 0 && top.count > limits.MaxArrayLength {
+				return RejectReasonJSONArray, bodyShapeError(
+					fmt.Sprintf("array has more than %d elements", limits.MaxArrayLength), jsonPath(stack), "max_array_length")
+			}
+		}
+
+		if !isDelim {
+			if top != nil && top.object {
+				top.expectKey = true
+			}
+			continue
+		}
+
+		if limits.MaxDepth > 0 && len(stack) >= limits.MaxDepth {
+			return RejectReasonJSONDepth, bodyShapeError(
+				fmt.Sprintf("nesting exceeds %d levels", limits.MaxDepth), jsonValuePath(stack), "max_depth")
+		}
+		stack = append(stack, &jsonFrame{object: delim == '{', expectKey: delim == '{'})
+	}
+}
+
+// bodyShapeError creates the ValidationError for a body exceeding a limit at field, with
+// the violation listed in the errors array of the response.
+func bodyShapeError(message, field, rule string) *ValidationError {
+	return &ValidationError{
+		Message: "request body exceeds limits",
+		Field:   field,
+		Err:     FieldViolations{{Field: field, Message: message, Rule: rule}},
+	}
+}
+
+// jsonPath returns the location of the innermost open container, such as "body.items[2]".
+func jsonPath(stack []*jsonFrame) string {
+	return jsonValuePath(stack[:len(stack)-1])
+}
+
+// jsonValuePath returns the location of the value being read inside the open containers.
+func jsonValuePath(stack []*jsonFrame) string {
+	var path strings.Builder
+	path.WriteString("body")
+	for _, frame := range stack {
+		if frame.object {
+			path.WriteString(".")
+			path.WriteString(frame.key)
+		} else {
+			path.WriteString("[")
+			path.WriteString(strconv.Itoa(frame.count - 1))
+			path.WriteString("]")
+		}
+	}
+	return path.String()
+}
@@ -0,0 +1,186 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+	"lambda-go-template/pkg/observability"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckJSONShape(t *testing.T) {
+	limits := BodyLimits{MaxDepth: 3, MaxArrayLength: 3, MaxObjectKeys: 3}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedReason string
+		expectedField  string
+	}{
+		{name: "within limits", body: `{"name":"Ada","tags":["a","b","c"],"address":{"city":"London"}}`},
+		{name: "scalar", body: `42`},
+		{name: "deepest allowed nesting", body: `{"a":{"b":[1]}}`},
+		{
+			name:           "too deeply nested object",
+			body:           `{"a":{"b":{"c":{}}}}`,
+			expectedReason: RejectReasonJSONDepth,
+			expectedField:  "body.a.b.c",
+		},
+		{
+			name:           "too deeply nested arrays",
+			body:           `{"items":[[1],[[2]]]}`,
+			expectedReason: RejectReasonJSONDepth,
+			expectedField:  "body.items[1][0]",
+		},
+		{
+			name:           "array too long",
+			body:           `{"user":{"tags":["a","b","c","d"]}}`,
+			expectedReason: RejectReasonJSONArray,
+			expectedField:  "body.user.tags",
+		},
+		{
+			name:           "too many object keys",
+			body:           `[{"a":1},{"a":1,"b":2,"c":3,"d":4}]`,
+			expectedReason: RejectReasonJSONObjectKeys,
+			expectedField:  "body[1]",
+		},
+		{
+			name:           "malformed JSON",
+			body:           `{"a":}`,
+			expectedReason: RejectReasonInvalidBody,
+			expectedField:  "body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := checkJSONShape([]byte(tt.body), limits)
+			assert.Equal(t, tt.expectedReason, reason)
+			if tt.expectedReason == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.expectedField, validationErr.Field)
+		})
+	}
+}
+
+func TestHandler_LimitBody(t *testing.T) {
+	ctx := testutil.CreateTestContext("test-request")
+	limits := BodyLimits{MaxBytes: 64, MaxDepth: 4, MaxArrayLength: 10, MaxObjectKeys: 10}
+
+	var reached bool
+	business := func(ctx context.Context, request *Request) (interface{}, error) {
+		reached = true
+		return map[string]string{"status": "ok"}, nil
+	}
+
+	newLimitedHandler := func(t *testing.T) (*Handler, *bytes.Buffer) {
+		var output bytes.Buffer
+		cfg := testutil.TestConfig()
+		h := NewHandler(cfg, testutil.TestLogger(t), testutil.TestTracer()).
+			WithMetrics(observability.NewMetrics(observability.MetricsConfig{
+				Enabled:     true,
+				Namespace:   "test",
+				ServiceName: cfg.ServiceName,
+				Output:      &output,
+			}))
+		return h, &output
+	}
+
+	t.Run("passes bodies within limits", func(t *testing.T) {
+		reached = false
+		h, output := newLimitedHandler(t)
+		request := testutil.CreateTestAPIGatewayV2RequestWithBody("POST", "/users", map[string]string{"name": "Ada"})
+
+		response, err := h.WrapHTTPAPI(business, h.LimitBody(limits))(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.True(t, reached)
+		assert.Empty(t, output.String())
+	})
+
+	t.Run("rejects oversized bodies with 413", func(t *testing.T) {
+		reached = false
+		h, output := newLimitedHandler(t)
+		request := testutil.CreateTestAPIGatewayV2RequestWithBody("POST", "/users", map[string]string{"name": strings.Repeat("a", 100)})
+
+		response, err := h.WrapHTTPAPI(business, h.LimitBody(limits))(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 413, response.StatusCode)
+		assert.False(t, reached)
+		testutil.AssertErrorResponse(t, response.Body, "Request body exceeds 64 bytes")
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, ErrorCodePayloadTooLarge, body["code"])
+		assert.Equal(t, map[string]interface{}{"limitBytes": float64(64)}, body["details"])
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+		assert.Equal(t, float64(1), record["RequestRejected"])
+		assert.Equal(t, RejectReasonBodyTooLarge, record["reason"])
+		assert.Equal(t, "test-request", record["requestId"])
+		assert.Contains(t, record, "_aws")
+	})
+
+	t.Run("measures base64-encoded bodies after decoding", func(t *testing.T) {
+		reached = false
+		h, _ := newLimitedHandler(t)
+		request := testutil.CreateTestAPIGatewayV2Request("POST", "/users")
+		request.Body = base64.StdEncoding.EncodeToString([]byte(`{"name":"` + strings.Repeat("a", 40) + `"}`))
+		request.IsBase64Encoded = true
+
+		response, err := h.WrapHTTPAPI(business, h.LimitBody(limits))(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.True(t, reached)
+	})
+
+	t.Run("rejects deeply nested JSON with 400", func(t *testing.T) {
+		reached = false
+		h, output := newLimitedHandler(t)
+		request := testutil.CreateTestAPIGatewayV2RequestWithBody("POST", "/users", json.RawMessage(`{"a":[{"b":[{"c":1}]}]}`))
+
+		response, err := h.WrapHTTPAPI(business, h.LimitBody(limits))(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 400, response.StatusCode)
+		assert.False(t, reached)
+		testutil.AssertErrorResponse(t, response.Body, "request body exceeds limits")
+
+		var body struct {
+			Errors []http.FieldError `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, []http.FieldError{{Field: "body.a[0].b[0]", Message: "nesting exceeds 4 levels", Rule: "max_depth"}}, body.Errors)
+		assert.Contains(t, output.String(), RejectReasonJSONDepth)
+	})
+
+	t.Run("skips the JSON checks for other content types", func(t *testing.T) {
+		reached = false
+		h, _ := newLimitedHandler(t)
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("POST", "/users", map[string]string{"content-type": "text/plain"})
+		request.Body = `[[[[[[1]]]]]]`
+
+		response, err := h.WrapHTTPAPI(business, h.LimitBody(limits))(ctx, request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.True(t, reached)
+	})
+}
@@ -0,0 +1,114 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query holds query string parameters by name. Its typed accessors return the default
+// for a missing or empty parameter and a ValidationError naming the parameter for a
+// malformed one.
+type Query map[string][]string
+
+// NewQuery builds a Query from the single- and multi-value parameter maps of a trigger
+// event. REST APIs and ALBs deliver repeated parameters in the multi-value map, which wins
+// where both hold a name; HTTP APIs and Function URLs join them with commas instead.
+func NewQuery(values map[string]string, multiValues map[string][]string) Query {
+	query := make(Query, len(values)+len(multiValues))
+	for name, value := range values {
+		query[name] = []string{value}
+	}
+	for name, list := range multiValues {
+		if len(list) > 0 {
+			query[name] = list
+		}
+	}
+	return query
+}
+
+// Has reports whether the named parameter is present, even if empty.
+func (q Query) Has(name string) bool {
+	_, ok := q[name]
+	return ok
+}
+
+// Get returns the last value of the named parameter, or an empty string. Like Bind, it
+// takes the last value of a repeated parameter.
+func (q Query) Get(name string) string {
+	if values := q[name]; len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
+}
+
+// Strings returns every value of the named parameter. A single comma-joined value, as
+// HTTP APIs deliver repeated parameters, is split; empty elements are dropped.
+func (q Query) Strings(name string) []string {
+	values := q[name]
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+
+	var elements []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			elements = append(elements, value)
+		}
+	}
+	return elements
+}
+
+// String returns the named parameter, or defaultValue when it is missing or empty.
+func (q Query) String(name, defaultValue string) string {
+	if value := q.Get(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Int returns the named parameter parsed as an integer, or defaultValue when it is
+// missing or empty.
+func (q Query) Int(name string, defaultValue int) (int, error) {
+	value := q.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, NewValidationErrorWithCause("query parameter must be an integer", name, value, err)
+	}
+	return parsed, nil
+}
+
+// Bool returns the named parameter parsed as a boolean ("true", "false", "1", "0", ...),
+// or defaultValue when it is missing or empty.
+func (q Query) Bool(name string, defaultValue bool) (bool, error) {
+	value := q.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, NewValidationErrorWithCause("query parameter must be a boolean", name, value, err)
+	}
+	return parsed, nil
+}
+
+// Time returns the named parameter parsed as an RFC 3339 timestamp, or defaultValue when
+// it is missing or empty.
+func (q Query) Time(name string, defaultValue time.Time) (time.Time, error) {
+	value := q.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, NewValidationErrorWithCause("query parameter must be an RFC 3339 timestamp", name, value, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,85 @@
+package lambda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	since := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	defaultSince := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	requests := map[string]*Request{
+		"REST API": NewRequestFromAPIGateway(events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{"limit": "10", "verbose": "true", "since": "2024-01-15T10:30:00Z", "tag": "b"},
+			MultiValueQueryStringParameters: map[string][]string{
+				"limit": {"10"}, "verbose": {"true"}, "since": {"2024-01-15T10:30:00Z"}, "tag": {"a", "b"},
+			},
+		}),
+		"HTTP API": NewRequestFromHTTPAPI(events.APIGatewayV2HTTPRequest{
+			QueryStringParameters: map[string]string{"limit": "10", "verbose": "true", "since": "2024-01-15T10:30:00Z", "tag": "a,b"},
+		}),
+	}
+
+	for name, request := range requests {
+		t.Run(name, func(t *testing.T) {
+			query := request.Query()
+
+			limit, err := query.Int("limit", 20)
+			require.NoError(t, err)
+			assert.Equal(t, 10, limit)
+
+			verbose, err := query.Bool("verbose", false)
+			require.NoError(t, err)
+			assert.True(t, verbose)
+
+			parsed, err := query.Time("since", defaultSince)
+			require.NoError(t, err)
+			assert.Equal(t, since, parsed)
+
+			assert.Equal(t, []string{"a", "b"}, query.Strings("tag"))
+			assert.True(t, query.Has("tag"))
+		})
+	}
+
+	t.Run("defaults for missing and empty parameters", func(t *testing.T) {
+		query := (&Request{QueryParameters: map[string]string{"offset": ""}}).Query()
+
+		offset, err := query.Int("offset", 5)
+		require.NoError(t, err)
+		assert.Equal(t, 5, offset)
+
+		verbose, err := query.Bool("verbose", true)
+		require.NoError(t, err)
+		assert.True(t, verbose)
+
+		parsed, err := query.Time("since", defaultSince)
+		require.NoError(t, err)
+		assert.Equal(t, defaultSince, parsed)
+
+		assert.Equal(t, "name", query.String("sort", "name"))
+		assert.Empty(t, query.Strings("tag"))
+	})
+
+	t.Run("malformed values", func(t *testing.T) {
+		query := (&Request{QueryParameters: map[string]string{"limit": "ten", "verbose": "maybe", "since": "yesterday"}}).Query()
+
+		_, err := query.Int("limit", 20)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "limit", validationErr.Field)
+		assert.Equal(t, "ten", validationErr.Value)
+
+		_, err = query.Bool("verbose", false)
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "verbose", validationErr.Field)
+
+		_, err = query.Time("since", defaultSince)
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "since", validationErr.Field)
+	})
+}
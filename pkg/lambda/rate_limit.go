@@ -0,0 +1,310 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RateLimitKeyFunc identifies the caller a request is counted against. It reports false
+// when the request carries no such identity; those requests are not rate limited.
+type RateLimitKeyFunc func(ctx context.Context, request *Request) (string, bool)
+
+// KeyBySourceIP counts requests per client IP address.
+func KeyBySourceIP(ctx context.Context, request *Request) (string, bool) {
+	return "ip:" + request.SourceIP, request.SourceIP != ""
+}
+
+// KeyByAPIKey counts requests per API key, taken from the API Gateway identity or the
+// X-Api-Key header.
+func KeyByAPIKey(ctx context.Context, request *Request) (string, bool) {
+	apiKey := request.Header("X-Api-Key")
+	if event, ok := request.Event.(events.APIGatewayProxyRequest); ok && event.RequestContext.Identity.APIKey != "" {
+		apiKey = event.RequestContext.Identity.APIKey
+	}
+	return "apikey:" + apiKey, apiKey != ""
+}
+
+// KeyByJWTSubject counts requests per authenticated user, using the sub claim verified by
+// JWTAuth or provided by an API Gateway JWT, Cognito or Lambda authorizer.
+func KeyByJWTSubject(ctx context.Context, request *Request) (string, bool) {
+	if claims, ok := ClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return "sub:" + claims.Subject, true
+	}
+
+	if authorizerContext, ok := request.AuthorizerContext(); ok && authorizerContext.Subject() != "" {
+		return "sub:" + authorizerContext.Subject(), true
+	}
+
+	subject := ""
+	switch event := request.Event.(type) {
+	case events.APIGatewayV2HTTPRequest:
+		if authorizer := event.RequestContext.Authorizer; authorizer != nil && authorizer.JWT != nil {
+			subject = authorizer.JWT.Claims["sub"]
+		}
+	case events.APIGatewayProxyRequest:
+		if claims, ok := event.RequestContext.Authorizer["claims"].(map[string]interface{}); ok {
+			subject, _ = claims["sub"].(string)
+		}
+	}
+	return "sub:" + subject, subject != ""
+}
+
+// RateLimitState is the stored state of one rate limit key. Its meaning depends on the
+// algorithm: a request count and window start for FixedWindow, or the tokens left and last
+// refill time for TokenBucket. The zero value is the state of a key never seen before.
+type RateLimitState struct {
+	Value float64
+	Since time.Time
+}
+
+// RateLimitDecision is the outcome of counting one request.
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the limit is fully available again
+	RetryAfter time.Duration // until a rejected request may succeed
+}
+
+// RateLimitAlgorithm counts requests against a limit.
+type RateLimitAlgorithm interface {
+	// Take counts one request at now, updating state, and returns the decision.
+	Take(state *RateLimitState, now time.Time) RateLimitDecision
+	// TTL is how long an untouched state must be kept.
+	TTL() time.Duration
+}
+
+// FixedWindow allows Limit requests per Window, with windows starting at the first request.
+type FixedWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Take implements RateLimitAlgorithm.
+func (w FixedWindow) Take(state *RateLimitState, now time.Time) RateLimitDecision {
+	if state.Since.IsZero() || !now.Before(state.Since.Add(w.Window)) {
+		state.Value = 0
+		state.Since = now
+	}
+
+	reset := state.Since.Add(w.Window).Sub(now)
+	if int(state.Value) >= w.Limit {
+		return RateLimitDecision{Limit: w.Limit, Reset: reset, RetryAfter: reset}
+	}
+
+	state.Value++
+	return RateLimitDecision{Allowed: true, Limit: w.Limit, Remaining: w.Limit - int(state.Value), Reset: reset}
+}
+
+// TTL implements RateLimitAlgorithm.
+func (w FixedWindow) TTL() time.Duration {
+	return w.Window
+}
+
+func (w FixedWindow) validate() error {
+	if w.Window <= 0 {
+		return fmt.Errorf("fixed window must be positive, got %s", w.Window)
+	}
+	return nil
+}
+
+// TokenBucket allows bursts of up to Capacity requests, refilled at Rate requests per second.
+type TokenBucket struct {
+	Capacity int
+	Rate     float64
+}
+
+// Take implements RateLimitAlgorithm.
+func (b TokenBucket) Take(state *RateLimitState, now time.Time) RateLimitDecision {
+	capacity := float64(b.Capacity)
+	if state.Since.IsZero() {
+		state.Value = capacity
+	} else if elapsed := now.Sub(state.Since); elapsed > 0 {
+		state.Value = math.Min(capacity, state.Value+elapsed.Seconds()*b.Rate)
+	}
+	state.Since = now
+
+	decision := RateLimitDecision{Limit: b.Capacity}
+	if state.Value >= 1 {
+		state.Value--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = b.refillTime(1 - state.Value)
+	}
+
+	decision.Remaining = int(state.Value)
+	decision.Reset = b.refillTime(capacity - state.Value)
+	return decision
+}
+
+// TTL implements RateLimitAlgorithm.
+func (b TokenBucket) TTL() time.Duration {
+	return b.refillTime(float64(b.Capacity))
+}
+
+func (b TokenBucket) validate() error {
+	if b.Rate <= 0 {
+		return fmt.Errorf("token bucket rate must be positive, got %g", b.Rate)
+	}
+	return nil
+}
+
+// refillTime returns how long it takes to refill the given number of tokens.
+func (b TokenBucket) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens / b.Rate * float64(time.Second))
+}
+
+// RateLimitStore persists rate limit state between requests.
+//
+// Update must apply fn to the state stored under key atomically with respect to other
+// updates of the same key, then store the result for at least ttl. A store backed by
+// DynamoDB reads the item, calls fn and writes it back with a condition on the version it
+// read, retrying on conflict; fn may therefore be called more than once. ttl maps onto the
+// table's TTL attribute.
+type RateLimitStore interface {
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+// MemoryRateLimitStore keeps rate limit state in memory. Limits are per container, so it
+// suits tests and functions with a single concurrent execution environment.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRateLimitEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// memoryRateLimitSweepInterval is how often expired entries are removed from a MemoryRateLimitStore.
+const memoryRateLimitSweepInterval = time.Minute
+
+type memoryRateLimitEntry struct {
+	state     RateLimitState
+	expiresAt time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: make(map[string]memoryRateLimitEntry),
+		now:     time.Now,
+	}
+}
+
+// Update implements RateLimitStore.
+func (s *MemoryRateLimitStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= memoryRateLimitSweepInterval {
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = memoryRateLimitEntry{}
+	}
+	fn(&entry.state)
+	entry.expiresAt = now.Add(ttl)
+	s.entries[key] = entry
+
+	return nil
+}
+
+// RateLimiter counts requests per caller using an algorithm and a store.
+type RateLimiter struct {
+	name      string
+	store     RateLimitStore
+	algorithm RateLimitAlgorithm
+	key       RateLimitKeyFunc
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter. The name prefixes stored keys so several limiters
+// can share a store. NewRateLimiter panics if a FixedWindow or TokenBucket is misconfigured,
+// since a state that expires at once would never limit anything and limiters are created
+// once at cold start.
+func NewRateLimiter(name string, store RateLimitStore, algorithm RateLimitAlgorithm, key RateLimitKeyFunc) *RateLimiter {
+	if validator, ok := algorithm.(interface{ validate() error }); ok {
+		if err := validator.validate(); err != nil {
+			panic(fmt.Sprintf("invalid rate limiter %s: %v", name, err))
+		}
+	}
+
+	return &RateLimiter{
+		name:      name,
+		store:     store,
+		algorithm: algorithm,
+		key:       key,
+		now:       time.Now,
+	}
+}
+
+// Take counts a request and returns the decision. It reports false when the request has no
+// key and is not limited.
+func (l *RateLimiter) Take(ctx context.Context, request *Request) (RateLimitDecision, bool, error) {
+	key, ok := l.key(ctx, request)
+	if !ok {
+		return RateLimitDecision{}, false, nil
+	}
+
+	var decision RateLimitDecision
+	err := l.store.Update(ctx, l.name+":"+key, l.algorithm.TTL(), func(state *RateLimitState) {
+		decision = l.algorithm.Take(state, l.now())
+	})
+	if err != nil {
+		return RateLimitDecision{}, false, err
+	}
+
+	return decision, true, nil
+}
+
+// RateLimit rejects requests exceeding the limiter's limit with a RateLimitError (429 with
+// Retry-After). Every limited response carries RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. If the store fails the request is let through, so an outage of
+// the store does not take the API down with it.
+func (h *Handler) RateLimit(limiter *RateLimiter) Middleware {
+	return func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			decision, limited, err := limiter.Take(ctx, request)
+			if err != nil {
+				h.logger.WithFields(map[string]interface{}{
+					"limiter": limiter.name,
+					"error":   err.Error(),
+				}).WithContext(ctx).Error("Rate limit store failed, allowing request")
+				return next(ctx, request)
+			}
+			if !limited {
+				return next(ctx, request)
+			}
+
+			SetResponseHeader(ctx, "RateLimit-Limit", strconv.Itoa(decision.Limit))
+			SetResponseHeader(ctx, "RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			SetResponseHeader(ctx, "RateLimit-Reset", retryAfterSeconds(decision.Reset))
+
+			if !decision.Allowed {
+				h.tracer.AddAnnotation(ctx, "rate_limited", true)
+				h.logger.WithFields(map[string]interface{}{
+					"limiter": limiter.name,
+					"limit":   decision.Limit,
+					"path":    request.Path,
+				}).WithContext(ctx).Warn("Rate limit exceeded")
+				return nil, NewRateLimitError("Too many requests", decision.Limit, decision.RetryAfter)
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
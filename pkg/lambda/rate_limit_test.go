@@ -0,0 +1,195 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedWindow_Take(t *testing.T) {
+	window := FixedWindow{Limit: 2, Window: time.Minute}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var state RateLimitState
+
+	first := window.Take(&state, start)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+	assert.Equal(t, time.Minute, first.Reset)
+
+	second := window.Take(&state, start.Add(10*time.Second))
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+
+	third := window.Take(&state, start.Add(20*time.Second))
+	assert.False(t, third.Allowed)
+	assert.Equal(t, 40*time.Second, third.RetryAfter)
+
+	// A new window starts once the old one ends
+	fourth := window.Take(&state, start.Add(time.Minute))
+	assert.True(t, fourth.Allowed)
+	assert.Equal(t, 1, fourth.Remaining)
+}
+
+func TestTokenBucket_Take(t *testing.T) {
+	bucket := TokenBucket{Capacity: 2, Rate: 1}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var state RateLimitState
+
+	assert.True(t, bucket.Take(&state, start).Allowed)
+	assert.True(t, bucket.Take(&state, start).Allowed)
+
+	rejected := bucket.Take(&state, start.Add(250*time.Millisecond))
+	assert.False(t, rejected.Allowed)
+	assert.Equal(t, 750*time.Millisecond, rejected.RetryAfter)
+	assert.Equal(t, 1750*time.Millisecond, rejected.Reset)
+
+	refilled := bucket.Take(&state, start.Add(time.Second))
+	assert.True(t, refilled.Allowed)
+	assert.Equal(t, 0, refilled.Remaining)
+
+	// Refills never exceed the capacity
+	later := bucket.Take(&state, start.Add(time.Hour))
+	assert.True(t, later.Allowed)
+	assert.Equal(t, 1, later.Remaining)
+}
+
+func TestNewRateLimiter_InvalidAlgorithm(t *testing.T) {
+	// A state with no TTL expires at once, so such limiters would never limit anything
+	for _, algorithm := range []RateLimitAlgorithm{
+		TokenBucket{Capacity: 10, Rate: 0},
+		TokenBucket{Capacity: 10, Rate: -1},
+		FixedWindow{Limit: 10},
+	} {
+		assert.Panics(t, func() {
+			NewRateLimiter("users", NewMemoryRateLimitStore(), algorithm, KeyBySourceIP)
+		}, "%#v", algorithm)
+	}
+
+	assert.NotPanics(t, func() {
+		NewRateLimiter("users", NewMemoryRateLimitStore(), TokenBucket{Capacity: 10, Rate: 0.5}, KeyBySourceIP)
+	})
+}
+
+func TestRateLimitKeyFuncs(t *testing.T) {
+	httpAPI := testutil.CreateTestAPIGatewayV2Request("GET", "/users")
+	httpAPI.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{Claims: map[string]string{"sub": "user-1"}},
+	}
+
+	rest := testutil.CreateTestAPIGatewayRequest("GET", "/users")
+	rest.RequestContext.Identity.APIKey = "key-123"
+	rest.RequestContext.Authorizer = map[string]interface{}{"claims": map[string]interface{}{"sub": "user-2"}}
+
+	lambdaAuthorizer := testutil.CreateTestAPIGatewayV2Request("GET", "/users")
+	lambdaAuthorizer.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		Lambda: map[string]interface{}{"principalId": "user-3", "sub": "user-3"},
+	}
+
+	tests := []struct {
+		name     string
+		key      RateLimitKeyFunc
+		request  *Request
+		expected string
+		ok       bool
+	}{
+		{name: "source ip", key: KeyBySourceIP, request: &Request{SourceIP: "10.0.0.1"}, expected: "ip:10.0.0.1", ok: true},
+		{name: "missing source ip", key: KeyBySourceIP, request: &Request{}, ok: false},
+		{name: "api key header", key: KeyByAPIKey, request: &Request{Headers: map[string]string{"x-api-key": "abc"}}, expected: "apikey:abc", ok: true},
+		{name: "api gateway api key", key: KeyByAPIKey, request: NewRequestFromAPIGateway(rest), expected: "apikey:key-123", ok: true},
+		{name: "http api jwt subject", key: KeyByJWTSubject, request: NewRequestFromHTTPAPI(httpAPI), expected: "sub:user-1", ok: true},
+		{name: "cognito subject", key: KeyByJWTSubject, request: NewRequestFromAPIGateway(rest), expected: "sub:user-2", ok: true},
+		{name: "lambda authorizer subject", key: KeyByJWTSubject, request: NewRequestFromHTTPAPI(lambdaAuthorizer), expected: "sub:user-3", ok: true},
+		{name: "anonymous", key: KeyByJWTSubject, request: &Request{}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := tt.key(context.Background(), tt.request)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, key)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStore_Expiry(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	increment := func(state *RateLimitState) { state.Value++ }
+	require.NoError(t, store.Update(context.Background(), "k", time.Second, increment))
+	require.NoError(t, store.Update(context.Background(), "k", time.Second, increment))
+	assert.Equal(t, float64(2), store.entries["k"].state.Value)
+
+	now = now.Add(2 * time.Second)
+	require.NoError(t, store.Update(context.Background(), "k", time.Second, increment))
+	assert.Equal(t, float64(1), store.entries["k"].state.Value)
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Update(context.Context, string, time.Duration, func(*RateLimitState)) error {
+	return errors.New("table unavailable")
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	ok := func(ctx context.Context, request *Request) (interface{}, error) {
+		return "ok", nil
+	}
+
+	t.Run("sets headers and rejects over limit", func(t *testing.T) {
+		h := newTestHandler(t)
+		limiter := NewRateLimiter("users", NewMemoryRateLimitStore(), FixedWindow{Limit: 1, Window: time.Minute}, KeyBySourceIP)
+		wrapped := h.WrapHTTPAPI(ok, h.RateLimit(limiter))
+		ctx := testutil.CreateTestContext("test-request")
+
+		response, err := wrapped(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		assert.Equal(t, "1", response.Headers["RateLimit-Limit"])
+		assert.Equal(t, "0", response.Headers["RateLimit-Remaining"])
+		assert.Equal(t, "60", response.Headers["RateLimit-Reset"])
+
+		response, err = wrapped(ctx, testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+		require.NoError(t, err)
+		assert.Equal(t, 429, response.StatusCode)
+		assert.Equal(t, "1", response.Headers["RateLimit-Limit"])
+		assert.Equal(t, "0", response.Headers["RateLimit-Remaining"])
+		assert.NotEmpty(t, response.Headers["Retry-After"])
+
+		var body http.ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, ErrorCodeRateLimited, body.Code)
+	})
+
+	t.Run("requests without a key are not limited", func(t *testing.T) {
+		h := newTestHandler(t)
+		limiter := NewRateLimiter("users", NewMemoryRateLimitStore(), FixedWindow{Limit: 1, Window: time.Minute}, KeyByAPIKey)
+		handler := h.RateLimit(limiter)(ok)
+
+		for i := 0; i < 3; i++ {
+			_, err := handler(context.Background(), &Request{})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("store failures let requests through", func(t *testing.T) {
+		h := newTestHandler(t)
+		limiter := NewRateLimiter("users", failingRateLimitStore{}, FixedWindow{Limit: 1, Window: time.Minute}, KeyBySourceIP)
+
+		data, err := h.RateLimit(limiter)(ok)(context.Background(), &Request{SourceIP: "10.0.0.1"})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", data)
+	})
+}
@@ -0,0 +1,324 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"lambda-go-template/pkg/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// EventSource identifies the trigger that delivered a request.
+type EventSource string
+
+const (
+	// EventSourceAPIGateway is an API Gateway REST API (payload format 1.0).
+	EventSourceAPIGateway EventSource = "apigateway"
+	// EventSourceHTTPAPI is an API Gateway HTTP API (payload format 2.0).
+	EventSourceHTTPAPI EventSource = "httpapi"
+	// EventSourceALB is an Application Load Balancer target group.
+	EventSourceALB EventSource = "alb"
+	// EventSourceFunctionURL is a Lambda Function URL.
+	EventSourceFunctionURL EventSource = "function_url"
+)
+
+// Request is a protocol-agnostic view of an HTTP request delivered to a Lambda function.
+// Trigger adapters convert their events into a Request so that middleware and handlers
+// are written once and run behind any supported trigger.
+type Request struct {
+	Source                    EventSource
+	Method                    string
+	Path                      string
+	Headers                   map[string]string
+	MultiValueHeaders         map[string][]string
+	QueryParameters           map[string]string
+	MultiValueQueryParameters map[string][]string
+	PathParameters            map[string]string
+	Cookies                   []string
+	Body                      string
+	IsBase64Encoded           bool
+	SourceIP                  string
+	UserAgent                 string
+
+	// Route is the matched path template, set by Router.
+	Route string
+
+	// RawPath is the path as the client called it. Path omits the stage prefix of HTTP API
+	// events on a named stage so routes match on every stage; RawPath keeps it.
+	RawPath string
+
+	// Event holds the original trigger event for code that needs source-specific fields.
+	Event interface{}
+}
+
+// clientPath returns the path the client called, which error responses, logs and traces
+// report. It falls back to Path for requests not built by a trigger adapter.
+func (r *Request) clientPath() string {
+	if r.RawPath != "" {
+		return r.RawPath
+	}
+	return r.Path
+}
+
+// Header returns the first value of the named header, matching the name case-insensitively.
+func (r *Request) Header(name string) string {
+	return r.CanonicalHeaders().Get(name)
+}
+
+// CanonicalHeaders returns the request headers keyed by canonical name, merging the
+// single- and multi-value maps so lookups behave the same for every trigger.
+func (r *Request) CanonicalHeaders() http.Headers {
+	return http.NewHeaders(r.Headers, r.MultiValueHeaders)
+}
+
+// Query returns the query string parameters with typed accessors.
+func (r *Request) Query() Query {
+	return NewQuery(r.QueryParameters, r.MultiValueQueryParameters)
+}
+
+// PathParam returns the named path parameter, or an empty string if it is absent.
+func (r *Request) PathParam(name string) string {
+	return r.PathParameters[name]
+}
+
+// PathParamInt returns the named path parameter parsed as an integer.
+// A missing or malformed value yields a ValidationError naming the parameter.
+func (r *Request) PathParamInt(name string) (int, error) {
+	value, ok := r.PathParameters[name]
+	if !ok || value == "" {
+		return 0, NewValidationError("path parameter is required", name, value)
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, NewValidationErrorWithCause("path parameter must be an integer", name, value, err)
+	}
+
+	return parsed, nil
+}
+
+// NewRequestFromAPIGateway converts an API Gateway REST API event into a Request.
+func NewRequestFromAPIGateway(event events.APIGatewayProxyRequest) *Request {
+	return &Request{
+		Source:                    EventSourceAPIGateway,
+		Method:                    event.HTTPMethod,
+		Path:                      event.Path,
+		RawPath:                   event.Path,
+		Headers:                   event.Headers,
+		MultiValueHeaders:         event.MultiValueHeaders,
+		QueryParameters:           event.QueryStringParameters,
+		MultiValueQueryParameters: event.MultiValueQueryStringParameters,
+		PathParameters:            event.PathParameters,
+		Body:                      event.Body,
+		IsBase64Encoded:           event.IsBase64Encoded,
+		SourceIP:                  event.RequestContext.Identity.SourceIP,
+		UserAgent:                 event.RequestContext.Identity.UserAgent,
+		Event:                     event,
+	}
+}
+
+// NewRequestFromHTTPAPI converts an API Gateway HTTP API event into a Request.
+func NewRequestFromHTTPAPI(event events.APIGatewayV2HTTPRequest) *Request {
+	return &Request{
+		Source:          EventSourceHTTPAPI,
+		Method:          event.RequestContext.HTTP.Method,
+		Path:            stripStage(event.RawPath, event.RequestContext.Stage),
+		RawPath:         event.RawPath,
+		Headers:         event.Headers,
+		QueryParameters: event.QueryStringParameters,
+		PathParameters:  event.PathParameters,
+		Cookies:         event.Cookies,
+		Body:            event.Body,
+		IsBase64Encoded: event.IsBase64Encoded,
+		SourceIP:        event.RequestContext.HTTP.SourceIP,
+		UserAgent:       event.RequestContext.HTTP.UserAgent,
+		Event:           event,
+	}
+}
+
+// NewRequestFromALB converts an Application Load Balancer target group event into a Request.
+// ALB delivers query parameters still percent-encoded and, when multi-value headers are
+// enabled on the target group, populates only the multi-value maps; both are normalized here.
+func NewRequestFromALB(event events.ALBTargetGroupRequest) *Request {
+	request := &Request{
+		Source:            EventSourceALB,
+		Method:            event.HTTPMethod,
+		Path:              event.Path,
+		RawPath:           event.Path,
+		Headers:           event.Headers,
+		MultiValueHeaders: event.MultiValueHeaders,
+		Body:              event.Body,
+		IsBase64Encoded:   event.IsBase64Encoded,
+		Event:             event,
+	}
+
+	if isMultiValueALB(event) {
+		request.Headers = make(map[string]string, len(event.MultiValueHeaders))
+		for key, values := range event.MultiValueHeaders {
+			if len(values) > 0 {
+				request.Headers[key] = values[len(values)-1]
+			}
+		}
+
+		request.MultiValueQueryParameters = make(map[string][]string, len(event.MultiValueQueryStringParameters))
+		request.QueryParameters = make(map[string]string, len(event.MultiValueQueryStringParameters))
+		for key, values := range event.MultiValueQueryStringParameters {
+			name := unescapeALBQuery(key)
+			for _, value := range values {
+				request.MultiValueQueryParameters[name] = append(request.MultiValueQueryParameters[name], unescapeALBQuery(value))
+			}
+			if len(values) > 0 {
+				request.QueryParameters[name] = unescapeALBQuery(values[len(values)-1])
+			}
+		}
+	} else {
+		request.QueryParameters = make(map[string]string, len(event.QueryStringParameters))
+		for key, value := range event.QueryStringParameters {
+			request.QueryParameters[unescapeALBQuery(key)] = unescapeALBQuery(value)
+		}
+	}
+
+	// ALB forwards the client address and user agent only as headers
+	request.SourceIP = clientForwardedFor(request.CanonicalHeaders().List("X-Forwarded-For"))
+	request.UserAgent = request.Header("User-Agent")
+
+	return request
+}
+
+// NewRequestFromFunctionURL converts a Lambda Function URL event into a Request.
+func NewRequestFromFunctionURL(event events.LambdaFunctionURLRequest) *Request {
+	return &Request{
+		Source:          EventSourceFunctionURL,
+		Method:          event.RequestContext.HTTP.Method,
+		Path:            event.RawPath,
+		RawPath:         event.RawPath,
+		Headers:         event.Headers,
+		QueryParameters: event.QueryStringParameters,
+		Cookies:         event.Cookies,
+		Body:            event.Body,
+		IsBase64Encoded: event.IsBase64Encoded,
+		SourceIP:        event.RequestContext.HTTP.SourceIP,
+		UserAgent:       event.RequestContext.HTTP.UserAgent,
+		Event:           event,
+	}
+}
+
+// ToAPIGatewayResponse converts a response into the API Gateway REST API response format,
+// which carries cookies as Set-Cookie values in multiValueHeaders.
+func ToAPIGatewayResponse(response http.Response) http.Response {
+	if len(response.Cookies) == 0 {
+		return response
+	}
+
+	multiValueHeaders := make(map[string][]string, len(response.MultiValueHeaders)+1)
+	for key, values := range response.MultiValueHeaders {
+		multiValueHeaders[key] = values
+	}
+	multiValueHeaders["Set-Cookie"] = append(append([]string(nil), multiValueHeaders["Set-Cookie"]...), response.Cookies...)
+
+	response.MultiValueHeaders = multiValueHeaders
+	response.Cookies = nil
+	return response
+}
+
+// ToHTTPAPIResponse converts a response into the API Gateway HTTP API response format.
+// Multi-value headers are joined with commas and cookies are returned in Cookies.
+func ToHTTPAPIResponse(response http.Response) events.APIGatewayV2HTTPResponse {
+	headers, cookies := response.JoinedHeaders()
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode:      response.StatusCode,
+		Headers:         headers,
+		Body:            response.Body,
+		IsBase64Encoded: response.IsBase64Encoded,
+		Cookies:         cookies,
+	}
+}
+
+// ToALBResponse converts a response into the Application Load Balancer response format.
+// When multiValue is true the headers are returned in multiValueHeaders, which is the only
+// header field ALB reads for target groups with multi-value headers enabled. Otherwise
+// only one Set-Cookie header can be sent, so only the last cookie reaches the client.
+func ToALBResponse(response http.Response, multiValue bool) events.ALBTargetGroupResponse {
+	albResponse := events.ALBTargetGroupResponse{
+		StatusCode:        response.StatusCode,
+		StatusDescription: fmt.Sprintf("%d %s", response.StatusCode, http.GetStatusText(response.StatusCode)),
+		Body:              response.Body,
+		IsBase64Encoded:   response.IsBase64Encoded,
+	}
+
+	if multiValue {
+		albResponse.MultiValueHeaders = response.AllHeaders()
+		return albResponse
+	}
+
+	headers, cookies := response.JoinedHeaders()
+	if len(cookies) > 0 {
+		joined := make(map[string]string, len(headers)+1)
+		for key, value := range headers {
+			joined[key] = value
+		}
+		joined["Set-Cookie"] = cookies[len(cookies)-1]
+		headers = joined
+	}
+	albResponse.Headers = headers
+
+	return albResponse
+}
+
+// ToFunctionURLResponse converts a response into the Lambda Function URL response format.
+// Multi-value headers are joined with commas and cookies are returned in Cookies.
+func ToFunctionURLResponse(response http.Response) events.LambdaFunctionURLResponse {
+	headers, cookies := response.JoinedHeaders()
+	return events.LambdaFunctionURLResponse{
+		StatusCode:      response.StatusCode,
+		Headers:         headers,
+		Body:            response.Body,
+		IsBase64Encoded: response.IsBase64Encoded,
+		Cookies:         cookies,
+	}
+}
+
+// clientForwardedFor returns the client address from the X-Forwarded-For entries of an ALB
+// request. ALB appends the address it received the connection from, so only the last
+// entry is trustworthy; earlier ones are whatever the client sent.
+func clientForwardedFor(entries []string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	return entries[len(entries)-1]
+}
+
+// stripStage removes a named HTTP API stage prefix from a raw path so routes match
+// the same paths regardless of the stage the API is deployed to.
+func stripStage(path, stage string) string {
+	if stage == "" || stage == "$default" {
+		return path
+	}
+
+	prefix := "/" + stage
+	if path == prefix {
+		return "/"
+	}
+	if strings.HasPrefix(path, prefix+"/") {
+		return strings.TrimPrefix(path, prefix)
+	}
+	return path
+}
+
+// isMultiValueALB reports whether the event came from a target group with multi-value headers enabled.
+func isMultiValueALB(event events.ALBTargetGroupRequest) bool {
+	return len(event.MultiValueHeaders) > 0 || len(event.MultiValueQueryStringParameters) > 0
+}
+
+// unescapeALBQuery decodes a query string key or value as delivered by ALB, falling back
+// to the raw value when it is not valid percent-encoding.
+func unescapeALBQuery(value string) string {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
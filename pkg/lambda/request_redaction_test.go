@@ -0,0 +1,122 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/observability"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor := observability.NewRedactor(observability.RedactionConfig{
+		Fields:    []string{"ssn"},
+		Patterns:  []*regexp.Regexp{regexp.MustCompile(`\b\d{13,16}\b`)},
+		BodyPaths: []string{"user.email", "cards[*].holder"},
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		redacted := redactor.RedactValues(map[string][]string{
+			"Authorization": {"Bearer abc.def.ghi"},
+			"X-Api-Key":     {"secret"},
+			"Cookie":        {"session=1"},
+			"Accept":        {"application/json"},
+		})
+
+		assert.Equal(t, map[string][]string{
+			"Authorization": {observability.RedactedValue},
+			"X-Api-Key":     {observability.RedactedValue},
+			"Cookie":        {observability.RedactedValue},
+			"Accept":        {"application/json"},
+		}, redacted)
+	})
+
+	t.Run("field names match ignoring case and separators", func(t *testing.T) {
+		assert.True(t, redactor.IsSensitive("x_api_key"))
+		assert.True(t, redactor.IsSensitive("Access-Token"))
+		assert.True(t, redactor.IsSensitive("SSN"))
+		assert.False(t, redactor.IsSensitive("limit"))
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		assert.Equal(t, "card "+observability.RedactedValue+" declined", redactor.RedactField("note", "card 4111111111111111 declined"))
+	})
+
+	t.Run("JSON bodies", func(t *testing.T) {
+		body := `{"user":{"name":"Ada","email":"ada@example.com","password":"hunter2"},` +
+			`"cards":[{"holder":"Ada","number":"4111111111111111","cvc":123}],"tokens":{"refresh_token":"r1"},"count":2}`
+
+		var redacted map[string]interface{}
+		require.NoError(t, json.Unmarshal(redactor.RedactJSON([]byte(body)), &redacted))
+
+		assert.Equal(t, map[string]interface{}{
+			"user":   map[string]interface{}{"name": "Ada", "email": observability.RedactedValue, "password": observability.RedactedValue},
+			"cards":  []interface{}{map[string]interface{}{"holder": observability.RedactedValue, "number": observability.RedactedValue, "cvc": float64(123)}},
+			"tokens": map[string]interface{}{"refresh_token": observability.RedactedValue},
+			"count":  float64(2),
+		}, redacted)
+	})
+
+	t.Run("invalid JSON bodies", func(t *testing.T) {
+		assert.Equal(t, "pan="+observability.RedactedValue, string(redactor.RedactJSON([]byte("pan=4111111111111111"))))
+	})
+
+	t.Run("hash mode", func(t *testing.T) {
+		hashing := observability.NewRedactor(observability.RedactionConfig{Mode: observability.RedactionModeHash, HashKey: "pepper"})
+		other := observability.NewRedactor(observability.RedactionConfig{Mode: observability.RedactionModeHash, HashKey: "salt"})
+
+		first := hashing.RedactField("X-Api-Key", "secret")
+		assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, first)
+		assert.Equal(t, first, hashing.RedactField("X-Api-Key", "secret"))
+		assert.NotEqual(t, first, hashing.RedactField("X-Api-Key", "other"))
+		assert.NotEqual(t, first, other.RedactField("X-Api-Key", "secret"))
+	})
+}
+
+func TestHandler_LoggingRedactsRequestData(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	cfg := testutil.TestConfig()
+	cfg.RedactFields = []string{"session"}
+	cfg.RedactBodyPaths = []string{"profile.phone"}
+	h := NewHandler(cfg, &observability.Logger{Logger: zap.New(core)}, testutil.TestTracer())
+
+	handler := Chain(func(ctx context.Context, request *Request) (interface{}, error) {
+		return "ok", nil
+	}, h.Logging())
+
+	_, err := handler(context.Background(), &Request{
+		Method: "POST",
+		Path:   "/users",
+		Headers: map[string]string{
+			"authorization": "Bearer abc.def.ghi",
+			"x-api-key":     "secret",
+			"content-type":  "application/json",
+		},
+		QueryParameters: map[string]string{"session": "s-123", "limit": "10"},
+		Body:            `{"name":"Ada","password":"hunter2","profile":{"phone":"555-0100"}}`,
+	})
+	require.NoError(t, err)
+
+	entries := logs.FilterMessage("Processing request").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, map[string][]string{
+		"Authorization": {observability.RedactedValue},
+		"X-Api-Key":     {observability.RedactedValue},
+		"Content-Type":  {"application/json"},
+	}, fields["headers"])
+	assert.Equal(t, map[string][]string{"session": {observability.RedactedValue}, "limit": {"10"}}, fields["query"])
+
+	bodies := logs.FilterMessage("Request body").All()
+	require.Len(t, bodies, 1)
+	assert.JSONEq(t,
+		`{"name":"Ada","password":"[REDACTED]","profile":{"phone":"[REDACTED]"}}`,
+		bodies[0].ContextMap()["body"].(string))
+}
@@ -0,0 +1,326 @@
+package lambda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lambda-go-template/pkg/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestFromAPIGateway(t *testing.T) {
+	event := events.APIGatewayProxyRequest{
+		HTTPMethod:            "POST",
+		Path:                  "/users",
+		Headers:               map[string]string{"Content-Type": "application/json"},
+		QueryStringParameters: map[string]string{"limit": "10"},
+		PathParameters:        map[string]string{"id": "1"},
+		Body:                  `{"name":"test"}`,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  "10.0.0.1",
+				UserAgent: "test-agent",
+			},
+		},
+	}
+
+	request := NewRequestFromAPIGateway(event)
+
+	assert.Equal(t, EventSourceAPIGateway, request.Source)
+	assert.Equal(t, "POST", request.Method)
+	assert.Equal(t, "/users", request.Path)
+	assert.Equal(t, "10", request.QueryParameters["limit"])
+	assert.Equal(t, "1", request.PathParameters["id"])
+	assert.Equal(t, `{"name":"test"}`, request.Body)
+	assert.Equal(t, "10.0.0.1", request.SourceIP)
+	assert.Equal(t, "test-agent", request.UserAgent)
+	assert.Equal(t, event, request.Event)
+}
+
+func TestNewRequestFromHTTPAPI(t *testing.T) {
+	event := events.APIGatewayV2HTTPRequest{
+		RawPath: "/hello",
+		Headers: map[string]string{"content-type": "application/json"},
+		Cookies: []string{"session=abc"},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    "GET",
+				SourceIP:  "10.0.0.2",
+				UserAgent: "test-agent",
+			},
+		},
+	}
+
+	request := NewRequestFromHTTPAPI(event)
+
+	assert.Equal(t, EventSourceHTTPAPI, request.Source)
+	assert.Equal(t, "GET", request.Method)
+	assert.Equal(t, "/hello", request.Path)
+	assert.Equal(t, []string{"session=abc"}, request.Cookies)
+	assert.Equal(t, "10.0.0.2", request.SourceIP)
+	assert.Equal(t, "test-agent", request.UserAgent)
+}
+
+func TestNewRequestFromHTTPAPI_StripsStage(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawPath  string
+		stage    string
+		expected string
+	}{
+		{name: "default stage", rawPath: "/users", stage: "$default", expected: "/users"},
+		{name: "named stage prefix", rawPath: "/prod/users/1", stage: "prod", expected: "/users/1"},
+		{name: "stage root", rawPath: "/prod", stage: "prod", expected: "/"},
+		{name: "path sharing stage prefix", rawPath: "/products", stage: "prod", expected: "/products"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := events.APIGatewayV2HTTPRequest{
+				RawPath:        tt.rawPath,
+				RequestContext: events.APIGatewayV2HTTPRequestContext{Stage: tt.stage},
+			}
+			request := NewRequestFromHTTPAPI(event)
+			assert.Equal(t, tt.expected, request.Path)
+			assert.Equal(t, tt.rawPath, request.RawPath)
+		})
+	}
+}
+
+func TestNewRequestFromALB(t *testing.T) {
+	event := events.ALBTargetGroupRequest{
+		HTTPMethod: "GET",
+		Path:       "/users",
+		Headers: map[string]string{
+			"x-forwarded-for": "203.0.113.7",
+			"user-agent":      "curl/8.0",
+		},
+	}
+
+	request := NewRequestFromALB(event)
+
+	assert.Equal(t, EventSourceALB, request.Source)
+	assert.Equal(t, "GET", request.Method)
+	assert.Equal(t, "203.0.113.7", request.SourceIP)
+	assert.Equal(t, "curl/8.0", request.UserAgent)
+}
+
+func TestNewRequestFromALB_ForgedForwardedFor(t *testing.T) {
+	// The client sent its own X-Forwarded-For header; ALB appended the real address
+	single := NewRequestFromALB(events.ALBTargetGroupRequest{
+		Headers: map[string]string{"x-forwarded-for": "10.0.0.1, 198.51.100.9"},
+	})
+	assert.Equal(t, "198.51.100.9", single.SourceIP)
+
+	multi := NewRequestFromALB(events.ALBTargetGroupRequest{
+		MultiValueHeaders: map[string][]string{"x-forwarded-for": {"10.0.0.1", "172.16.0.5, 198.51.100.9"}},
+	})
+	assert.Equal(t, "198.51.100.9", multi.SourceIP)
+
+	// Changing the forged entries does not escape per-IP rate limiting
+	limiter := NewRateLimiter("per-ip", NewMemoryRateLimitStore(), FixedWindow{Limit: 1, Window: time.Minute}, KeyBySourceIP)
+	first, _, err := limiter.Take(context.Background(), single)
+	require.NoError(t, err)
+	assert.True(t, first.Allowed)
+
+	second, _, err := limiter.Take(context.Background(), multi)
+	require.NoError(t, err)
+	assert.False(t, second.Allowed)
+}
+
+func TestNewRequestFromFunctionURL(t *testing.T) {
+	event := events.LambdaFunctionURLRequest{
+		RawPath: "/hello",
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:   "GET",
+				SourceIP: "10.0.0.3",
+			},
+		},
+	}
+
+	request := NewRequestFromFunctionURL(event)
+
+	assert.Equal(t, EventSourceFunctionURL, request.Source)
+	assert.Equal(t, "GET", request.Method)
+	assert.Equal(t, "/hello", request.Path)
+	assert.Equal(t, "10.0.0.3", request.SourceIP)
+}
+
+func TestRequest_Header(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *Request
+		header   string
+		expected string
+	}{
+		{
+			name:     "exact match",
+			request:  &Request{Headers: map[string]string{"Content-Type": "application/json"}},
+			header:   "Content-Type",
+			expected: "application/json",
+		},
+		{
+			name:     "lowercase header",
+			request:  &Request{Headers: map[string]string{"content-type": "application/json"}},
+			header:   "Content-Type",
+			expected: "application/json",
+		},
+		{
+			name:     "multi-value header",
+			request:  &Request{MultiValueHeaders: map[string][]string{"accept": {"text/html", "application/json"}}},
+			header:   "Accept",
+			expected: "text/html",
+		},
+		{
+			name:     "missing header",
+			request:  &Request{},
+			header:   "Authorization",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.request.Header(tt.header))
+		})
+	}
+}
+
+func TestRequest_CanonicalHeaders(t *testing.T) {
+	t.Run("HTTP API lowercase names", func(t *testing.T) {
+		request := NewRequestFromHTTPAPI(events.APIGatewayV2HTTPRequest{
+			Headers: map[string]string{"user-agent": "curl/8.0", "accept": "text/html, application/json", "x-forwarded-for": "1.1.1.1, 2.2.2.2"},
+		})
+		headers := request.CanonicalHeaders()
+
+		assert.Equal(t, "curl/8.0", headers.Get("User-Agent"))
+		assert.Equal(t, "curl/8.0", headers.Get("USER-AGENT"))
+		assert.Equal(t, []string{"text/html", "application/json"}, headers.List("Accept"))
+		assert.Equal(t, []string{"1.1.1.1", "2.2.2.2"}, headers.List("X-Forwarded-For"))
+		assert.True(t, headers.Has("x-forwarded-for"))
+		assert.False(t, headers.Has("Authorization"))
+	})
+
+	t.Run("REST API multi-value headers win", func(t *testing.T) {
+		request := NewRequestFromAPIGateway(events.APIGatewayProxyRequest{
+			Headers:           map[string]string{"Accept": "application/json", "X-Api-Key": "secret"},
+			MultiValueHeaders: map[string][]string{"Accept": {"text/html", "application/json"}, "X-Api-Key": {"secret"}},
+		})
+		headers := request.CanonicalHeaders()
+
+		assert.Equal(t, []string{"text/html", "application/json"}, headers.Values("accept"))
+		assert.Equal(t, []string{"text/html", "application/json"}, headers.List("Accept"))
+		assert.Equal(t, "secret", headers.Get("x-api-key"))
+	})
+
+	t.Run("canonical spelling first", func(t *testing.T) {
+		headers := (&Request{Headers: map[string]string{"content-type": "application/json", "Content-Type": "text/plain"}}).CanonicalHeaders()
+
+		assert.Equal(t, []string{"text/plain", "application/json"}, headers.Values("Content-Type"))
+	})
+}
+
+func TestNewRequestFromALB_MultiValue(t *testing.T) {
+	event := events.ALBTargetGroupRequest{
+		HTTPMethod: "GET",
+		Path:       "/users",
+		MultiValueHeaders: map[string][]string{
+			"x-forwarded-for": {"203.0.113.7"},
+			"accept":          {"application/json"},
+		},
+		MultiValueQueryStringParameters: map[string][]string{
+			"name": {"John%20Doe"},
+			"tag":  {"a", "b%2Bc"},
+		},
+	}
+
+	request := NewRequestFromALB(event)
+
+	assert.Equal(t, "application/json", request.Headers["accept"])
+	assert.Equal(t, "203.0.113.7", request.SourceIP)
+	assert.Equal(t, "John Doe", request.QueryParameters["name"])
+	assert.Equal(t, "b+c", request.QueryParameters["tag"])
+	assert.Equal(t, []string{"a", "b+c"}, request.MultiValueQueryParameters["tag"])
+}
+
+func TestToALBResponse(t *testing.T) {
+	response := http.Response{
+		StatusCode: 404,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"message":"not found"}`,
+	}
+
+	t.Run("single value headers", func(t *testing.T) {
+		albResponse := ToALBResponse(response, false)
+
+		assert.Equal(t, 404, albResponse.StatusCode)
+		assert.Equal(t, "404 Not Found", albResponse.StatusDescription)
+		assert.Equal(t, "application/json", albResponse.Headers["Content-Type"])
+		assert.Nil(t, albResponse.MultiValueHeaders)
+		assert.Equal(t, `{"message":"not found"}`, albResponse.Body)
+	})
+
+	t.Run("multi-value headers", func(t *testing.T) {
+		albResponse := ToALBResponse(response, true)
+
+		assert.Nil(t, albResponse.Headers)
+		assert.Equal(t, []string{"application/json"}, albResponse.MultiValueHeaders["Content-Type"])
+	})
+}
+
+func TestResponseConversions_CookiesAndMultiValueHeaders(t *testing.T) {
+	response := http.Response{
+		StatusCode:        200,
+		Headers:           map[string]string{"Content-Type": "text/csv", "Link": "</users?page=1>; rel=\"first\""},
+		MultiValueHeaders: map[string][]string{"Link": {"</users?page=3>; rel=\"next\""}},
+		Body:              "aWQsbmFtZQo=",
+		IsBase64Encoded:   true,
+		Cookies:           []string{"session=abc; Path=/; HttpOnly", "theme=dark"},
+	}
+
+	t.Run("API Gateway REST", func(t *testing.T) {
+		restResponse := ToAPIGatewayResponse(response)
+
+		assert.Equal(t, response.Headers, restResponse.Headers)
+		assert.Equal(t, []string{"session=abc; Path=/; HttpOnly", "theme=dark"}, restResponse.MultiValueHeaders["Set-Cookie"])
+		assert.Equal(t, []string{"</users?page=3>; rel=\"next\""}, restResponse.MultiValueHeaders["Link"])
+		assert.Nil(t, restResponse.Cookies)
+		assert.True(t, restResponse.IsBase64Encoded)
+	})
+
+	t.Run("API Gateway HTTP API", func(t *testing.T) {
+		v2Response := ToHTTPAPIResponse(response)
+
+		assert.Equal(t, "</users?page=1>; rel=\"first\", </users?page=3>; rel=\"next\"", v2Response.Headers["Link"])
+		assert.Equal(t, response.Cookies, v2Response.Cookies)
+		assert.NotContains(t, v2Response.Headers, "Set-Cookie")
+		assert.True(t, v2Response.IsBase64Encoded)
+		assert.Equal(t, response.Body, v2Response.Body)
+	})
+
+	t.Run("Function URL", func(t *testing.T) {
+		urlResponse := ToFunctionURLResponse(response)
+
+		assert.Equal(t, response.Cookies, urlResponse.Cookies)
+		assert.True(t, urlResponse.IsBase64Encoded)
+	})
+
+	t.Run("ALB multi-value headers", func(t *testing.T) {
+		albResponse := ToALBResponse(response, true)
+
+		assert.Equal(t, response.Cookies, albResponse.MultiValueHeaders["Set-Cookie"])
+		assert.Equal(t, []string{"</users?page=1>; rel=\"first\"", "</users?page=3>; rel=\"next\""}, albResponse.MultiValueHeaders["Link"])
+	})
+
+	t.Run("ALB single value headers", func(t *testing.T) {
+		albResponse := ToALBResponse(response, false)
+
+		assert.Equal(t, "theme=dark", albResponse.Headers["Set-Cookie"])
+		assert.NotContains(t, response.Headers, "Set-Cookie")
+	})
+}
@@ -0,0 +1,221 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"lambda-go-template/pkg/observability"
+)
+
+// RetryClassifier decides whether a failed attempt is retried. It reports false as its
+// second result when it does not apply to the error.
+type RetryClassifier func(err error) (retry bool, ok bool)
+
+// RetryErrorType creates a RetryClassifier for errors of type E anywhere in the error chain.
+func RetryErrorType[E error](retry bool) RetryClassifier {
+	return func(err error) (bool, bool) {
+		var target E
+		if errors.As(err, &target) {
+			return retry, true
+		}
+		return false, false
+	}
+}
+
+// RetryPolicy configures a Retrier.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the wait before the first retry. The bound
+	// grows by Multiplier on every retry up to MaxBackoff, and the actual wait is drawn
+	// uniformly below it (full jitter).
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// AttemptTimeout bounds each attempt. Zero leaves attempts bounded only by ctx.
+	AttemptTimeout time.Duration
+	// DeadlineMargin is the time left before the context deadline at which no further
+	// attempts are started, leaving room to build the response and flush telemetry.
+	DeadlineMargin time.Duration
+	// Classifiers decide which errors are retried, newest first. Errors no classifier
+	// applies to are retried when IsRetryableError reports true.
+	Classifiers []RetryClassifier
+}
+
+// DefaultRetryPolicy returns a policy with three attempts and backoff from 100ms to 2s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		DeadlineMargin: 500 * time.Millisecond,
+	}
+}
+
+// Retrier runs operations with exponential backoff, recording each attempt as a trace subsegment.
+type Retrier struct {
+	policy RetryPolicy
+	logger *observability.Logger
+	tracer *observability.Tracer
+	jitter func(limit time.Duration) time.Duration
+}
+
+// NewRetrier creates a retrier for the given policy.
+func NewRetrier(policy RetryPolicy, logger *observability.Logger, tracer *observability.Tracer) *Retrier {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 1
+	}
+
+	return &Retrier{
+		policy: policy,
+		logger: logger,
+		tracer: tracer,
+		jitter: func(limit time.Duration) time.Duration {
+			if limit <= 0 {
+				return 0
+			}
+			return rand.N(limit)
+		},
+	}
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, runs out of attempts or
+// would run past the context deadline. The error of the last attempt is returned.
+func (r *Retrier) Do(ctx context.Context, name string, fn func(context.Context) error) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		err = r.attempt(ctx, name, attempt, fn)
+		if err == nil {
+			return nil
+		}
+
+		if !r.shouldRetry(err) {
+			return err
+		}
+
+		if attempt >= r.policy.MaxAttempts {
+			r.logger.WithFields(map[string]interface{}{
+				"operation": name,
+				"attempts":  attempt,
+				"error":     err.Error(),
+			}).WithContext(ctx).Warn("Retries exhausted")
+			return err
+		}
+
+		wait := r.backoff(attempt, err)
+		if !r.hasBudget(ctx, wait) {
+			r.logger.WithFields(map[string]interface{}{
+				"operation": name,
+				"attempts":  attempt,
+				"error":     err.Error(),
+			}).WithContext(ctx).Warn("Not retrying: deadline too close")
+			return err
+		}
+
+		r.logger.WithFields(map[string]interface{}{
+			"operation":  name,
+			"attempt":    attempt,
+			"backoff_ms": wait.Milliseconds(),
+			"error":      err.Error(),
+		}).WithContext(ctx).Debug("Retrying after failed attempt")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Retry runs fn with the retrier and returns its result.
+func Retry[T any](ctx context.Context, r *Retrier, name string, fn func(context.Context) (T, error)) (T, error) {
+	var result T
+	err := r.Do(ctx, name, func(ctx context.Context) error {
+		value, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		result = value
+		return nil
+	})
+	return result, err
+}
+
+// attempt runs a single attempt inside its own subsegment and per-attempt timeout.
+func (r *Retrier) attempt(ctx context.Context, name string, attempt int, fn func(context.Context) error) error {
+	attemptCtx, seg := r.tracer.StartSubsegment(ctx, name)
+	r.tracer.AddAnnotation(attemptCtx, "attempt", attempt)
+
+	if r.policy.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(attemptCtx, r.policy.AttemptTimeout)
+		defer cancel()
+	}
+
+	err := fn(attemptCtx)
+	// An attempt cut short by its own timeout is a timeout, even if fn returned the bare context error
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && !IsTimeoutError(err) {
+		err = fmt.Errorf("%s attempt %d: %w: %w", name, attempt,
+			NewTimeoutError(fmt.Sprintf("%s attempt timed out", name), r.policy.AttemptTimeout), err)
+	}
+
+	r.tracer.Close(seg, err)
+	return err
+}
+
+// shouldRetry applies the classifiers, newest first, falling back to IsRetryableError.
+func (r *Retrier) shouldRetry(err error) bool {
+	for i := len(r.policy.Classifiers) - 1; i >= 0; i-- {
+		if retry, ok := r.policy.Classifiers[i](err); ok {
+			return retry
+		}
+	}
+	return IsRetryableError(err)
+}
+
+// backoff returns the wait before the retry following the given attempt. A RetryAfter on
+// an ExternalServiceError raises the wait to at least that long.
+func (r *Retrier) backoff(attempt int, err error) time.Duration {
+	limit := float64(r.policy.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		limit *= r.policy.Multiplier
+		if r.policy.MaxBackoff > 0 && limit >= float64(r.policy.MaxBackoff) {
+			break
+		}
+	}
+	if r.policy.MaxBackoff > 0 && limit > float64(r.policy.MaxBackoff) {
+		limit = float64(r.policy.MaxBackoff)
+	}
+
+	wait := r.jitter(time.Duration(limit))
+
+	var extErr *ExternalServiceError
+	if errors.As(err, &extErr) && extErr.RetryAfter > wait {
+		wait = extErr.RetryAfter
+	}
+
+	return wait
+}
+
+// hasBudget reports whether waiting and starting another attempt leaves at least the
+// deadline margin before the context deadline.
+func (r *Retrier) hasBudget(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+
+	needed := wait + r.policy.DeadlineMargin + r.policy.AttemptTimeout
+	return time.Until(deadline) > needed
+}
@@ -0,0 +1,149 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRetrier(t *testing.T, policy RetryPolicy) *Retrier {
+	retrier := NewRetrier(policy, testutil.TestLogger(t), testutil.TestTracer())
+	retrier.jitter = func(limit time.Duration) time.Duration { return limit }
+	return retrier
+}
+
+func TestRetrier_Do(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+	retryable := NewExternalServiceError("payments", "throttled", 429, true, nil)
+	permanent := NewExternalServiceError("payments", "card declined", 402, false, nil)
+
+	tests := []struct {
+		name          string
+		policy        RetryPolicy
+		errs          []error
+		expectedCalls int
+		expectedErr   error
+	}{
+		{name: "succeeds first time", policy: policy, errs: []error{nil}, expectedCalls: 1},
+		{name: "succeeds after retryable errors", policy: policy, errs: []error{retryable, retryable, nil}, expectedCalls: 3},
+		{name: "stops on non-retryable error", policy: policy, errs: []error{permanent}, expectedCalls: 1, expectedErr: permanent},
+		{name: "exhausts attempts", policy: policy, errs: []error{retryable, retryable, retryable, nil}, expectedCalls: 3, expectedErr: retryable},
+		{
+			name: "classifier overrides default",
+			policy: func() RetryPolicy {
+				p := policy
+				p.Classifiers = []RetryClassifier{RetryErrorType[*ConflictError](true)}
+				return p
+			}(),
+			errs:          []error{NewConflictError("version mismatch"), nil},
+			expectedCalls: 2,
+		},
+		{
+			name: "newest classifier wins",
+			policy: func() RetryPolicy {
+				p := policy
+				p.Classifiers = []RetryClassifier{
+					RetryErrorType[*ExternalServiceError](true),
+					RetryErrorType[*ExternalServiceError](false),
+				}
+				return p
+			}(),
+			errs:          []error{retryable, nil},
+			expectedCalls: 1,
+			expectedErr:   retryable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := newTestRetrier(t, tt.policy).Do(context.Background(), "charge", func(ctx context.Context) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			assert.Equal(t, tt.expectedCalls, calls)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetrier_Backoff(t *testing.T) {
+	retrier := newTestRetrier(t, RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3})
+	err := errors.New("failed")
+
+	assert.Equal(t, 100*time.Millisecond, retrier.backoff(1, err))
+	assert.Equal(t, 300*time.Millisecond, retrier.backoff(2, err))
+	assert.Equal(t, 900*time.Millisecond, retrier.backoff(3, err))
+	assert.Equal(t, time.Second, retrier.backoff(4, err))
+
+	throttled := NewExternalServiceError("payments", "throttled", 429, true, nil).WithRetryAfter(5 * time.Second)
+	assert.Equal(t, 5*time.Second, retrier.backoff(1, throttled))
+
+	retrier.jitter = func(limit time.Duration) time.Duration { return 0 }
+	assert.Equal(t, time.Duration(0), retrier.backoff(4, err))
+}
+
+func TestRetrier_AttemptTimeout(t *testing.T) {
+	retrier := newTestRetrier(t, RetryPolicy{MaxAttempts: 2, AttemptTimeout: 10 * time.Millisecond})
+
+	calls := 0
+	err := retrier.Do(context.Background(), "slow", func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.Equal(t, 2, calls, "attempt timeouts are retryable")
+	assert.True(t, IsTimeoutError(err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRetrier_StopsBeforeDeadline(t *testing.T) {
+	retrier := newTestRetrier(t, RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 50 * time.Millisecond,
+		Multiplier:     1,
+		DeadlineMargin: 50 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := retrier.Do(ctx, "charge", func(ctx context.Context) error {
+		calls++
+		return NewExternalServiceError("payments", "unavailable", 503, true, nil)
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, ctx.Err(), "retrier must give up before the deadline")
+}
+
+func TestRetry(t *testing.T) {
+	retrier := newTestRetrier(t, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	calls := 0
+	value, err := Retry(context.Background(), retrier, "lookup", func(ctx context.Context) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", NewTimeoutError("lookup", time.Millisecond)
+		}
+		return "found", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "found", value)
+}
@@ -0,0 +1,235 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MethodAny matches a route regardless of the request method.
+const MethodAny = "ANY"
+
+// Router dispatches requests to handlers registered by HTTP method and path template.
+// Templates use {name} to capture a single path segment and {name+} to capture the
+// remainder of the path, mirroring API Gateway route syntax.
+type Router struct {
+	routes []*route
+}
+
+// route is a registered method and path template with its middleware-wrapped handler.
+type route struct {
+	method   string
+	pattern  string
+	segments []routeSegment
+	static   int
+	handler  RequestHandlerFunc
+}
+
+// routeSegment is one slash-separated element of a path template.
+type routeSegment struct {
+	value  string
+	param  bool
+	greedy bool
+}
+
+// NewRouter creates a new empty router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers a handler for the method and path template. Middlewares apply only
+// to this route, with the first middleware outermost. Handle panics if the template is
+// malformed, since routes are registered once at cold start.
+func (r *Router) Handle(method, pattern string, handlerFunc RequestHandlerFunc, middlewares ...Middleware) {
+	segments, err := parseRoutePattern(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("invalid route %s %s: %v", method, pattern, err))
+	}
+
+	rt := &route{
+		method:   strings.ToUpper(method),
+		pattern:  pattern,
+		segments: segments,
+		handler:  Chain(handlerFunc, middlewares...),
+	}
+	for _, segment := range segments {
+		if !segment.param {
+			rt.static++
+		}
+	}
+
+	r.routes = append(r.routes, rt)
+}
+
+// GET registers a handler for GET requests.
+func (r *Router) GET(pattern string, handlerFunc RequestHandlerFunc, middlewares ...Middleware) {
+	r.Handle("GET", pattern, handlerFunc, middlewares...)
+}
+
+// POST registers a handler for POST requests.
+func (r *Router) POST(pattern string, handlerFunc RequestHandlerFunc, middlewares ...Middleware) {
+	r.Handle("POST", pattern, handlerFunc, middlewares...)
+}
+
+// PUT registers a handler for PUT requests.
+func (r *Router) PUT(pattern string, handlerFunc RequestHandlerFunc, middlewares ...Middleware) {
+	r.Handle("PUT", pattern, handlerFunc, middlewares...)
+}
+
+// PATCH registers a handler for PATCH requests.
+func (r *Router) PATCH(pattern string, handlerFunc RequestHandlerFunc, middlewares ...Middleware) {
+	r.Handle("PATCH", pattern, handlerFunc, middlewares...)
+}
+
+// DELETE registers a handler for DELETE requests.
+func (r *Router) DELETE(pattern string, handlerFunc RequestHandlerFunc, middlewares ...Middleware) {
+	r.Handle("DELETE", pattern, handlerFunc, middlewares...)
+}
+
+// Serve dispatches the request to the best matching route. It is a RequestHandlerFunc and
+// can be passed directly to any Wrap entrypoint. A path that matches a route registered for
+// other methods yields a MethodNotAllowedError listing them; an unknown path yields a NotFoundError.
+// When several routes match, the one with the most static segments wins.
+func (r *Router) Serve(ctx context.Context, request *Request) (interface{}, error) {
+	pathSegments := splitPath(request.Path)
+
+	var matched *route
+	var matchedParams map[string]string
+	var allowed []string
+
+	for _, rt := range r.routes {
+		params, ok := rt.match(pathSegments)
+		if !ok {
+			continue
+		}
+
+		if rt.method != MethodAny && rt.method != request.Method {
+			if !containsString(allowed, rt.method) {
+				allowed = append(allowed, rt.method)
+			}
+			continue
+		}
+
+		if matched == nil || rt.static > matched.static {
+			matched = rt
+			matchedParams = params
+		}
+	}
+
+	if matched == nil {
+		if len(allowed) > 0 {
+			return nil, NewMethodNotAllowedError(request.Method, allowed)
+		}
+		return nil, NewNotFoundError(fmt.Sprintf("no route for %s %s", request.Method, request.Path))
+	}
+
+	// Copy the request so path parameters do not leak into the caller's maps
+	routed := *request
+	routed.Route = matched.pattern
+	routed.PathParameters = make(map[string]string, len(request.PathParameters)+len(matchedParams))
+	for key, value := range request.PathParameters {
+		routed.PathParameters[key] = value
+	}
+	for key, value := range matchedParams {
+		routed.PathParameters[key] = value
+	}
+
+	return matched.handler(ctx, &routed)
+}
+
+// match reports whether the path segments satisfy the route template and returns the captured parameters.
+func (rt *route) match(pathSegments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	for i, segment := range rt.segments {
+		if segment.greedy {
+			if i >= len(pathSegments) {
+				return nil, false
+			}
+			params[segment.value] = strings.Join(pathSegments[i:], "/")
+			return params, true
+		}
+
+		if i >= len(pathSegments) {
+			return nil, false
+		}
+
+		if segment.param {
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+			params[segment.value] = pathSegments[i]
+			continue
+		}
+
+		if segment.value != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	if len(pathSegments) != len(rt.segments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// parseRoutePattern splits a path template into segments and validates its parameters.
+func parseRoutePattern(pattern string) ([]routeSegment, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("pattern must start with '/'")
+	}
+
+	parts := splitPath(pattern)
+	segments := make([]routeSegment, 0, len(parts))
+	seen := make(map[string]bool)
+
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			if strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("malformed segment %q", part)
+			}
+			segments = append(segments, routeSegment{value: part})
+			continue
+		}
+
+		name := part[1 : len(part)-1]
+		greedy := strings.HasSuffix(name, "+")
+		name = strings.TrimSuffix(name, "+")
+
+		if name == "" {
+			return nil, fmt.Errorf("empty parameter name in segment %q", part)
+		}
+		if greedy && i != len(parts)-1 {
+			return nil, fmt.Errorf("greedy parameter %q must be the last segment", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate parameter %q", name)
+		}
+		seen[name] = true
+
+		segments = append(segments, routeSegment{value: name, param: true, greedy: greedy})
+	}
+
+	return segments, nil
+}
+
+// splitPath splits a URL path into segments, ignoring leading and trailing slashes.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
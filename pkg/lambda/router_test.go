@@ -0,0 +1,169 @@
+package lambda
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Serve(t *testing.T) {
+	router := NewRouter()
+	respond := func(name string) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			return map[string]interface{}{
+				"handler": name,
+				"route":   request.Route,
+				"params":  request.PathParameters,
+			}, nil
+		}
+	}
+
+	router.GET("/users", respond("list"))
+	router.POST("/users", respond("create"))
+	router.GET("/users/{id}", respond("get"))
+	router.GET("/users/me", respond("me"))
+	router.DELETE("/users/{id}", respond("delete"))
+	router.GET("/files/{path+}", respond("files"))
+	router.Handle(MethodAny, "/health", respond("health"))
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		expectedHandler string
+		expectedParams  map[string]string
+		errorCheck      func(error) bool
+		expectedAllowed []string
+	}{
+		{name: "static route", method: "GET", path: "/users", expectedHandler: "list"},
+		{name: "trailing slash", method: "GET", path: "/users/", expectedHandler: "list"},
+		{name: "method dispatch", method: "POST", path: "/users", expectedHandler: "create"},
+		{
+			name:            "path parameter",
+			method:          "GET",
+			path:            "/users/42",
+			expectedHandler: "get",
+			expectedParams:  map[string]string{"id": "42"},
+		},
+		{name: "static segment preferred over parameter", method: "GET", path: "/users/me", expectedHandler: "me"},
+		{
+			name:            "greedy parameter",
+			method:          "GET",
+			path:            "/files/reports/2024/q1.csv",
+			expectedHandler: "files",
+			expectedParams:  map[string]string{"path": "reports/2024/q1.csv"},
+		},
+		{name: "any method", method: "PUT", path: "/health", expectedHandler: "health"},
+		{
+			name:            "method not allowed",
+			method:          "PUT",
+			path:            "/users/42",
+			errorCheck:      IsMethodNotAllowedError,
+			expectedAllowed: []string{"GET", "DELETE"},
+		},
+		{name: "unknown path", method: "GET", path: "/accounts", errorCheck: IsNotFoundError},
+		{name: "too many segments", method: "GET", path: "/users/42/orders", errorCheck: IsNotFoundError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := router.Serve(context.Background(), &Request{Method: tt.method, Path: tt.path})
+
+			if tt.errorCheck != nil {
+				require.Error(t, err)
+				assert.True(t, tt.errorCheck(err))
+				if tt.expectedAllowed != nil {
+					methodErr, ok := err.(*MethodNotAllowedError)
+					require.True(t, ok)
+					assert.Equal(t, tt.expectedAllowed, methodErr.Allowed)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			data := result.(map[string]interface{})
+			assert.Equal(t, tt.expectedHandler, data["handler"])
+			if tt.expectedParams != nil {
+				assert.Equal(t, tt.expectedParams, data["params"])
+			}
+		})
+	}
+}
+
+func TestRouter_RouteMiddleware(t *testing.T) {
+	router := NewRouter()
+	var calls []string
+
+	guard := func(next RequestHandlerFunc) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			calls = append(calls, "guard")
+			return next(ctx, request)
+		}
+	}
+	handler := func(name string) RequestHandlerFunc {
+		return func(ctx context.Context, request *Request) (interface{}, error) {
+			calls = append(calls, name)
+			return nil, nil
+		}
+	}
+
+	router.GET("/public", handler("public"))
+	router.GET("/private", handler("private"), guard)
+
+	_, err := router.Serve(context.Background(), &Request{Method: "GET", Path: "/public"})
+	require.NoError(t, err)
+	_, err = router.Serve(context.Background(), &Request{Method: "GET", Path: "/private"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"public", "guard", "private"}, calls)
+}
+
+func TestRouter_DoesNotMutateRequest(t *testing.T) {
+	router := NewRouter()
+	router.GET("/users/{id}", func(ctx context.Context, request *Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	request := &Request{Method: "GET", Path: "/users/1", PathParameters: map[string]string{}}
+	_, err := router.Serve(context.Background(), request)
+
+	require.NoError(t, err)
+	assert.Empty(t, request.PathParameters)
+	assert.Empty(t, request.Route)
+}
+
+func TestRouter_InvalidPatterns(t *testing.T) {
+	patterns := []string{
+		"users",
+		"/users/{}",
+		"/files/{path+}/meta",
+		"/users/{id}/{id}",
+		"/users/id}",
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			assert.Panics(t, func() {
+				NewRouter().GET(pattern, func(ctx context.Context, request *Request) (interface{}, error) {
+					return nil, nil
+				})
+			})
+		})
+	}
+}
+
+func TestRequest_PathParamInt(t *testing.T) {
+	request := &Request{PathParameters: map[string]string{"id": "42", "name": "abc"}}
+
+	id, err := request.PathParamInt("id")
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = request.PathParamInt("name")
+	assert.True(t, IsValidationError(err))
+
+	_, err = request.PathParamInt("missing")
+	assert.True(t, IsValidationError(err))
+}
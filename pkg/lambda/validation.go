@@ -0,0 +1,453 @@
+// Package lambda provides common utilities and middleware for AWS Lambda functions.
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"lambda-go-template/pkg/http"
+)
+
+// ValidationRule checks a single field value against a rule. param is the text after '='
+// in the tag, or empty when the rule takes no parameter. A non-nil error marks the field
+// invalid and its message is reported to the client.
+type ValidationRule func(value reflect.Value, param string) error
+
+// FieldViolations lists every field that failed validation. It is carried as the cause
+// of an aggregate ValidationError and rendered as the errors array in error responses.
+type FieldViolations []http.FieldError
+
+func (v FieldViolations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = fmt.Sprintf("%s: %s", violation.Field, violation.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FieldErrors returns the violations for rendering in an error response.
+func (v FieldViolations) FieldErrors() []http.FieldError {
+	return v
+}
+
+// NewValidationErrors creates an aggregate validation error listing every field violation.
+func NewValidationErrors(violations FieldViolations) *ValidationError {
+	return &ValidationError{
+		Message: "request validation failed",
+		Err:     violations,
+	}
+}
+
+// Validator validates structs using `validate` struct tags.
+//
+// Tags are comma-separated rules, for example `validate:"required,min=1,max=50"`.
+// Built-in rules are required, min, max, len, email, uuid, oneof and regex. min and max
+// bound numeric values and the length of strings, slices and maps; len requires an exact
+// length; oneof takes space-separated options. regex consumes the rest of the tag so the
+// pattern may contain commas. Rules apply to zero values too, so min=1 rejects 0; nil
+// pointers and fields tagged omitempty skip every rule but required when left empty. A
+// rule parameter that cannot be parsed yields an InternalError rather than a violation.
+// Nested structs, pointers to structs and slices of structs are validated recursively and
+// field names follow their json tags, e.g. "items[0].name".
+type Validator struct {
+	mu    sync.RWMutex
+	rules map[string]ValidationRule
+}
+
+// NewValidator creates a validator with the built-in rules registered.
+func NewValidator() *Validator {
+	return &Validator{
+		rules: map[string]ValidationRule{
+			"min":   ruleMin,
+			"max":   ruleMax,
+			"len":   ruleLen,
+			"email": ruleEmail,
+			"uuid":  ruleUUID,
+			"oneof": ruleOneOf,
+			"regex": ruleRegex,
+		},
+	}
+}
+
+// defaultValidator backs the package-level Validate and RegisterValidationRule functions.
+var defaultValidator = NewValidator()
+
+// Validate validates target with the default validator.
+func Validate(target interface{}) error {
+	return defaultValidator.Validate(target)
+}
+
+// RegisterValidationRule registers a custom rule on the default validator.
+func RegisterValidationRule(name string, rule ValidationRule) {
+	defaultValidator.RegisterRule(name, rule)
+}
+
+// RegisterRule registers a custom rule, replacing any existing rule with the same name.
+func (v *Validator) RegisterRule(name string, rule ValidationRule) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule
+}
+
+// Validate checks target, a struct or pointer to a struct, and returns an aggregate
+// ValidationError listing every violation, or nil if the struct is valid. A tag naming
+// an unregistered rule yields an InternalError.
+func (v *Validator) Validate(target interface{}) error {
+	value := reflect.ValueOf(target)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return NewInternalError("cannot validate a nil value", nil)
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return NewInternalError(fmt.Sprintf("validation target must be a struct, got %T", target), nil)
+	}
+
+	var violations FieldViolations
+	if err := v.validateStruct(value, "", &violations); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return NewValidationErrors(violations)
+	}
+
+	return nil
+}
+
+// fieldRules holds the parsed validation rules for one struct field.
+type fieldRules struct {
+	index     int
+	name      string
+	required  bool
+	omitEmpty bool
+	rules     []parsedRule
+}
+
+// parsedRule is a single rule name and parameter from a validate tag.
+type parsedRule struct {
+	name  string
+	param string
+}
+
+// fieldRulesCache caches parsed validate tags per struct type.
+var fieldRulesCache sync.Map // map[reflect.Type][]fieldRules
+
+// validateStruct validates every field of a struct value, appending violations.
+func (v *Validator) validateStruct(value reflect.Value, prefix string, violations *FieldViolations) error {
+	for _, field := range rulesFor(value.Type()) {
+		fieldValue := value.Field(field.index)
+		name := field.name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if err := v.validateField(fieldValue, name, field, violations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateField applies a field's rules and recurses into nested structs.
+func (v *Validator) validateField(value reflect.Value, name string, field fieldRules, violations *FieldViolations) error {
+	if value.IsZero() {
+		if field.required {
+			*violations = append(*violations, http.FieldError{Field: name, Message: "is required", Rule: "required"})
+			return nil
+		}
+		if field.omitEmpty || value.Kind() == reflect.Ptr {
+			return nil
+		}
+	}
+
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	for _, rule := range field.rules {
+		v.mu.RLock()
+		check, ok := v.rules[rule.name]
+		v.mu.RUnlock()
+		if !ok {
+			return NewInternalError(fmt.Sprintf("unknown validation rule %q on field %s", rule.name, name), nil)
+		}
+
+		if err := check(value, rule.param); err != nil {
+			var invalid *invalidRuleError
+			if errors.As(err, &invalid) {
+				return NewInternalError(fmt.Sprintf("invalid validation rule %q on field %s: %s", rule.name, name, invalid.message), nil)
+			}
+			*violations = append(*violations, http.FieldError{Field: name, Message: err.Error(), Rule: rule.name})
+		}
+	}
+
+	return v.validateNested(value, name, violations)
+}
+
+// validateNested validates structs reachable from a field value.
+func (v *Validator) validateNested(value reflect.Value, name string, violations *FieldViolations) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		return v.validateStruct(value, name, violations)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			element := value.Index(i)
+			for element.Kind() == reflect.Ptr && !element.IsNil() {
+				element = element.Elem()
+			}
+			if element.Kind() == reflect.Struct {
+				if err := v.validateStruct(element, fmt.Sprintf("%s[%d]", name, i), violations); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// rulesFor returns the parsed rules of a struct type. Fields without a validate tag are
+// kept when they may contain nested structs to validate.
+func rulesFor(structType reflect.Type) []fieldRules {
+	if cached, ok := fieldRulesCache.Load(structType); ok {
+		return cached.([]fieldRules)
+	}
+
+	var fields []fieldRules
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup("validate")
+		if tag == "-" || (!hasTag && !mayContainStruct(field.Type)) {
+			continue
+		}
+
+		parsed := fieldRules{index: i, name: validationFieldName(field)}
+		for _, rule := range parseValidateTag(tag) {
+			switch rule.name {
+			case "required":
+				parsed.required = true
+				continue
+			case "omitempty":
+				parsed.omitEmpty = true
+				continue
+			}
+			parsed.rules = append(parsed.rules, rule)
+		}
+
+		fields = append(fields, parsed)
+	}
+
+	fieldRulesCache.Store(structType, fields)
+	return fields
+}
+
+// parseValidateTag splits a validate tag into rules. A regex rule consumes the remainder
+// of the tag so its pattern may contain commas.
+func parseValidateTag(tag string) []parsedRule {
+	var rules []parsedRule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, parsedRule{name: name, param: param})
+	}
+	return rules
+}
+
+// validationFieldName returns the client-facing name of a field, preferring the json tag
+// and then the path, query and header binding tags.
+func validationFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "path", "query", "header"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+// mayContainStruct reports whether values of the type can hold nested structs to validate.
+func mayContainStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// invalidRuleError reports a rule that cannot be applied as declared, such as an
+// unparsable parameter. It is a mistake in the struct, not in the request.
+type invalidRuleError struct {
+	message string
+}
+
+func (e *invalidRuleError) Error() string {
+	return e.message
+}
+
+// ruleMin enforces a lower bound on numbers or a minimum length on strings, slices and maps.
+func ruleMin(value reflect.Value, param string) error {
+	return checkBound(value, param, func(actual, bound float64) bool { return actual >= bound }, "must be at least %s", "must contain at least %s")
+}
+
+// ruleMax enforces an upper bound on numbers or a maximum length on strings, slices and maps.
+func ruleMax(value reflect.Value, param string) error {
+	return checkBound(value, param, func(actual, bound float64) bool { return actual <= bound }, "must be at most %s", "must contain at most %s")
+}
+
+// ruleLen requires an exact length for strings, slices and maps.
+func ruleLen(value reflect.Value, param string) error {
+	expected, err := strconv.Atoi(param)
+	if err != nil {
+		return &invalidRuleError{message: fmt.Sprintf("len %q is not an integer", param)}
+	}
+
+	length, ok := valueLength(value)
+	if !ok {
+		return &invalidRuleError{message: fmt.Sprintf("len does not apply to %s values", value.Kind())}
+	}
+	if length != expected {
+		return fmt.Errorf("must have length %d", expected)
+	}
+	return nil
+}
+
+// checkBound compares a numeric value or a length against the rule parameter.
+func checkBound(value reflect.Value, param string, within func(actual, bound float64) bool, numberMessage, lengthMessage string) error {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return &invalidRuleError{message: fmt.Sprintf("bound %q is not a number", param)}
+	}
+
+	if length, ok := valueLength(value); ok {
+		if !within(float64(length), bound) {
+			unit := "items"
+			if value.Kind() == reflect.String {
+				unit = "characters"
+			}
+			return fmt.Errorf(lengthMessage, param+" "+unit)
+		}
+		return nil
+	}
+
+	var actual float64
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	default:
+		return &invalidRuleError{message: fmt.Sprintf("bounds do not apply to %s values", value.Kind())}
+	}
+
+	if !within(actual, bound) {
+		return fmt.Errorf(numberMessage, param)
+	}
+	return nil
+}
+
+// valueLength returns the length of strings (in characters), slices, arrays and maps.
+func valueLength(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	}
+	return 0, false
+}
+
+// ruleEmail requires a bare email address such as user@example.com.
+func ruleEmail(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("must be a string")
+	}
+
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return fmt.Errorf("must be a valid email address")
+	}
+	return nil
+}
+
+// uuidPattern matches the canonical 8-4-4-4-12 hexadecimal UUID format.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ruleUUID requires a canonical UUID string.
+func ruleUUID(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String || !uuidPattern.MatchString(value.String()) {
+		return fmt.Errorf("must be a valid UUID")
+	}
+	return nil
+}
+
+// ruleOneOf requires the value to equal one of the space-separated options.
+func ruleOneOf(value reflect.Value, param string) error {
+	options := strings.Fields(param)
+	actual := fmt.Sprint(value.Interface())
+	for _, option := range options {
+		if actual == option {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of [%s]", strings.Join(options, ", "))
+}
+
+// regexCache caches compiled regex rule patterns.
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+// ruleRegex requires a string value to match the pattern.
+func ruleRegex(value reflect.Value, param string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("must be a string")
+	}
+
+	var pattern *regexp.Regexp
+	if cached, ok := regexCache.Load(param); ok {
+		pattern = cached.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(param)
+		if err != nil {
+			return &invalidRuleError{message: fmt.Sprintf("pattern %q does not compile: %v", param, err)}
+		}
+		regexCache.Store(param, compiled)
+		pattern = compiled
+	}
+
+	if !pattern.MatchString(value.String()) {
+		return fmt.Errorf("must match pattern %s", param)
+	}
+	return nil
+}
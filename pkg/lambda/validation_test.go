@@ -0,0 +1,269 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateItem struct {
+	SKU      string `json:"sku" validate:"required,len=8"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type validateRequest struct {
+	ID       string         `path:"id" validate:"required,uuid"`
+	Name     string         `json:"name" validate:"required,min=2,max=10"`
+	Email    string         `json:"email" validate:"omitempty,email"`
+	Role     string         `json:"role" validate:"omitempty,oneof=admin member"`
+	Code     string         `json:"code" validate:"omitempty,regex=^[A-Z]{2,3}$"`
+	Tags     []string       `json:"tags" validate:"max=2"`
+	Items    []validateItem `json:"items"`
+	Nickname *string        `json:"nickname" validate:"min=3"`
+	internal string
+}
+
+func TestValidate_Valid(t *testing.T) {
+	nickname := "ally"
+	input := validateRequest{
+		ID:       "123e4567-e89b-12d3-a456-426614174000",
+		Name:     "Alice",
+		Email:    "alice@example.com",
+		Role:     "admin",
+		Code:     "PT",
+		Tags:     []string{"a", "b"},
+		Items:    []validateItem{{SKU: "ABCD1234", Quantity: 3}},
+		Nickname: &nickname,
+	}
+
+	assert.NoError(t, Validate(&input))
+	assert.NoError(t, Validate(input))
+}
+
+func TestValidate_OptionalFieldsSkipped(t *testing.T) {
+	input := validateRequest{ID: "123e4567-e89b-12d3-a456-426614174000", Name: "Al"}
+	assert.NoError(t, Validate(&input))
+}
+
+func TestValidate_CollectsAllViolations(t *testing.T) {
+	nickname := "al"
+	input := validateRequest{
+		ID:       "not-a-uuid",
+		Name:     "A",
+		Email:    "not-an-email",
+		Role:     "owner",
+		Code:     "pt",
+		Tags:     []string{"a", "b", "c"},
+		Items:    []validateItem{{SKU: "ABCD1234", Quantity: 1}, {Quantity: 101}},
+		Nickname: &nickname,
+	}
+
+	err := Validate(&input)
+	require.Error(t, err)
+	assert.True(t, IsValidationError(err))
+
+	var violations FieldViolations
+	require.True(t, errors.As(err, &violations))
+
+	actual := make(map[string]string, len(violations))
+	for _, violation := range violations {
+		actual[violation.Field] = violation.Rule
+	}
+
+	assert.Equal(t, map[string]string{
+		"id":                "uuid",
+		"name":              "min",
+		"email":             "email",
+		"role":              "oneof",
+		"code":              "regex",
+		"tags":              "max",
+		"items[1].sku":      "required",
+		"items[1].quantity": "max",
+		"nickname":          "min",
+	}, actual)
+}
+
+func TestValidate_Messages(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           interface{}
+		expectedMessage string
+	}{
+		{
+			name: "required",
+			input: struct {
+				Name string `json:"name" validate:"required"`
+			}{},
+			expectedMessage: "is required",
+		},
+		{
+			name: "string length",
+			input: struct {
+				Name string `json:"name" validate:"max=3"`
+			}{Name: "Élodie"},
+			expectedMessage: "must contain at most 3 characters",
+		},
+		{
+			name: "numeric bound",
+			input: struct {
+				Age int `json:"age" validate:"min=18"`
+			}{Age: 12},
+			expectedMessage: "must be at least 18",
+		},
+		{
+			name: "oneof",
+			input: struct {
+				Status string `json:"status" validate:"oneof=active inactive"`
+			}{Status: "gone"},
+			expectedMessage: "must be one of [active, inactive]",
+		},
+		{
+			name: "regex with comma",
+			input: struct {
+				Code string `json:"code" validate:"regex=^[0-9]{2,4}$"`
+			}{Code: "1"},
+			expectedMessage: "must match pattern ^[0-9]{2,4}$",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var violations FieldViolations
+			require.True(t, errors.As(Validate(tt.input), &violations))
+			require.Len(t, violations, 1)
+			assert.Equal(t, tt.expectedMessage, violations[0].Message)
+		})
+	}
+}
+
+func TestValidate_ZeroValues(t *testing.T) {
+	type zeroRequest struct {
+		Quantity int      `json:"quantity" validate:"min=1"`
+		Status   string   `json:"status" validate:"oneof=a b"`
+		Code     string   `json:"code" validate:"len=2"`
+		Tags     []string `json:"tags" validate:"min=1"`
+		Note     string   `json:"note" validate:"omitempty,min=3"`
+		Limit    *int     `json:"limit" validate:"min=1"`
+	}
+
+	var violations FieldViolations
+	require.True(t, errors.As(Validate(zeroRequest{}), &violations))
+
+	actual := make(map[string]string, len(violations))
+	for _, violation := range violations {
+		actual[violation.Field] = violation.Rule
+	}
+	assert.Equal(t, map[string]string{"quantity": "min", "status": "oneof", "code": "len", "tags": "min"}, actual)
+
+	zero := 0
+	require.True(t, errors.As(Validate(zeroRequest{Quantity: 1, Status: "a", Code: "ab", Tags: []string{"x"}, Limit: &zero}), &violations))
+	assert.Equal(t, FieldViolations{{Field: "limit", Message: "must be at least 1", Rule: "min"}}, violations)
+}
+
+func TestValidate_InvalidRuleParameters(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{
+			name: "len",
+			input: struct {
+				Code string `json:"code" validate:"len=two"`
+			}{Code: "ab"},
+		},
+		{
+			name: "bound",
+			input: struct {
+				Age int `json:"age" validate:"min=eighteen"`
+			}{Age: 20},
+		},
+		{
+			name: "bound on unsupported type",
+			input: struct {
+				Active bool `json:"active" validate:"max=1"`
+			}{Active: true},
+		},
+		{
+			name: "pattern",
+			input: struct {
+				Code string `json:"code" validate:"regex=^[A-Z"`
+			}{Code: "AB"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.input)
+			assert.True(t, IsInternalError(err), "unexpected error %v", err)
+			assert.False(t, IsValidationError(err))
+		})
+	}
+}
+
+func TestValidator_RegisterRule(t *testing.T) {
+	validator := NewValidator()
+	validator.RegisterRule("even", func(value reflect.Value, param string) error {
+		if value.Int()%2 != 0 {
+			return fmt.Errorf("must be even")
+		}
+		return nil
+	})
+
+	type evenRequest struct {
+		Count int `json:"count" validate:"even"`
+	}
+
+	assert.NoError(t, validator.Validate(evenRequest{Count: 4}))
+
+	var violations FieldViolations
+	require.True(t, errors.As(validator.Validate(evenRequest{Count: 3}), &violations))
+	assert.Equal(t, FieldViolations{{Field: "count", Message: "must be even", Rule: "even"}}, violations)
+
+	// Rules registered on one validator are not visible to others
+	assert.True(t, IsInternalError(NewValidator().Validate(evenRequest{Count: 3})))
+}
+
+func TestValidate_InvalidTarget(t *testing.T) {
+	var nilRequest *validateRequest
+	assert.True(t, IsInternalError(Validate(nilRequest)))
+	assert.True(t, IsInternalError(Validate("not a struct")))
+}
+
+func TestTypedHandler_RendersFieldErrors(t *testing.T) {
+	type createUserRequest struct {
+		Name  string `json:"name" validate:"required"`
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	called := false
+	business := TypedHandler(func(ctx context.Context, request createUserRequest) (*createUserRequest, error) {
+		called = true
+		return &request, nil
+	})
+
+	h := newTestHandler(t)
+	wrapped := h.WrapHTTPAPI(business)
+	request := testutil.CreateTestAPIGatewayV2RequestWithBody("POST", "/users", map[string]string{"email": "bad"})
+	response, err := wrapped(testutil.CreateTestContext("test-request"), request)
+	require.NoError(t, err)
+
+	assert.False(t, called)
+	assert.Equal(t, 400, response.StatusCode)
+
+	var body http.ErrorResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "request validation failed", body.Message)
+	assert.Equal(t, []http.FieldError{
+		{Field: "name", Message: "is required", Rule: "required"},
+		{Field: "email", Message: "must be a valid email address", Rule: "email"},
+	}, body.Errors)
+}
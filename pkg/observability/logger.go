@@ -161,6 +161,15 @@ func (l *Logger) LogLambdaError(ctx context.Context, err error, msg string) {
 	)
 }
 
+// LogPanic logs a panic recovered from a Lambda handler together with its stack trace.
+func (l *Logger) LogPanic(ctx context.Context, value interface{}, stack []byte) {
+	l.WithContext(ctx).Error("Recovered from panic",
+		zap.String("panic", fmt.Sprintf("%v", value)),
+		zap.String("panic_type", fmt.Sprintf("%T", value)),
+		zap.ByteString("stack", stack),
+	)
+}
+
 // Close flushes any buffered log entries.
 func (l *Logger) Close() error {
 	return l.Sync()
@@ -0,0 +1,105 @@
+// Package observability provides structured logging and distributed tracing utilities.
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metric units understood by CloudWatch.
+const (
+	UnitCount        = "Count"
+	UnitBytes        = "Bytes"
+	UnitMilliseconds = "Milliseconds"
+)
+
+// MetricsConfig holds configuration for metrics.
+type MetricsConfig struct {
+	Enabled     bool
+	Namespace   string
+	ServiceName string
+	// Output receives the metric records; it defaults to standard output, which Lambda
+	// sends to CloudWatch Logs.
+	Output io.Writer
+}
+
+// Metrics publishes CloudWatch metrics in the Embedded Metric Format: JSON log records
+// that CloudWatch Logs turns into metrics, so publishing needs no API calls.
+type Metrics struct {
+	config MetricsConfig
+	mu     sync.Mutex
+	out    io.Writer
+}
+
+// NewMetrics creates a new metrics instance.
+func NewMetrics(config MetricsConfig) *Metrics {
+	out := config.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	return &Metrics{
+		config: config,
+		out:    out,
+	}
+}
+
+// Put records value for the named metric, keyed by the service and dimensions. The Lambda
+// request ID is included as a property so the record can be found from the metric.
+func (m *Metrics) Put(ctx context.Context, name string, value float64, unit string, dimensions map[string]string) {
+	if m == nil || !m.config.Enabled {
+		return
+	}
+
+	names := []string{"service"}
+	for key := range dimensions {
+		if key != "service" {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names[1:])
+
+	record := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": time.Now().UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  m.config.Namespace,
+				"Dimensions": [][]string{names},
+				"Metrics":    []map[string]string{{"Name": name, "Unit": unit}},
+			}},
+		},
+		"service": m.config.ServiceName,
+		name:      value,
+	}
+	for key, dimension := range dimensions {
+		if key != "service" {
+			record[key] = dimension
+		}
+	}
+	if lc := GetLambdaContext(ctx); lc != nil {
+		record["requestId"] = lc.AwsRequestID
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, _ = m.out.Write(append(line, '\n'))
+}
+
+// Count records one occurrence of the named metric.
+func (m *Metrics) Count(ctx context.Context, name string, dimensions map[string]string) {
+	m.Put(ctx, name, 1, UnitCount, dimensions)
+}
+
+// IsEnabled returns whether metrics are enabled.
+func (m *Metrics) IsEnabled() bool {
+	return m != nil && m.config.Enabled
+}
@@ -0,0 +1,229 @@
+// Package observability provides structured logging and distributed tracing utilities.
+package observability
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Redaction modes: masked values are replaced by RedactedValue, hashed values by a keyed
+// hash that is the same for equal values, so requests can still be correlated by them.
+const (
+	RedactionModeMask = "mask"
+	RedactionModeHash = "hash"
+)
+
+// RedactedValue replaces values redacted in mask mode.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedFields are the header, query parameter and JSON field names that are
+// always redacted: credentials, cookies and tokens.
+var DefaultRedactedFields = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Amz-Security-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+	"api_key",
+	"apikey",
+	"password",
+	"secret",
+	"client_secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+}
+
+// RedactionConfig holds configuration for redaction.
+type RedactionConfig struct {
+	// Fields are header, query parameter and JSON field names redacted besides
+	// DefaultRedactedFields. Names match ignoring case, "-" and "_".
+	Fields []string
+	// Patterns redact the matching text within any other value, such as card numbers.
+	Patterns []*regexp.Regexp
+	// BodyPaths are locations redacted in JSON bodies, such as "user.ssn" or
+	// "cards[*].number", where * matches any field or array element.
+	BodyPaths []string
+	// Mode is RedactionModeMask, the default, or RedactionModeHash.
+	Mode string
+	// HashKey keys the hashes of RedactionModeHash, so low-entropy values cannot be
+	// recovered by hashing guesses.
+	HashKey string
+}
+
+// Redactor removes sensitive values from request data before it is logged or traced.
+type Redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	paths    [][]string
+	hash     bool
+	hashKey  []byte
+}
+
+// NewRedactor creates a redactor for the default fields and those configured.
+func NewRedactor(config RedactionConfig) *Redactor {
+	r := &Redactor{
+		fields:   make(map[string]bool, len(DefaultRedactedFields)+len(config.Fields)),
+		patterns: config.Patterns,
+		hash:     config.Mode == RedactionModeHash,
+		hashKey:  []byte(config.HashKey),
+	}
+	for _, field := range append(append([]string(nil), DefaultRedactedFields...), config.Fields...) {
+		r.fields[normalizeFieldName(field)] = true
+	}
+	for _, path := range config.BodyPaths {
+		r.paths = append(r.paths, parseRedactionPath(path))
+	}
+	return r
+}
+
+// normalizeFieldName folds the spellings of a field name, so "X-Api-Key", "x_api_key" and
+// "XApiKey" match.
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
+
+// parseRedactionPath splits a body path such as "cards[*].number" into its segments.
+func parseRedactionPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	return strings.Split(path, ".")
+}
+
+// IsSensitive reports whether values of the named field are redacted.
+func (r *Redactor) IsSensitive(name string) bool {
+	return r.fields[normalizeFieldName(name)]
+}
+
+// Redact returns the replacement of a sensitive value: RedactedValue, or in hash mode a
+// "sha256:" prefixed keyed hash.
+func (r *Redactor) Redact(value string) string {
+	if !r.hash {
+		return RedactedValue
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(value))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// RedactField returns value redacted entirely if the named field is sensitive, and
+// otherwise with the text matching any pattern redacted.
+func (r *Redactor) RedactField(name, value string) string {
+	if r.IsSensitive(name) {
+		return r.Redact(value)
+	}
+	return r.redactPatterns(value)
+}
+
+// RedactValues returns a redacted copy of multi-value fields such as headers or query
+// parameters.
+func (r *Redactor) RedactValues(values map[string][]string) map[string][]string {
+	redacted := make(map[string][]string, len(values))
+	for name, list := range values {
+		redacted[name] = make([]string, len(list))
+		for i, value := range list {
+			redacted[name][i] = r.RedactField(name, value)
+		}
+	}
+	return redacted
+}
+
+// RedactMap returns a redacted copy of single-value fields such as path parameters.
+func (r *Redactor) RedactMap(values map[string]string) map[string]string {
+	redacted := make(map[string]string, len(values))
+	for name, value := range values {
+		redacted[name] = r.RedactField(name, value)
+	}
+	return redacted
+}
+
+// RedactJSON returns a redacted copy of a JSON document. Sensitive fields are redacted at
+// any depth, as are the configured body paths; a body that is not valid JSON only has its
+// pattern matches redacted.
+func (r *Redactor) RedactJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []byte(r.redactPatterns(string(body)))
+	}
+
+	redacted, err := json.Marshal(r.redactJSONValue(value, nil))
+	if err != nil {
+		return []byte(RedactedValue)
+	}
+	return redacted
+}
+
+// redactJSONValue redacts a decoded JSON value found at path.
+func (r *Redactor) redactJSONValue(value interface{}, path []string) interface{} {
+	if r.matchesPath(path) {
+		return r.redactAny(value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			if r.IsSensitive(key) {
+				v[key] = r.redactAny(member)
+				continue
+			}
+			v[key] = r.redactJSONValue(member, append(path, key))
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = r.redactJSONValue(element, append(path, strconv.Itoa(i)))
+		}
+	case string:
+		return r.redactPatterns(v)
+	}
+	return value
+}
+
+// redactAny redacts a whole JSON value; objects and arrays are hashed by their encoding.
+func (r *Redactor) redactAny(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return r.Redact(s)
+	}
+	encoded, _ := json.Marshal(value)
+	return r.Redact(string(encoded))
+}
+
+// matchesPath reports whether a location in a JSON document is a configured body path.
+func (r *Redactor) matchesPath(path []string) bool {
+	for _, pattern := range r.paths {
+		if len(pattern) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range pattern {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// redactPatterns redacts the text of value matching any pattern.
+func (r *Redactor) redactPatterns(value string) string {
+	for _, pattern := range r.patterns {
+		value = pattern.ReplaceAllStringFunc(value, r.Redact)
+	}
+	return value
+}
@@ -92,6 +92,18 @@ func (t *Tracer) AddError(ctx context.Context, err error) {
 	}
 }
 
+// AddPanic records a recovered panic on the current segment and marks it as a fault.
+// Call it from the deferred function that recovered the panic so the stack is captured.
+func (t *Tracer) AddPanic(ctx context.Context, value interface{}) {
+	if !t.config.Enabled {
+		return
+	}
+
+	if seg := xray.GetSegment(ctx); seg != nil {
+		seg.AddError(seg.ParentSegment.GetConfiguration().ExceptionFormattingStrategy.Panicf("%v", value))
+	}
+}
+
 // SetHTTPRequest adds HTTP request information to the current segment.
 func (t *Tracer) SetHTTPRequest(ctx context.Context, method, url string) {
 	if !t.config.Enabled {
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lambda-go-template/pkg/config"
+	"lambda-go-template/pkg/http"
+	"lambda-go-template/pkg/lambda"
+	"lambda-go-template/pkg/observability"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"go.uber.org/zap"
+)
+
+// Response formats of the authorizer, matching the API Gateway authorizer configuration.
+const (
+	ResponseFormatSimple = "simple"
+	ResponseFormatIAM    = "iam"
+)
+
+// unauthenticatedPrincipal is the principal of IAM deny policies.
+const unauthenticatedPrincipal = "unauthenticated"
+
+// Identity is the caller an authorizer request authenticated as.
+type Identity struct {
+	Principal string
+	AuthType  string
+	Claims    *lambda.Claims
+}
+
+// AuthorizerService validates the credentials of HTTP API requests.
+type AuthorizerService struct {
+	config   *config.Config
+	logger   *observability.Logger
+	tracer   *observability.Tracer
+	verifier *lambda.JWTVerifier
+	apiKeys  map[[sha256.Size]byte]string
+}
+
+// NewAuthorizerService creates a new authorizer service instance. Bearer tokens are
+// verified against keys; when keys is nil only API keys are accepted.
+func NewAuthorizerService(cfg *config.Config, logger *observability.Logger, tracer *observability.Tracer, keys lambda.JWTKeySet) *AuthorizerService {
+	var verifier *lambda.JWTVerifier
+	if keys != nil {
+		verifier = lambda.NewJWTVerifier(lambda.JWTConfig{
+			Keys:     keys,
+			Issuer:   cfg.AuthIssuer,
+			Audience: cfg.AuthAudience,
+		})
+	}
+
+	// Keys are looked up by hash, so lookup time does not depend on how much of a
+	// guessed key matches a real one
+	apiKeys := make(map[[sha256.Size]byte]string, len(cfg.AuthAPIKeys))
+	for key, principal := range cfg.AuthAPIKeys {
+		apiKeys[sha256.Sum256([]byte(key))] = principal
+	}
+
+	return &AuthorizerService{
+		config:   cfg,
+		logger:   logger,
+		tracer:   tracer,
+		verifier: verifier,
+		apiKeys:  apiKeys,
+	}
+}
+
+// Authenticate validates the request's bearer token or, failing that, its API key.
+// Every failure is an UnauthorizedError whose Reason says why.
+func (s *AuthorizerService) Authenticate(ctx context.Context, request events.APIGatewayV2CustomAuthorizerV2Request) (*Identity, error) {
+	headers := http.NewHeaders(request.Headers, nil)
+
+	if token, ok := lambda.BearerToken(headers.Get("Authorization")); ok {
+		if s.verifier == nil {
+			return nil, lambda.NewUnauthorizedErrorWithReason("Bearer tokens are not accepted", lambda.AuthReasonUnknownKey)
+		}
+
+		claims, err := s.verifier.Verify(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &Identity{Principal: claims.Subject, AuthType: lambda.AuthTypeJWT, Claims: claims}, nil
+	}
+
+	if apiKey := headers.Get("X-Api-Key"); apiKey != "" {
+		principal, ok := s.apiKeys[sha256.Sum256([]byte(apiKey))]
+		if !ok {
+			return nil, lambda.NewUnauthorizedErrorWithReason("Invalid API key", lambda.AuthReasonInvalidAPIKey)
+		}
+		return &Identity{Principal: principal, AuthType: lambda.AuthTypeAPIKey}, nil
+	}
+
+	return nil, lambda.NewUnauthorizedErrorWithReason("Missing credentials", lambda.AuthReasonMissingToken)
+}
+
+// ProcessAuthorizerRequest authenticates the request and returns the authorizer response
+// in the configured format. Authenticated callers are allowed, with their identity passed
+// on to the integration as the authorizer context; everyone else is denied, which API
+// Gateway answers with 403 Forbidden.
+func (s *AuthorizerService) ProcessAuthorizerRequest(ctx context.Context, request events.APIGatewayV2CustomAuthorizerV2Request) (interface{}, error) {
+	ctx, seg := s.tracer.StartSubsegment(ctx, "authorize")
+	defer s.tracer.Close(seg, nil)
+
+	s.tracer.AddAnnotation(ctx, "routeKey", request.RouteKey)
+
+	identity, err := s.Authenticate(ctx, request)
+	if err != nil {
+		var unauthorized *lambda.UnauthorizedError
+		if !errors.As(err, &unauthorized) {
+			return nil, err
+		}
+
+		s.tracer.AddAnnotation(ctx, "auth_decision", "deny")
+		s.tracer.AddAnnotation(ctx, "auth_failure", unauthorized.Reason)
+		s.logger.WithFields(map[string]interface{}{
+			"routeKey": request.RouteKey,
+			"reason":   unauthorized.Reason,
+			"sourceIp": request.RequestContext.HTTP.SourceIP,
+		}).WithContext(ctx).Warn("Request denied")
+
+		return s.response(request, false, unauthenticatedPrincipal, nil), nil
+	}
+
+	s.tracer.AddAnnotation(ctx, "auth_decision", "allow")
+	s.tracer.AddAnnotation(ctx, "auth_type", identity.AuthType)
+	s.tracer.AddUserID(ctx, identity.Principal)
+	s.logger.WithFields(map[string]interface{}{
+		"routeKey":  request.RouteKey,
+		"principal": identity.Principal,
+		"authType":  identity.AuthType,
+	}).WithContext(ctx).Info("Request authorized")
+
+	// IAM policies need a principal ID; callers without one, such as API keys mapped to an
+	// empty principal or tokens without a subject, share a fixed one per credential type
+	principal := identity.Principal
+	if principal == "" {
+		principal = identity.AuthType
+	}
+
+	authorizerContext := lambda.NewAuthorizerContext(identity.Principal, identity.AuthType, identity.Claims)
+	return s.response(request, true, principal, authorizerContext), nil
+}
+
+// response builds the simple or IAM policy response for a decision.
+func (s *AuthorizerService) response(request events.APIGatewayV2CustomAuthorizerV2Request, allowed bool, principal string, authorizerContext lambda.AuthorizerContext) interface{} {
+	if s.config.AuthResponseFormat != ResponseFormatIAM {
+		return events.APIGatewayV2CustomAuthorizerSimpleResponse{
+			IsAuthorized: allowed,
+			Context:      authorizerContext,
+		}
+	}
+
+	effect := "Deny"
+	if allowed {
+		effect = "Allow"
+	}
+
+	return events.APIGatewayV2CustomAuthorizerIAMPolicyResponse{
+		PrincipalID: principal,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{{
+				Action:   []string{"execute-api:Invoke"},
+				Effect:   effect,
+				Resource: []string{request.RouteArn},
+			}},
+		},
+		Context: authorizerContext,
+	}
+}
+
+// loadAPIKeys reads API keys from the named SSM SecureString parameter, so they never
+// appear in the function's environment variables.
+func loadAPIKeys(ctx context.Context, client ssmiface.SSMAPI, name string) (map[string]string, error) {
+	output, err := client.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading API keys parameter %s: %w", name, err)
+	}
+	return parseAPIKeys(aws.StringValue(output.Parameter.Value))
+}
+
+// parseAPIKeys parses comma-separated key:principal pairs, the format of AUTH_API_KEYS.
+// Errors never include the keys themselves.
+func parseAPIKeys(value string) (map[string]string, error) {
+	apiKeys := make(map[string]string)
+	for i, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, principal, found := strings.Cut(pair, ":")
+		if !found || key == "" {
+			return nil, fmt.Errorf("API key entry %d is not a key:principal pair", i+1)
+		}
+		apiKeys[key] = principal
+	}
+	return apiKeys, nil
+}
+
+// CreateHandler creates the Lambda handler function.
+func CreateHandler(cfg *config.Config, logger *observability.Logger, tracer *observability.Tracer, keys lambda.JWTKeySet) func(context.Context, events.APIGatewayV2CustomAuthorizerV2Request) (interface{}, error) {
+	service := NewAuthorizerService(cfg, logger, tracer, keys)
+
+	return func(ctx context.Context, request events.APIGatewayV2CustomAuthorizerV2Request) (interface{}, error) {
+		return service.ProcessAuthorizerRequest(ctx, request)
+	}
+}
+
+func main() {
+	// Load configuration
+	cfg := config.MustLoad()
+
+	// Initialize logger
+	logger := observability.MustNewLogger(cfg)
+	defer logger.Close()
+
+	// Set global logger for packages that need it
+	observability.SetGlobalLogger(logger)
+
+	// Initialize tracer
+	tracer := observability.NewTracer(observability.TracingConfig{
+		Enabled:     cfg.EnableTracing,
+		ServiceName: cfg.ServiceName,
+		Version:     cfg.ServiceVersion,
+	})
+
+	// Bearer tokens are only accepted when the issuer's signing keys are configured. The
+	// key set is created once per container, so warm invocations reuse the cached keys.
+	var keys lambda.JWTKeySet
+	if cfg.AuthJWKSURL != "" {
+		keys = lambda.NewJWKSFromURL(cfg.AuthJWKSURL, cfg.AuthJWKSCacheTTL, nil)
+	}
+
+	// API keys stored in SSM are read once per container, alongside any set in AUTH_API_KEYS
+	if cfg.AuthAPIKeysParameter != "" {
+		apiKeys, err := loadAPIKeys(context.Background(), ssm.New(session.Must(session.NewSession())), cfg.AuthAPIKeysParameter)
+		if err != nil {
+			logger.Fatal("Failed to load API keys", zap.Error(err))
+		}
+		if cfg.AuthAPIKeys == nil {
+			cfg.AuthAPIKeys = make(map[string]string, len(apiKeys))
+		}
+		for key, principal := range apiKeys {
+			cfg.AuthAPIKeys[key] = principal
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"service":        cfg.ServiceName,
+		"version":        cfg.ServiceVersion,
+		"environment":    cfg.Environment,
+		"tracing":        cfg.EnableTracing,
+		"responseFormat": cfg.AuthResponseFormat,
+		"jwtEnabled":     keys != nil,
+		"apiKeyCount":    len(cfg.AuthAPIKeys),
+	}).Info("Starting authorizer Lambda function")
+
+	// Start Lambda
+	awslambda.Start(CreateHandler(cfg, logger, tracer, keys))
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"lambda-go-template/internal/testutil"
+	"lambda-go-template/pkg/lambda"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("test-secret")
+
+const testRouteArn = "arn:aws:execute-api:us-east-1:123456789012:abcdef123/prod/GET/users"
+
+// signTestToken creates an HS256 token signed with testSecret.
+func signTestToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": lambda.JWTAlgHS256, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// createAuthorizerRequest creates an authorizer event for GET /users with headers.
+func createAuthorizerRequest(headers map[string]string) events.APIGatewayV2CustomAuthorizerV2Request {
+	return events.APIGatewayV2CustomAuthorizerV2Request{
+		Version:  "2.0",
+		Type:     "REQUEST",
+		RouteArn: testRouteArn,
+		RouteKey: "GET /users",
+		RawPath:  "/prod/users",
+		Headers:  headers,
+	}
+}
+
+func TestAuthorizerService_SimpleResponse(t *testing.T) {
+	validToken := signTestToken(t, map[string]interface{}{
+		"iss":   "https://issuer.example.com",
+		"sub":   "user-1",
+		"aud":   "users-api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "users:read users:write",
+		"roles": []string{"admin"},
+	})
+	expiredToken := signTestToken(t, map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"sub": "user-1",
+		"aud": "users-api",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	otherAudienceToken := signTestToken(t, map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"sub": "user-1",
+		"aud": "other-api",
+	})
+
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		expectAllowed   bool
+		expectedContext map[string]interface{}
+	}{
+		{
+			name:          "should allow valid bearer token",
+			headers:       map[string]string{"authorization": "Bearer " + validToken},
+			expectAllowed: true,
+			expectedContext: map[string]interface{}{
+				"principalId": "user-1",
+				"authType":    "jwt",
+				"sub":         "user-1",
+				"iss":         "https://issuer.example.com",
+				"scope":       "users:read users:write",
+				"roles":       "admin",
+			},
+		},
+		{
+			name:          "should allow valid API key",
+			headers:       map[string]string{"x-api-key": "partner-key"},
+			expectAllowed: true,
+			expectedContext: map[string]interface{}{
+				"principalId": "partner-a",
+				"authType":    "api_key",
+			},
+		},
+		{
+			name:          "should prefer bearer token over API key",
+			headers:       map[string]string{"Authorization": "Bearer " + validToken, "x-api-key": "partner-key"},
+			expectAllowed: true,
+			expectedContext: map[string]interface{}{
+				"principalId": "user-1",
+				"authType":    "jwt",
+				"sub":         "user-1",
+				"iss":         "https://issuer.example.com",
+				"scope":       "users:read users:write",
+				"roles":       "admin",
+			},
+		},
+		{
+			name:    "should deny expired token",
+			headers: map[string]string{"authorization": "Bearer " + expiredToken},
+		},
+		{
+			name:    "should deny token for another audience",
+			headers: map[string]string{"authorization": "Bearer " + otherAudienceToken},
+		},
+		{
+			name:    "should deny tampered token",
+			headers: map[string]string{"authorization": "Bearer " + validToken + "x"},
+		},
+		{
+			name:    "should deny unknown API key",
+			headers: map[string]string{"x-api-key": "guessed-key"},
+		},
+		{
+			name:    "should deny request without credentials",
+			headers: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.AuthIssuer = "https://issuer.example.com"
+			cfg.AuthAudience = "users-api"
+			cfg.AuthAPIKeys = map[string]string{"partner-key": "partner-a"}
+			cfg.AuthResponseFormat = ResponseFormatSimple
+
+			handler := CreateHandler(cfg, testutil.TestLogger(t), testutil.TestTracer(), lambda.StaticKeySet{"": testSecret})
+
+			result, err := handler(testutil.CreateTestContext("test-request-123"), createAuthorizerRequest(tt.headers))
+			require.NoError(t, err)
+
+			response, ok := result.(events.APIGatewayV2CustomAuthorizerSimpleResponse)
+			require.True(t, ok, "expected a simple response, got %T", result)
+			assert.Equal(t, tt.expectAllowed, response.IsAuthorized)
+			if tt.expectAllowed {
+				assert.Equal(t, tt.expectedContext, response.Context)
+			} else {
+				assert.Empty(t, response.Context)
+			}
+		})
+	}
+}
+
+func TestAuthorizerService_IAMPolicyResponse(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.AuthAPIKeys = map[string]string{"partner-key": "partner-a"}
+	cfg.AuthResponseFormat = ResponseFormatIAM
+
+	handler := CreateHandler(cfg, testutil.TestLogger(t), testutil.TestTracer(), nil)
+	ctx := testutil.CreateTestContext("test-request-123")
+
+	t.Run("should allow route for valid API key", func(t *testing.T) {
+		result, err := handler(ctx, createAuthorizerRequest(map[string]string{"x-api-key": "partner-key"}))
+		require.NoError(t, err)
+
+		response, ok := result.(events.APIGatewayV2CustomAuthorizerIAMPolicyResponse)
+		require.True(t, ok, "expected an IAM policy response, got %T", result)
+		assert.Equal(t, "partner-a", response.PrincipalID)
+		assert.Equal(t, "2012-10-17", response.PolicyDocument.Version)
+		require.Len(t, response.PolicyDocument.Statement, 1)
+		assert.Equal(t, "Allow", response.PolicyDocument.Statement[0].Effect)
+		assert.Equal(t, []string{"execute-api:Invoke"}, response.PolicyDocument.Statement[0].Action)
+		assert.Equal(t, []string{testRouteArn}, response.PolicyDocument.Statement[0].Resource)
+		assert.Equal(t, "api_key", response.Context["authType"])
+	})
+
+	t.Run("should fall back to a fixed principal for API keys without one", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.AuthAPIKeys = map[string]string{"anonymous-key": ""}
+		cfg.AuthResponseFormat = ResponseFormatIAM
+		handler := CreateHandler(cfg, testutil.TestLogger(t), testutil.TestTracer(), nil)
+
+		result, err := handler(ctx, createAuthorizerRequest(map[string]string{"x-api-key": "anonymous-key"}))
+		require.NoError(t, err)
+
+		response, ok := result.(events.APIGatewayV2CustomAuthorizerIAMPolicyResponse)
+		require.True(t, ok, "expected an IAM policy response, got %T", result)
+		assert.Equal(t, lambda.AuthTypeAPIKey, response.PrincipalID)
+		assert.Equal(t, "Allow", response.PolicyDocument.Statement[0].Effect)
+	})
+
+	t.Run("should deny bearer tokens when no keys are configured", func(t *testing.T) {
+		token := signTestToken(t, map[string]interface{}{"sub": "user-1"})
+
+		result, err := handler(ctx, createAuthorizerRequest(map[string]string{"authorization": "Bearer " + token}))
+		require.NoError(t, err)
+
+		response, ok := result.(events.APIGatewayV2CustomAuthorizerIAMPolicyResponse)
+		require.True(t, ok, "expected an IAM policy response, got %T", result)
+		assert.Equal(t, unauthenticatedPrincipal, response.PrincipalID)
+		require.Len(t, response.PolicyDocument.Statement, 1)
+		assert.Equal(t, "Deny", response.PolicyDocument.Statement[0].Effect)
+		assert.Empty(t, response.Context)
+	})
+}
+
+func TestAuthorizerService_Authenticate(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.AuthAPIKeys = map[string]string{"partner-key": "partner-a"}
+	service := NewAuthorizerService(cfg, testutil.TestLogger(t), testutil.TestTracer(), lambda.StaticKeySet{"": testSecret})
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedReason string
+	}{
+		{
+			name:           "missing credentials",
+			headers:        map[string]string{},
+			expectedReason: lambda.AuthReasonMissingToken,
+		},
+		{
+			name:           "non-bearer authorization header",
+			headers:        map[string]string{"authorization": "Basic dXNlcjpwYXNz"},
+			expectedReason: lambda.AuthReasonMissingToken,
+		},
+		{
+			name:           "invalid API key",
+			headers:        map[string]string{"x-api-key": "guessed-key"},
+			expectedReason: lambda.AuthReasonInvalidAPIKey,
+		},
+		{
+			name:           "malformed token",
+			headers:        map[string]string{"authorization": "Bearer not-a-token"},
+			expectedReason: lambda.AuthReasonMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := service.Authenticate(context.Background(), createAuthorizerRequest(tt.headers))
+
+			assert.Nil(t, identity)
+			var unauthorized *lambda.UnauthorizedError
+			require.ErrorAs(t, err, &unauthorized)
+			assert.Equal(t, tt.expectedReason, unauthorized.Reason)
+		})
+	}
+}
+
+// fakeSSM serves a single SSM parameter.
+type fakeSSM struct {
+	ssmiface.SSMAPI
+	name  string
+	value string
+}
+
+func (f *fakeSSM) GetParameterWithContext(ctx aws.Context, input *ssm.GetParameterInput, opts ...request.Option) (*ssm.GetParameterOutput, error) {
+	if aws.StringValue(input.Name) != f.name || !aws.BoolValue(input.WithDecryption) {
+		return nil, errors.New("parameter not found")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Value: aws.String(f.value)}}, nil
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	client := &fakeSSM{name: "/users-api/api-keys", value: "key-1:partner-a, key-2:partner-b"}
+
+	apiKeys, err := loadAPIKeys(context.Background(), client, "/users-api/api-keys")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key-1": "partner-a", "key-2": "partner-b"}, apiKeys)
+
+	_, err = loadAPIKeys(context.Background(), client, "/missing")
+	assert.Error(t, err)
+
+	client.value = "key-1:partner-a,secret-key-without-principal"
+	_, err = loadAPIKeys(context.Background(), client, "/users-api/api-keys")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-key-without-principal")
+}
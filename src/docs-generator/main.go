@@ -9,8 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"lambda-go-template/pkg/lambda"
+
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -221,6 +223,17 @@ func (dg *DocsGenerator) generatePostmanCollection(apiGatewayURL string) string
 	return string(jsonBytes)
 }
 
+// generateErrorCodes generates the error code catalog as JSON
+func (dg *DocsGenerator) generateErrorCodes() string {
+	catalog := map[string]interface{}{
+		"description": "Stable error codes returned in the code field of error responses",
+		"codes":       lambda.ErrorCodes(),
+	}
+
+	jsonBytes, _ := json.MarshalIndent(catalog, "", "  ")
+	return string(jsonBytes)
+}
+
 // generateReadme generates a README file for the API
 func (dg *DocsGenerator) generateReadme(apiGatewayURL string) string {
 	readme := "# Lambda Go Template API\n\n"
@@ -243,6 +256,14 @@ func (dg *DocsGenerator) generateReadme(apiGatewayURL string) string {
 	readme += "```bash\n"
 	readme += "curl -X GET \"" + apiGatewayURL + "/prod/users\"\n"
 	readme += "```\n\n"
+	readme += "## ❗ Error Codes\n\n"
+	readme += "Error responses carry a stable `code`; send `Accept: application/problem+json` for RFC 7807 problem details.\n\n"
+	readme += "| Code | Status | Description |\n"
+	readme += "|------|--------|-------------|\n"
+	for _, info := range lambda.ErrorCodes() {
+		readme += fmt.Sprintf("| `%s` | %d | %s |\n", info.Code, info.Status, info.Description)
+	}
+	readme += "\n"
 	readme += "## 📊 Features\n\n"
 	readme += "- ✅ **Idiomatic Go** - Clean, maintainable Go code\n"
 	readme += "- ✅ **Observability** - Structured logging & X-Ray tracing\n"
@@ -290,6 +311,12 @@ func (dg *DocsGenerator) generateDocs(apiGatewayURL string) error {
 		return fmt.Errorf("failed to upload README.md: %v", err)
 	}
 
+	// Generate error code catalog
+	errorCodes := dg.generateErrorCodes()
+	if err := dg.uploadToS3("error-codes.json", errorCodes, "application/json"); err != nil {
+		return fmt.Errorf("failed to upload error-codes.json: %v", err)
+	}
+
 	// Generate a simple API status page
 	statusPage := fmt.Sprintf(`<!DOCTYPE html>
 <html>
@@ -335,6 +362,7 @@ func (dg *DocsGenerator) generateDocs(apiGatewayURL string) error {
 			<li><a href="openapi.json">OpenAPI Specification (JSON)</a></li>
 			<li><a href="postman-collection.json">Postman Collection</a></li>
 			<li><a href="README.md">API README</a></li>
+			<li><a href="error-codes.json">Error Codes</a></li>
 		</ul>
 	</div>
 </body>
@@ -411,5 +439,5 @@ func (dg *DocsGenerator) Handler(ctx context.Context, event json.RawMessage) (Re
 
 func main() {
 	dg := NewDocsGenerator()
-	lambda.Start(dg.Handler)
+	awslambda.Start(dg.Handler)
 }
@@ -21,6 +21,7 @@ type HelloResponse struct {
 	Environment string `json:"environment"`
 	RequestID   string `json:"requestId"`
 	Version     string `json:"version"`
+	Principal   string `json:"principal,omitempty"`
 }
 
 // HelloService handles the business logic for hello operations.
@@ -82,6 +83,11 @@ func (s *HelloService) ProcessHelloRequest(ctx context.Context, request events.A
 		Version:     s.config.ServiceVersion,
 	}
 
+	// Greet the caller identified by the Lambda authorizer, if the route has one
+	if authorizerContext, ok := lambda.AuthorizerContextFromEvent(request); ok {
+		response.Principal = authorizerContext.Principal()
+	}
+
 	// Add response metadata to tracing
 	s.tracer.AddMetadata(ctx, "response", map[string]interface{}{
 		"message":     response.Message,
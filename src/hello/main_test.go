@@ -67,6 +67,20 @@ func TestHelloService_ProcessHelloRequest(t *testing.T) {
 				assert.Equal(t, "Hello from Lambda with observability!", response.Message)
 			},
 		},
+		{
+			name: "should include principal from Lambda authorizer",
+			request: func() events.APIGatewayV2HTTPRequest {
+				request := testutil.CreateTestAPIGatewayV2Request("GET", "/hello")
+				request.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+					Lambda: map[string]interface{}{"principalId": "user-1", "authType": "jwt"},
+				}
+				return request
+			}(),
+			expectError: false,
+			validate: func(t *testing.T, response *HelloResponse) {
+				assert.Equal(t, "user-1", response.Principal)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -196,7 +210,7 @@ func TestMainIntegration(t *testing.T) {
 			},
 		},
 		{
-			name: "should return 400 for invalid content type",
+			name: "should return 415 for unsupported content type",
 			request: func() events.APIGatewayV2HTTPRequest {
 				req := testutil.CreateTestAPIGatewayV2RequestWithHeaders("POST", "/hello", map[string]string{
 					"Content-Type": "text/plain",
@@ -204,10 +218,10 @@ func TestMainIntegration(t *testing.T) {
 				req.Body = `{"test": "data"}`
 				return req
 			}(),
-			expectedStatusCode: 400,
+			expectedStatusCode: 415,
 			validateResponse: func(t *testing.T, body string) {
 				testutil.AssertValidJSONResponse(t, body)
-				testutil.AssertErrorResponse(t, body, "Content-Type must be application/json for requests with body")
+				testutil.AssertErrorResponse(t, body, "Content-Type text/plain is not supported; use application/json, application/x-www-form-urlencoded, multipart/form-data")
 			},
 		},
 	}
@@ -240,6 +254,9 @@ func TestMainIntegration(t *testing.T) {
 				handler.TracingMiddlewareV2(),
 			)
 
+			// CORS headers are only sent to browser requests, which carry an Origin header
+			tt.request.Headers["origin"] = "https://app.example.com"
+
 			// Create test context
 			ctx := testutil.CreateTestContext("test-integration-request")
 
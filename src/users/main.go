@@ -5,12 +5,11 @@ import (
 	"time"
 
 	"lambda-go-template/pkg/config"
+	"lambda-go-template/pkg/http"
 	"lambda-go-template/pkg/lambda"
 	"lambda-go-template/pkg/observability"
 
-	"github.com/aws/aws-lambda-go/events"
 	awslambda "github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-lambda-go/lambdacontext"
 )
 
 // User represents a user entity.
@@ -88,6 +87,33 @@ func (r *MockUserRepository) GetUserByID(ctx context.Context, id string) (*User,
 	return nil, lambda.NewResourceNotFoundError("user", id, "user not found")
 }
 
+// CircuitBreakerUserRepository guards another repository with a circuit breaker, so a
+// degraded data store fails fast with a retryable ExternalServiceError.
+type CircuitBreakerUserRepository struct {
+	repository UserRepository
+	breaker    *lambda.CircuitBreaker
+}
+
+// NewCircuitBreakerUserRepository wraps repo with breaker.
+func NewCircuitBreakerUserRepository(repo UserRepository, breaker *lambda.CircuitBreaker) *CircuitBreakerUserRepository {
+	return &CircuitBreakerUserRepository{
+		repository: repo,
+		breaker:    breaker,
+	}
+}
+
+// GetUsers retrieves all users through the circuit breaker.
+func (r *CircuitBreakerUserRepository) GetUsers(ctx context.Context) ([]User, error) {
+	return lambda.ExecuteWithBreaker(ctx, r.breaker, r.repository.GetUsers)
+}
+
+// GetUserByID retrieves a user by ID through the circuit breaker.
+func (r *CircuitBreakerUserRepository) GetUserByID(ctx context.Context, id string) (*User, error) {
+	return lambda.ExecuteWithBreaker(ctx, r.breaker, func(ctx context.Context) (*User, error) {
+		return r.repository.GetUserByID(ctx, id)
+	})
+}
+
 // UsersService handles the business logic for user operations.
 type UsersService struct {
 	config     *config.Config
@@ -106,41 +132,34 @@ func NewUsersService(cfg *config.Config, logger *observability.Logger, tracer *o
 	}
 }
 
-// ProcessUsersRequest processes a users list request and returns the response data.
-func (s *UsersService) ProcessUsersRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (*UsersResponse, error) {
+// ListUsers handles GET /users and returns every user.
+func (s *UsersService) ListUsers(ctx context.Context, request *lambda.Request) (*UsersResponse, error) {
 	// Add business logic tracing
-	ctx, seg := s.tracer.StartSubsegment(ctx, "processUsersRequest")
+	ctx, seg := s.tracer.StartSubsegment(ctx, "listUsers")
 	defer s.tracer.Close(seg, nil)
 
-	// Get Lambda context for request ID
-	lc, _ := lambdacontext.FromContext(ctx)
-	requestID := ""
-	if lc != nil {
-		requestID = lc.AwsRequestID
-	}
+	requestID := lambda.GetRequestID(ctx)
 
 	// Add tracing annotations
-	s.tracer.AddAnnotation(ctx, "path", request.RawPath)
-	s.tracer.AddAnnotation(ctx, "httpMethod", request.RequestContext.HTTP.Method)
+	s.tracer.AddAnnotation(ctx, "path", request.Path)
+	s.tracer.AddAnnotation(ctx, "httpMethod", request.Method)
 
 	// Log structured information about the request processing
 	s.logger.WithFields(map[string]interface{}{
-		"path":       request.RawPath,
-		"httpMethod": request.RequestContext.HTTP.Method,
+		"path":       request.Path,
+		"httpMethod": request.Method,
 		"requestId":  requestID,
+		"principal":  principal(request),
 	}).Info("Processing users request")
 
-	// Check if this is a request for a specific user
-	userID := request.PathParameters["id"]
-	if userID != "" {
-		return s.processSingleUserRequest(ctx, userID, requestID)
-	}
-
 	// Fetch all users data
 	var allUsers []User
 	err := s.tracer.WithTimer(ctx, "getUsersFromDatabase", func(ctx context.Context) error {
 		var fetchErr error
 		allUsers, fetchErr = s.repository.GetUsers(ctx)
+		if lambda.IsExternalServiceError(fetchErr) {
+			return fetchErr
+		}
 		if fetchErr != nil {
 			return lambda.NewInternalErrorWithOperation("database query", "failed to fetch users", fetchErr)
 		}
@@ -158,6 +177,7 @@ func (s *UsersService) ProcessUsersRequest(ctx context.Context, request events.A
 	if err != nil {
 		return nil, err
 	}
+	setUsersETag(ctx, allUsers)
 
 	// Create response
 	response := &UsersResponse{
@@ -183,16 +203,20 @@ func (s *UsersService) ProcessUsersRequest(ctx context.Context, request events.A
 	return response, nil
 }
 
-// processSingleUserRequest handles requests for a specific user by ID.
-func (s *UsersService) processSingleUserRequest(ctx context.Context, userID, requestID string) (*UsersResponse, error) {
-	ctx, seg := s.tracer.StartSubsegment(ctx, "processSingleUserRequest")
+// GetUser handles GET /users/{id} and returns a single user.
+func (s *UsersService) GetUser(ctx context.Context, request *lambda.Request) (*UsersResponse, error) {
+	ctx, seg := s.tracer.StartSubsegment(ctx, "getUser")
 	defer s.tracer.Close(seg, nil)
 
+	requestID := lambda.GetRequestID(ctx)
+	userID := request.PathParam("id")
+
 	s.tracer.AddAnnotation(ctx, "userId", userID)
 
 	s.logger.WithFields(map[string]interface{}{
 		"userId":    userID,
 		"requestId": requestID,
+		"principal": principal(request),
 	}).Info("Processing single user request")
 
 	// Validate user ID format
@@ -203,12 +227,13 @@ func (s *UsersService) processSingleUserRequest(ctx context.Context, userID, req
 	// Fetch specific user
 	user, err := s.repository.GetUserByID(ctx, userID)
 	if err != nil {
-		// Check if it's a not found error and pass it through
-		if lambda.IsNotFoundError(err) {
+		// Pass not found and dependency errors through with their own status codes
+		if lambda.IsNotFoundError(err) || lambda.IsExternalServiceError(err) {
 			return nil, err
 		}
 		return nil, lambda.NewInternalErrorWithOperation("user retrieval", "failed to get user from repository", err)
 	}
+	setUsersETag(ctx, []User{*user})
 
 	// Create response with single user
 	response := &UsersResponse{
@@ -228,51 +253,45 @@ func (s *UsersService) processSingleUserRequest(ctx context.Context, userID, req
 	return response, nil
 }
 
-// ValidateUsersRequest validates the incoming request for users operations.
-func (s *UsersService) ValidateUsersRequest(_ context.Context, request events.APIGatewayV2HTTPRequest) error {
-	// Validate HTTP method
-	if request.RequestContext.HTTP.Method != "GET" {
-		return lambda.NewValidationError("only GET method is allowed for users endpoint", "httpMethod", request.RequestContext.HTTP.Method)
-	}
-
-	// Validate path parameters if present
-	if userID, exists := request.PathParameters["id"]; exists {
-		if userID == "" {
-			return lambda.NewValidationError("user ID cannot be empty", "id", userID)
-		}
+// setUsersETag sets the ETag of a users response from the users alone, since the
+// timestamp and request ID in the response change every time. Clients revalidating an
+// unchanged list with If-None-Match then get 304 Not Modified.
+func setUsersETag(ctx context.Context, users []User) {
+	if etag, err := http.ComputeJSONETag(users, true); err == nil {
+		lambda.SetResponseETag(ctx, etag)
 	}
+}
 
-	return nil
+// principal returns the caller identified by the Lambda authorizer, or an empty string on
+// routes without one.
+func principal(request *lambda.Request) string {
+	authorizerContext, _ := request.AuthorizerContext()
+	return authorizerContext.Principal()
 }
 
-// CreateHandler creates the Lambda handler function.
-func CreateHandler(cfg *config.Config, logger *observability.Logger, tracer *observability.Tracer) func(context.Context, events.APIGatewayV2HTTPRequest) (interface{}, error) {
-	// Initialize repository (in production, this might be a DynamoDB repository)
-	repository := NewMockUserRepository()
-	service := NewUsersService(cfg, logger, tracer, repository)
+// CreateRouter registers the users routes against a new router.
+func CreateRouter(service *UsersService) *lambda.Router {
+	router := lambda.NewRouter()
 
-	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
-		// Validate request first
-		if err := service.ValidateUsersRequest(ctx, request); err != nil {
-			return nil, err
-		}
+	router.GET("/users", func(ctx context.Context, request *lambda.Request) (interface{}, error) {
+		return service.ListUsers(ctx, request)
+	})
+	router.GET("/users/{id}", func(ctx context.Context, request *lambda.Request) (interface{}, error) {
+		return service.GetUser(ctx, request)
+	})
 
-		return service.ProcessUsersRequest(ctx, request)
-	}
+	return router
 }
 
-// CustomValidationMiddleware provides users-specific validation.
-func CustomValidationMiddleware(cfg *config.Config) func(lambda.HandlerFuncV2) lambda.HandlerFuncV2 {
-	return func(next lambda.HandlerFuncV2) lambda.HandlerFuncV2 {
-		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
-			// Only allow GET requests for users endpoint
-			if request.RequestContext.HTTP.Method != "GET" {
-				return nil, lambda.NewValidationError("only GET method is allowed for users endpoint", "httpMethod", request.RequestContext.HTTP.Method)
-			}
+// CreateHandler creates the Lambda handler function.
+func CreateHandler(cfg *config.Config, logger *observability.Logger, tracer *observability.Tracer) lambda.RequestHandlerFunc {
+	// Initialize repository (in production, this might be a DynamoDB repository). The
+	// handler is created once per container, so the breaker state survives warm invocations.
+	breaker := lambda.NewCircuitBreaker("users-repository", lambda.DefaultCircuitBreakerConfig(), logger, tracer)
+	repository := NewCircuitBreakerUserRepository(NewMockUserRepository(), breaker)
+	service := NewUsersService(cfg, logger, tracer, repository)
 
-			return next(ctx, request)
-		}
-	}
+	return CreateRouter(service).Serve
 }
 
 func main() {
@@ -299,15 +318,14 @@ func main() {
 	// Create the business logic handler
 	businessHandler := CreateHandler(cfg, logger, tracer)
 
-	// Wrap with middleware (including custom validation)
 	// Wrap with middleware
-	wrappedHandler := handler.WrapV2(
+	wrappedHandler := handler.WrapHTTPAPI(
 		businessHandler,
-		CustomValidationMiddleware(cfg),
-		handler.ValidationMiddlewareV2(),
-		handler.LoggingMiddlewareV2(),
-		handler.TracingMiddlewareV2(),
-		handler.TimeoutMiddlewareV2(),
+		handler.Validation(),
+		handler.LimitBody(lambda.BodyLimitsFromConfig(cfg)),
+		handler.Logging(),
+		handler.Tracing(),
+		handler.Timeout(),
 	)
 
 	logger.WithFields(map[string]interface{}{
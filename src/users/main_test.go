@@ -60,7 +60,7 @@ func (r *TestUserRepository) GetUserByID(ctx context.Context, id string) (*User,
 	return nil, lambda.NewResourceNotFoundError("user", id, "user not found")
 }
 
-func TestUsersService_ProcessUsersRequest(t *testing.T) {
+func TestUsersService_ListUsers(t *testing.T) {
 	tests := []struct {
 		name        string
 		request     events.APIGatewayV2HTTPRequest
@@ -94,36 +94,6 @@ func TestUsersService_ProcessUsersRequest(t *testing.T) {
 				}
 			},
 		},
-		{
-			name: "should process single user request",
-			request:     testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/1", map[string]string{
-				"id": "1",
-			}),
-			expectError: false,
-			validate: func(t *testing.T, response *UsersResponse) {
-				assert.Equal(t, 1, response.Count)
-				assert.Len(t, response.Users, 1)
-				assert.Equal(t, "1", response.Users[0].ID)
-				assert.Equal(t, "John Doe", response.Users[0].Name)
-				assert.Equal(t, "john@example.com", response.Users[0].Email)
-			},
-		},
-		{
-			name: "should return error for non-existent user",
-			request:     testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/999", map[string]string{
-				"id": "999",
-			}),
-			expectError: true,
-		},
-		{
-			name: "should handle request without user ID as list request",
-			request: testutil.CreateTestAPIGatewayV2Request("GET", "/users"),
-			expectError: false,
-			validate: func(t *testing.T, response *UsersResponse) {
-				assert.Equal(t, 2, response.Count)
-				assert.Len(t, response.Users, 2)
-			},
-		},
 		{
 			name:        "should handle repository error",
 			request:     testutil.CreateTestAPIGatewayV2Request("GET", "/users"),
@@ -153,7 +123,7 @@ func TestUsersService_ProcessUsersRequest(t *testing.T) {
 			ctx := testutil.CreateTestContext("test")
 
 			// Execute test
-			result, err := service.ProcessUsersRequest(ctx, tt.request)
+			result, err := service.ListUsers(ctx, lambda.NewRequestFromHTTPAPI(tt.request))
 
 			// Assertions
 			if tt.expectError {
@@ -171,43 +141,56 @@ func TestUsersService_ProcessUsersRequest(t *testing.T) {
 	}
 }
 
-func TestUsersService_ValidateUsersRequest(t *testing.T) {
+func TestUsersService_GetUser(t *testing.T) {
 	tests := []struct {
 		name        string
 		request     events.APIGatewayV2HTTPRequest
 		expectError bool
-		errorType   string
+		errorCheck  func(error) bool
+		validate    func(*testing.T, *UsersResponse)
+		setupRepo   func(*TestUserRepository)
 	}{
 		{
-			name:        "should process request without user ID",
-			request:     testutil.CreateTestAPIGatewayV2Request("GET", "/users"),
+			name: "should process single user request",
+			request: testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/1", map[string]string{
+				"id": "1",
+			}),
 			expectError: false,
+			validate: func(t *testing.T, response *UsersResponse) {
+				assert.Equal(t, 1, response.Count)
+				assert.Len(t, response.Users, 1)
+				assert.Equal(t, "1", response.Users[0].ID)
+				assert.Equal(t, "John Doe", response.Users[0].Name)
+				assert.Equal(t, "john@example.com", response.Users[0].Email)
+				assert.Equal(t, "test", response.RequestID)
+			},
 		},
 		{
-			name:        "should reject POST request",
-			request:     testutil.CreateTestAPIGatewayV2Request("POST", "/users"),
-			expectError: true,
-			errorType:   "ValidationError",
-		},
-		{
-			name:        "should reject PUT request",
-			request:     testutil.CreateTestAPIGatewayV2Request("PUT", "/users"),
+			name: "should return error for non-existent user",
+			request: testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/999", map[string]string{
+				"id": "999",
+			}),
 			expectError: true,
-			errorType:   "ValidationError",
+			errorCheck:  lambda.IsNotFoundError,
 		},
 		{
-			name:        "should reject PATCH request",
-			request:     testutil.CreateTestAPIGatewayV2Request("PATCH", "/users"),
+			name: "should reject empty user ID",
+			request: testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/", map[string]string{
+				"id": "",
+			}),
 			expectError: true,
-			errorType:   "ValidationError",
+			errorCheck:  lambda.IsValidationError,
 		},
 		{
-			name: "should reject empty user ID in path",
-			request:     testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/", map[string]string{
-				"id": "",
+			name: "should wrap repository error",
+			request: testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/1", map[string]string{
+				"id": "1",
 			}),
 			expectError: true,
-			errorType:   "ValidationError",
+			errorCheck:  lambda.IsInternalError,
+			setupRepo: func(repo *TestUserRepository) {
+				repo.failGet = true
+			},
 		},
 	}
 
@@ -219,35 +202,73 @@ func TestUsersService_ValidateUsersRequest(t *testing.T) {
 			tracer := testutil.TestTracer()
 			repo := NewTestUserRepository()
 
+			if tt.setupRepo != nil {
+				tt.setupRepo(repo)
+			}
+
 			// Create service
 			service := NewUsersService(cfg, logger, tracer, repo)
 
 			// Create test context
-			ctx := testutil.CreateTestContext("test-validation")
+			ctx := testutil.CreateTestContext("test")
 
 			// Execute test
-			err := service.ValidateUsersRequest(ctx, tt.request)
+			result, err := service.GetUser(ctx, lambda.NewRequestFromHTTPAPI(tt.request))
 
 			// Assertions
 			if tt.expectError {
 				assert.Error(t, err)
-				if tt.errorType != "" {
-					if tt.errorType == "ValidationError" {
-						assert.True(t, lambda.IsValidationError(err))
-					}
+				assert.Nil(t, result)
+				if tt.errorCheck != nil {
+					assert.True(t, tt.errorCheck(err))
 				}
 			} else {
 				assert.NoError(t, err)
+				require.NotNil(t, result)
+
+				if tt.validate != nil {
+					tt.validate(t, result)
+				}
 			}
 		})
 	}
 }
 
+func TestCircuitBreakerUserRepository(t *testing.T) {
+	cfg := testutil.TestConfig()
+	logger := testutil.TestLogger(t)
+	tracer := testutil.TestTracer()
+
+	repo := NewTestUserRepository()
+	repo.failGet = true
+	breaker := lambda.NewCircuitBreaker("users-repository", lambda.CircuitBreakerConfig{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         time.Minute,
+	}, logger, tracer)
+	service := NewUsersService(cfg, logger, tracer, NewCircuitBreakerUserRepository(repo, breaker))
+
+	ctx := testutil.CreateTestContext("test")
+	request := lambda.NewRequestFromHTTPAPI(testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+
+	for i := 0; i < 2; i++ {
+		_, err := service.ListUsers(ctx, request)
+		assert.True(t, lambda.IsInternalError(err))
+	}
+	assert.Equal(t, lambda.CircuitOpen, breaker.State())
+
+	// The open circuit fails fast even though the repository has recovered
+	repo.failGet = false
+	_, err := service.ListUsers(ctx, request)
+	assert.ErrorIs(t, err, lambda.ErrCircuitOpen)
+	assert.True(t, lambda.IsRetryableError(err))
+}
+
 func TestCreateHandler(t *testing.T) {
 	tests := []struct {
 		name        string
 		request     events.APIGatewayV2HTTPRequest
 		expectError bool
+		errorCheck  func(error) bool
 	}{
 		{
 			name:        "should handle valid users list request",
@@ -255,16 +276,21 @@ func TestCreateHandler(t *testing.T) {
 			expectError: false,
 		},
 		{
-			name: "should handle valid single user request",
-			request:     testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/1", map[string]string{
-				"id": "1",
-			}),
+			name:        "should handle valid single user request",
+			request:     testutil.CreateTestAPIGatewayV2Request("GET", "/users/1"),
 			expectError: false,
 		},
 		{
-			name:        "should reject invalid HTTP method",
+			name:        "should reject unsupported HTTP method",
 			request:     testutil.CreateTestAPIGatewayV2Request("POST", "/users"),
 			expectError: true,
+			errorCheck:  lambda.IsMethodNotAllowedError,
+		},
+		{
+			name:        "should reject unknown path",
+			request:     testutil.CreateTestAPIGatewayV2Request("GET", "/accounts"),
+			expectError: true,
+			errorCheck:  lambda.IsNotFoundError,
 		},
 	}
 
@@ -282,11 +308,14 @@ func TestCreateHandler(t *testing.T) {
 			ctx := testutil.CreateTestContext("test-request-456")
 
 			// Execute test
-			result, err := handler(ctx, tt.request)
+			result, err := handler(ctx, lambda.NewRequestFromHTTPAPI(tt.request))
 
 			// Assertions
 			if tt.expectError {
 				assert.Error(t, err)
+				if tt.errorCheck != nil {
+					assert.True(t, tt.errorCheck(err))
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, result)
@@ -311,72 +340,20 @@ func TestCreateHandler(t *testing.T) {
 	}
 }
 
-func TestCustomValidationMiddleware(t *testing.T) {
-	tests := []struct {
-		name        string
-		request     events.APIGatewayV2HTTPRequest
-		expectError bool
-	}{
-		{
-			name:        "should handle valid request",
-			request:     testutil.CreateTestAPIGatewayV2Request("GET", "/users"),
-			expectError: false,
-		},
-		{
-			name: "should allow normal user ID",
-			request:     testutil.CreateTestAPIGatewayV2RequestWithPath("GET", "/users/123", map[string]string{
-				"id": "123",
-			}),
-			expectError: false,
-		},
-
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Setup test environment
-			cfg := testutil.TestConfig()
-
-			// Create mock handler
-			mockHandler := func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, error) {
-				return "success", nil
-			}
-
-			// Apply middleware
-			middleware := CustomValidationMiddleware(cfg)
-			wrappedHandler := middleware(mockHandler)
-
-			// Create test context
-			ctx := testutil.CreateTestContext("test-middleware")
-
-			// Execute test
-			result, err := wrappedHandler(ctx, tt.request)
-
-			// Assertions
-			if tt.expectError {
-				assert.Error(t, err)
-				assert.True(t, lambda.IsValidationError(err))
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, "success", result)
-			}
-		})
-	}
-}
-
 func TestMainIntegration(t *testing.T) {
 	// Test the complete Lambda handler with middleware
 	tests := []struct {
 		name               string
 		request            events.APIGatewayV2HTTPRequest
 		expectedStatusCode int
-		validateResponse   func(*testing.T, string)
+		validateResponse   func(*testing.T, events.APIGatewayV2HTTPResponse)
 	}{
 		{
 			name:               "should return 200 for valid GET request",
 			request:            testutil.CreateTestAPIGatewayV2Request("GET", "/users"),
 			expectedStatusCode: 200,
-			validateResponse: func(t *testing.T, body string) {
+			validateResponse: func(t *testing.T, response events.APIGatewayV2HTTPResponse) {
+				body := response.Body
 				testutil.AssertValidJSONResponse(t, body)
 				testutil.AssertSuccessResponse(t, body, nil)
 
@@ -398,22 +375,46 @@ func TestMainIntegration(t *testing.T) {
 				assert.Equal(t, "1.0.0-test", successResponse.Data.Version)
 			},
 		},
+		{
+			name:               "should return 200 for single user request",
+			request:            testutil.CreateTestAPIGatewayV2Request("GET", "/users/2"),
+			expectedStatusCode: 200,
+			validateResponse: func(t *testing.T, response events.APIGatewayV2HTTPResponse) {
+				var successResponse struct {
+					Data UsersResponse `json:"data"`
+				}
+				err := json.Unmarshal([]byte(response.Body), &successResponse)
+				require.NoError(t, err)
+
+				require.Len(t, successResponse.Data.Users, 1)
+				assert.Equal(t, "Jane Smith", successResponse.Data.Users[0].Name)
+			},
+		},
+		{
+			name:               "should return 404 for unknown user",
+			request:            testutil.CreateTestAPIGatewayV2Request("GET", "/users/999"),
+			expectedStatusCode: 404,
+			validateResponse: func(t *testing.T, response events.APIGatewayV2HTTPResponse) {
+				testutil.AssertErrorResponse(t, response.Body, "user not found")
+			},
+		},
 		{
 			name:               "should return 400 for invalid HTTP method",
 			request:            testutil.CreateTestAPIGatewayV2Request("INVALID", "/users"),
 			expectedStatusCode: 400,
-			validateResponse: func(t *testing.T, body string) {
-				testutil.AssertValidJSONResponse(t, body)
-				testutil.AssertErrorResponse(t, body, "HTTP method INVALID is not allowed")
+			validateResponse: func(t *testing.T, response events.APIGatewayV2HTTPResponse) {
+				testutil.AssertValidJSONResponse(t, response.Body)
+				testutil.AssertErrorResponse(t, response.Body, "HTTP method INVALID is not allowed")
 			},
 		},
 		{
-			name:               "should return 400 for unsupported HTTP method",
+			name:               "should return 405 for unsupported HTTP method",
 			request:            testutil.CreateTestAPIGatewayV2Request("PATCH", "/users"),
-			expectedStatusCode: 400,
-			validateResponse: func(t *testing.T, body string) {
-				testutil.AssertValidJSONResponse(t, body)
-				testutil.AssertErrorResponse(t, body, "only GET method is allowed for users endpoint")
+			expectedStatusCode: 405,
+			validateResponse: func(t *testing.T, response events.APIGatewayV2HTTPResponse) {
+				testutil.AssertValidJSONResponse(t, response.Body)
+				testutil.AssertErrorResponse(t, response.Body, "HTTP method PATCH is not allowed for this resource")
+				assert.Equal(t, "GET", response.Headers["Allow"])
 			},
 		},
 	}
@@ -439,13 +440,16 @@ func TestMainIntegration(t *testing.T) {
 			handler := lambda.NewHandler(cfg, logger, tracer)
 			businessHandler := CreateHandler(cfg, logger, tracer)
 
-			wrappedHandler := handler.WrapV2(
+			wrappedHandler := handler.WrapHTTPAPI(
 				businessHandler,
-				handler.ValidationMiddlewareV2(),
-				handler.LoggingMiddlewareV2(),
-				handler.TracingMiddlewareV2(),
+				handler.Validation(),
+				handler.Logging(),
+				handler.Tracing(),
 			)
 
+			// CORS headers are only sent to browser requests, which carry an Origin header
+			tt.request.Headers["origin"] = "https://app.example.com"
+
 			// Create test context
 			ctx := testutil.CreateTestContext("test-integration-request")
 
@@ -463,7 +467,7 @@ func TestMainIntegration(t *testing.T) {
 
 			// Validate response body
 			if tt.validateResponse != nil {
-				tt.validateResponse(t, response.Body)
+				tt.validateResponse(t, response)
 			}
 		})
 	}
@@ -647,7 +651,7 @@ func TestMockUserRepository(t *testing.T) {
 	})
 }
 
-func BenchmarkUsersService_ProcessUsersRequest(b *testing.B) {
+func BenchmarkUsersService_ListUsers(b *testing.B) {
 	// Setup
 	cfg := testutil.TestConfig()
 	logger := testutil.TestLogger(&testing.T{}) // Use testing.T for benchmark
@@ -655,13 +659,13 @@ func BenchmarkUsersService_ProcessUsersRequest(b *testing.B) {
 	repo := NewTestUserRepository()
 	service := NewUsersService(cfg, logger, tracer, repo)
 
-	request := testutil.CreateTestAPIGatewayV2Request("GET", "/users")
+	request := lambda.NewRequestFromHTTPAPI(testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
 	ctx := testutil.CreateTestContext("bench-request")
 
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := service.ProcessUsersRequest(ctx, request)
+		_, err := service.ListUsers(ctx, request)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -675,7 +679,7 @@ func BenchmarkCreateHandler(b *testing.B) {
 	tracer := testutil.TestTracer()
 	handler := CreateHandler(cfg, logger, tracer)
 
-	request := testutil.CreateTestAPIGatewayV2Request("GET", "/users")
+	request := lambda.NewRequestFromHTTPAPI(testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
 	ctx := testutil.CreateTestContext("bench-request")
 
 	b.ResetTimer()
@@ -687,3 +691,39 @@ func BenchmarkCreateHandler(b *testing.B) {
 		}
 	}
 }
+
+func TestConditionalGet(t *testing.T) {
+	cfg := testutil.TestConfig()
+	logger := testutil.TestLogger(t)
+	tracer := testutil.TestTracer()
+
+	handler := lambda.NewHandler(cfg, logger, tracer)
+	wrappedHandler := handler.WrapHTTPAPI(CreateHandler(cfg, logger, tracer))
+
+	first, err := wrappedHandler(testutil.CreateTestContext("first-request"), testutil.CreateTestAPIGatewayV2Request("GET", "/users"))
+	require.NoError(t, err)
+	require.Equal(t, 200, first.StatusCode)
+	etag := first.Headers["ETag"]
+	require.NotEmpty(t, etag)
+
+	t.Run("should return 304 when the users are unchanged", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users", map[string]string{"if-none-match": etag})
+
+		response, err := wrappedHandler(testutil.CreateTestContext("second-request"), request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 304, response.StatusCode)
+		assert.Empty(t, response.Body)
+		assert.Equal(t, etag, response.Headers["ETag"])
+	})
+
+	t.Run("should return 200 for another user", func(t *testing.T) {
+		request := testutil.CreateTestAPIGatewayV2RequestWithHeaders("GET", "/users/2", map[string]string{"if-none-match": etag})
+
+		response, err := wrappedHandler(testutil.CreateTestContext("third-request"), request)
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, response.StatusCode)
+		assert.NotEqual(t, etag, response.Headers["ETag"])
+	})
+}
@@ -4,14 +4,10 @@ resource "aws_apigatewayv2_api" "api" {
   description   = "Serverless HTTP API Gateway for ${var.project_name}"
   protocol_type = "HTTP"
 
-  cors_configuration {
-    allow_credentials = false
-    allow_headers     = ["authorization", "content-type", "x-amz-date", "x-amz-security-token", "x-amz-user-agent", "x-api-key", "x-request-id"]
-    allow_methods     = ["DELETE", "GET", "OPTIONS", "POST", "PUT"]
-    allow_origins     = ["*"]
-    expose_headers    = ["x-request-id", "x-service", "x-version"]
-    max_age           = 86400
-  }
+  # CORS, including preflight requests, is handled by the functions (CORS_* variables), so
+  # API Gateway passes OPTIONS requests through to them. HTTP APIs decode the base64 bodies
+  # of compressed responses (ENABLE_COMPRESSION); a REST API would need binary_media_types
+  # = ["*/*"] and COMPRESS_REST_API=true instead
 
   tags = local.common_tags
 }
@@ -57,6 +53,7 @@ resource "aws_apigatewayv2_route" "lambda" {
   route_key = each.value.key
   target    = "integrations/${aws_apigatewayv2_integration.lambda[each.value.func_key].id}"
 
-  # Simplified auth - no custom authorizer for now
-  authorization_type = "NONE"
+  # Routes with auth = true are guarded by the Lambda authorizer
+  authorization_type = each.value.auth ? "CUSTOM" : "NONE"
+  authorizer_id      = each.value.auth ? aws_apigatewayv2_authorizer.lambda.id : null
 }
@@ -0,0 +1,72 @@
+# Lambda authorizer for routes with auth = true
+module "authorizer" {
+  source  = "terraform-aws-modules/lambda/aws"
+  version = "~> 8.1"
+
+  function_name = "${local.function_base_name}-authorizer"
+  description   = "Lambda authorizer validating bearer tokens and API keys"
+  handler       = "bootstrap"
+  runtime       = "provided.al2023"
+  architectures = ["arm64"]
+
+  create_package         = false
+  local_existing_package = "../build/authorizer.zip"
+
+  timeout     = 10
+  memory_size = 256
+
+  environment_variables = {
+    ENVIRONMENT          = local.environment
+    LOG_LEVEL            = "info"
+    AUTH_JWKS_URL        = var.auth_jwks_url
+    AUTH_ISSUER          = var.auth_issuer
+    AUTH_AUDIENCE        = var.auth_audience
+    AUTH_RESPONSE_FORMAT = var.auth_response_format
+
+    # API keys stay in SSM and are read at cold start, never stored in the environment
+    AUTH_API_KEYS_PARAMETER = var.auth_api_keys_parameter
+  }
+
+  # CloudWatch Logs
+  attach_cloudwatch_logs_policy     = true
+  cloudwatch_logs_retention_in_days = 14
+
+  # X-Ray tracing
+  tracing_mode          = "Active"
+  attach_tracing_policy = true
+
+  # Read access to the API keys parameter; SecureStrings under the default aws/ssm key
+  # are decrypted by SSM on the function's behalf
+  attach_policy_statements = var.auth_api_keys_parameter != ""
+  policy_statements = {
+    api_keys = {
+      effect    = "Allow"
+      actions   = ["ssm:GetParameter"]
+      resources = ["arn:aws:ssm:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:parameter/${trimprefix(var.auth_api_keys_parameter, "/")}"]
+    }
+  }
+
+  tags = local.common_tags
+}
+
+resource "aws_apigatewayv2_authorizer" "lambda" {
+  api_id                            = aws_apigatewayv2_api.api.id
+  name                              = "${local.function_base_name}-authorizer"
+  authorizer_type                   = "REQUEST"
+  authorizer_uri                    = module.authorizer.lambda_function_invoke_arn
+  authorizer_payload_format_version = "2.0"
+  enable_simple_responses           = var.auth_response_format == "simple"
+
+  # Callers may send either credential, so neither header can be a required identity
+  # source, and decisions are not cached
+  identity_sources                 = []
+  authorizer_result_ttl_in_seconds = 0
+}
+
+resource "aws_lambda_permission" "api_gateway_authorizer" {
+  statement_id  = "AllowExecutionFromAPIGateway-authorizer"
+  action        = "lambda:InvokeFunction"
+  function_name = module.authorizer.lambda_function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_apigatewayv2_api.api.execution_arn}/authorizers/${aws_apigatewayv2_authorizer.lambda.id}"
+}
@@ -21,6 +21,9 @@ module "lambda_functions" {
     LOG_LEVEL        = "info"
     USERS_TABLE_NAME = aws_dynamodb_table.users.name
     EVENT_BUS_NAME   = aws_cloudwatch_event_bus.app_events.name
+
+    CORS_ALLOWED_ORIGINS   = join(",", var.cors_allowed_origins)
+    CORS_ALLOW_CREDENTIALS = tostring(var.cors_allow_credentials)
   }
 
   # CloudWatch Logs
@@ -10,21 +10,31 @@ locals {
   actual_namespace   = local.namespace != "" ? local.namespace : local.environment
   function_base_name = "${local.project_name}-${local.actual_namespace}"
 
-  # Lambda functions configuration for Go template
+  # Lambda functions configuration for Go template. The authorizer is opt-in: set
+  # auth = true on a route to require a bearer token or API key for it. Every path keeps
+  # an unauthenticated OPTIONS route, since browsers send CORS preflights without credentials
   lambda_functions = {
     hello = {
       name        = "${local.function_base_name}-hello"
       source_dir  = "../build/hello.zip"
       runtime     = "provided.al2023"
       handler     = "bootstrap"
-      routes      = [{ path = "/hello", method = "GET", auth = false }]
+      routes = [
+        { path = "/hello", method = "GET", auth = false },
+        { path = "/hello", method = "OPTIONS", auth = false },
+      ]
     }
     users = {
       name        = "${local.function_base_name}-users"
       source_dir  = "../build/users.zip"
       runtime     = "provided.al2023"
       handler     = "bootstrap"
-      routes      = [{ path = "/users", method = "ANY", auth = false }]
+      routes = [
+        { path = "/users", method = "ANY", auth = false },
+        { path = "/users", method = "OPTIONS", auth = false },
+        { path = "/users/{id}", method = "ANY", auth = false },
+        { path = "/users/{id}", method = "OPTIONS", auth = false },
+      ]
     }
   }
 
@@ -68,3 +68,8 @@ output "docs_generator_function_name" {
   description = "Name of the documentation generator Lambda function"
   value       = aws_lambda_function.docs_generator.function_name
 }
+
+output "authorizer_lambda_function_arn" {
+  description = "ARN of the Lambda authorizer function"
+  value       = module.authorizer.lambda_function_arn
+}
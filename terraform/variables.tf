@@ -55,3 +55,49 @@ variable "create_oidc_provider" {
   type        = bool
   default     = false
 }
+
+variable "auth_jwks_url" {
+  description = "JWKS URL of the token issuer trusted by the authorizer (empty disables bearer tokens)"
+  type        = string
+  default     = ""
+}
+
+variable "auth_issuer" {
+  description = "Required iss claim of bearer tokens"
+  type        = string
+  default     = ""
+}
+
+variable "auth_audience" {
+  description = "Required aud claim of bearer tokens"
+  type        = string
+  default     = ""
+}
+
+variable "auth_api_keys_parameter" {
+  description = "Name of the SSM SecureString parameter holding the API keys accepted by the authorizer, as comma-separated key:principal pairs (empty disables API keys)"
+  type        = string
+  default     = ""
+}
+
+variable "auth_response_format" {
+  description = "Authorizer response format: simple or iam"
+  type        = string
+  default     = "simple"
+  validation {
+    condition     = contains(["simple", "iam"], var.auth_response_format)
+    error_message = "Authorizer response format must be simple or iam."
+  }
+}
+
+variable "cors_allowed_origins" {
+  description = "Origins allowed to call the API from browsers: exact origins, patterns such as https://*.example.com, or *"
+  type        = list(string)
+  default     = ["*"]
+}
+
+variable "cors_allow_credentials" {
+  description = "Whether browsers may send credentials on cross-origin requests (requires explicit origins)"
+  type        = bool
+  default     = false
+}